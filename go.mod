@@ -2,7 +2,11 @@ module github.com/ldeng7/go-redis-stack
 
 go 1.18
 
-require github.com/go-redis/redis/v9 v9.0.0-beta.1
+require (
+	github.com/go-redis/redis/v9 v9.0.0-beta.1
+	github.com/golang/snappy v0.0.4
+	google.golang.org/protobuf v1.28.1
+)
 
 require (
 	github.com/cespare/xxhash/v2 v2.1.2 // indirect
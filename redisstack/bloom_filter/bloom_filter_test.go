@@ -0,0 +1,153 @@
+package redisstack
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+)
+
+func TestArgs(t *testing.T) {
+	errorRate, capacity, expansion := 0.01, int64(1000), int64(4)
+	for _, c := range []struct {
+		args []any
+		exp  []any
+	}{
+		{AddArgs("k", "a"), []any{"BF.ADD", "k", "a"}},
+		{ExistsArgs("k", "a"), []any{"BF.EXISTS", "k", "a"}},
+		{InfoArgs("k"), []any{"BF.INFO", "k"}},
+		{InfoFieldArgs("k", InfoFieldCapacity), []any{"BF.INFO", "k", "CAPACITY"}},
+		{InfoFieldArgs("k", InfoFieldExpansion), []any{"BF.INFO", "k", "EXPANSION"}},
+		{InsertArgs("k", nil, false, []string{"a", "b"}), []any{"BF.INSERT", "k", "ITEMS", "a", "b"}},
+		{InsertArgs("k", &Option{ErrorRate: &errorRate, Capacity: &capacity, ExpansionRate: &expansion, NonScaling: true}, true, []string{"a"}),
+			[]any{"BF.INSERT", "k", "CAPACITY", int64(1000), "ERROR", 0.01, "EXPANSION", int64(4), "NONSCALING", "NOCREATE", "ITEMS", "a"}},
+		{LoadChunkArgs("k", 1, "data"), []any{"BF.LOADCHUNK", "k", int64(1), "data"}},
+		{MAddArgs("k", []string{"a", "b"}), []any{"BF.MADD", "k", "a", "b"}},
+		{MExistsArgs("k", []string{"a", "b"}), []any{"BF.MEXISTS", "k", "a", "b"}},
+		{ReserveArgs("k", &Option{ErrorRate: &errorRate, Capacity: &capacity}), []any{"BF.RESERVE", "k", 0.01, int64(1000)}},
+		{ReserveArgs("k", &Option{ErrorRate: &errorRate, Capacity: &capacity, ExpansionRate: &expansion, NonScaling: true}),
+			[]any{"BF.RESERVE", "k", 0.01, int64(1000), "EXPANSION", int64(4), "NONSCALING"}},
+		{ScanDumpArgs("k", 0), []any{"BF.SCANDUMP", "k", int64(0)}},
+	} {
+		if !reflect.DeepEqual(c.args, c.exp) {
+			t.Errorf("got %#v, expected %#v", c.args, c.exp)
+		}
+	}
+}
+
+func TestReplies(t *testing.T) {
+	exchanges, err := redisstacktest.LoadExchanges("testdata/replies.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expansion, capacity := int64(2), int64(100)
+	info := &Info{Capacity: 100, Size: 240, NumFilters: 1, NumItems: 2, ExpansionRate: &expansion, Extra: map[string]any{}}
+	for _, c := range []struct {
+		name  string
+		args  []any
+		parse func(any) (any, error)
+		exp   any
+	}{
+		{"Add", AddArgs("k", "a"), func(val any) (any, error) { return AddResult(val) }, true},
+		{"Exists", ExistsArgs("k", "b"), func(val any) (any, error) { return ExistsResult(val) }, false},
+		{"Info", InfoArgs("k"), func(val any) (any, error) { return InfoResult(val) }, info},
+		{"InfoField", InfoFieldArgs("k", InfoFieldCapacity), func(val any) (any, error) { return InfoFieldResult(val) }, &capacity},
+		{"InfoFieldNonScaling", InfoFieldArgs("k", InfoFieldExpansion), func(val any) (any, error) { return InfoFieldResult(val) }, (*int64)(nil)},
+		{"Insert", InsertArgs("k", nil, false, []string{"a", "b"}), func(val any) (any, error) { return InsertResult(val) }, []bool{true, false}},
+		{"MAdd", MAddArgs("k", []string{"a", "b"}), func(val any) (any, error) { return MAddResult(val) }, []bool{false, true}},
+		{"MExists", MExistsArgs("k", []string{"a"}), func(val any) (any, error) { return MExistsResult(val) }, []bool{true}},
+		{"ScanDump", ScanDumpArgs("k", 0), func(val any) (any, error) { return ScanDumpResult(val) }, &ScanDump{1, "data"}},
+		{"ScanDumpEnd", ScanDumpArgs("k", 1), func(val any) (any, error) { return ScanDumpResult(val) }, &ScanDump{}},
+	} {
+		redisstacktest.CheckExchange(t, exchanges, c.name, c.args, c.parse, c.exp)
+	}
+}
+
+func TestResultErrors(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		parse func(any) (any, error)
+		val   any
+	}{
+		{"InfoFieldEmpty", func(val any) (any, error) { return InfoFieldResult(val) }, []any{}},
+		{"InfoWrongType", func(val any) (any, error) { return InfoResult(val) }, []any{"Capacity", "x"}},
+		{"ScanDumpShort", func(val any) (any, error) { return ScanDumpResult(val) }, []any{int64(1)}},
+		{"ScanDumpData", func(val any) (any, error) { return ScanDumpResult(val) }, []any{int64(1), int64(2)}},
+	} {
+		var perr *redisstack.ParseError
+		if _, err := c.parse(c.val); !errors.As(err, &perr) {
+			t.Errorf("%s: expected a parse error, got %v", c.name, err)
+		}
+	}
+}
+
+func TestInfoNonScaling(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		errorRate, capacity := 0.01, int64(100)
+		if err := red.Do(ctx, ReserveArgs("k", &Option{ErrorRate: &errorRate, Capacity: &capacity, NonScaling: true})...).Err(); err != nil {
+			t.Fatal(err)
+		}
+		if info, err := InfoResult(red.Do(ctx, InfoArgs("k")...).Val()); err != nil {
+			t.Errorf("info: %v", err)
+		} else if info.Capacity != 100 || info.ExpansionRate != nil {
+			t.Errorf("info: %+v", info)
+		}
+		if expansion, err := InfoFieldResult(red.Do(ctx, InfoFieldArgs("k", InfoFieldExpansion)...).Val()); err != nil || expansion != nil {
+			t.Errorf("expansion: %v %v", expansion, err)
+		}
+	})
+}
+
+func TestDumpBatch(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		if err := red.Do(ctx, MAddArgs("src", []string{"a", "b"})...).Err(); err != nil {
+			t.Fatal(err)
+		}
+		dumps, err := DumpBatch(ctx, red, "src")
+		if err != nil {
+			t.Fatal(err)
+		} else if len(dumps) == 0 {
+			t.Fatal("empty dump")
+		}
+		for _, d := range dumps {
+			if err := red.Do(ctx, LoadChunkArgs("dst", d.Iter, d.Data)...).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		res, err := MExistsResult(red.Do(ctx, MExistsArgs("dst", []string{"a", "b", "c"})...).Val())
+		if err != nil || !reflect.DeepEqual(res, []bool{true, true, false}) {
+			t.Errorf("restored filter: %v %v", res, err)
+		}
+	})
+}
+
+func TestMAddBatch(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		if err := red.Do(ctx, "TS.CREATE", "ts").Err(); err != nil {
+			t.Fatal(err)
+		}
+		if err := red.Do(ctx, MAddArgs("a", []string{"x"})...).Err(); err != nil {
+			t.Fatal(err)
+		}
+		replies, err := MAddBatch(ctx, red, map[string][]string{"a": {"x", "y"}, "b": {"z"}, "ts": {"x"}})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if r := replies["a"]; r == nil || r.Err != nil || !reflect.DeepEqual(r.Added, []bool{false, true}) {
+			t.Errorf("a: %+v", r)
+		}
+		if r := replies["b"]; r == nil || r.Err != nil || !reflect.DeepEqual(r.Added, []bool{true}) {
+			t.Errorf("b: %+v", r)
+		}
+		if r := replies["ts"]; r == nil || r.Added != nil || !errors.Is(r.Err, redisstack.ErrWrongType) {
+			t.Errorf("ts: %+v", r)
+		}
+	})
+}
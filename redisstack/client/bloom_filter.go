@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	bloom_filter "github.com/ldeng7/go-redis-stack/redisstack/bloom_filter"
+)
+
+type BloomFilter struct {
+	red redis.UniversalClient
+}
+
+func (h *BloomFilter) Add(ctx context.Context, key string, item string) (bool, error) {
+	return do(ctx, h.red, bloom_filter.AddArgs(key, item), bloom_filter.AddResult)
+}
+
+func (h *BloomFilter) Exists(ctx context.Context, key string, item string) (bool, error) {
+	return do(ctx, h.red, bloom_filter.ExistsArgs(key, item), bloom_filter.ExistsResult)
+}
+
+func (h *BloomFilter) Info(ctx context.Context, key string) (*bloom_filter.Info, error) {
+	return do(ctx, h.red, bloom_filter.InfoArgs(key), bloom_filter.InfoResult)
+}
+
+func (h *BloomFilter) InfoField(ctx context.Context, key string, field bloom_filter.InfoField) (*int64, error) {
+	return do(ctx, h.red, bloom_filter.InfoFieldArgs(key, field), bloom_filter.InfoFieldResult)
+}
+
+func (h *BloomFilter) Insert(ctx context.Context, key string, option *bloom_filter.Option, noCreate bool, items []string) ([]bool, error) {
+	return do(ctx, h.red, bloom_filter.InsertArgs(key, option, noCreate, items), bloom_filter.InsertResult)
+}
+
+func (h *BloomFilter) LoadChunk(ctx context.Context, key string, iter int64, data string) error {
+	return doNoResult(ctx, h.red, bloom_filter.LoadChunkArgs(key, iter, data))
+}
+
+func (h *BloomFilter) MAdd(ctx context.Context, key string, items []string) ([]bool, error) {
+	return do(ctx, h.red, bloom_filter.MAddArgs(key, items), bloom_filter.MAddResult)
+}
+
+func (h *BloomFilter) MAddBatch(ctx context.Context, keyItems map[string][]string) (map[string]*bloom_filter.MAddReply, error) {
+	res, err := bloom_filter.MAddBatch(ctx, h.red, keyItems)
+	return res, redisstack.ClassifyError(err)
+}
+
+func (h *BloomFilter) MExists(ctx context.Context, key string, items []string) ([]bool, error) {
+	return do(ctx, h.red, bloom_filter.MExistsArgs(key, items), bloom_filter.MExistsResult)
+}
+
+func (h *BloomFilter) Reserve(ctx context.Context, key string, option *bloom_filter.Option) error {
+	return doNoResult(ctx, h.red, bloom_filter.ReserveArgs(key, option))
+}
+
+func (h *BloomFilter) ScanDump(ctx context.Context, key string, iter int64) (*bloom_filter.ScanDump, error) {
+	return do(ctx, h.red, bloom_filter.ScanDumpArgs(key, iter), bloom_filter.ScanDumpResult)
+}
+
+func (h *BloomFilter) DumpBatch(ctx context.Context, key string) ([]*bloom_filter.ScanDump, error) {
+	res, err := bloom_filter.DumpBatch(ctx, h.red, key)
+	return res, redisstack.ClassifyError(err)
+}
+
+type BloomFilterPipe struct {
+	pipe redis.Pipeliner
+}
+
+func (h *BloomFilterPipe) Add(ctx context.Context, key string, item string) *BoolFuture {
+	return queue(ctx, h.pipe, bloom_filter.AddArgs(key, item), bloom_filter.AddResult)
+}
+
+func (h *BloomFilterPipe) Exists(ctx context.Context, key string, item string) *BoolFuture {
+	return queue(ctx, h.pipe, bloom_filter.ExistsArgs(key, item), bloom_filter.ExistsResult)
+}
+
+func (h *BloomFilterPipe) Info(ctx context.Context, key string) *Future[*bloom_filter.Info] {
+	return queue(ctx, h.pipe, bloom_filter.InfoArgs(key), bloom_filter.InfoResult)
+}
+
+func (h *BloomFilterPipe) InfoField(ctx context.Context, key string, field bloom_filter.InfoField) *Future[*int64] {
+	return queue(ctx, h.pipe, bloom_filter.InfoFieldArgs(key, field), bloom_filter.InfoFieldResult)
+}
+
+func (h *BloomFilterPipe) Insert(ctx context.Context, key string, option *bloom_filter.Option, noCreate bool, items []string) *BoolSliceFuture {
+	return queue(ctx, h.pipe, bloom_filter.InsertArgs(key, option, noCreate, items), bloom_filter.InsertResult)
+}
+
+func (h *BloomFilterPipe) LoadChunk(ctx context.Context, key string, iter int64, data string) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, bloom_filter.LoadChunkArgs(key, iter, data))
+}
+
+func (h *BloomFilterPipe) MAdd(ctx context.Context, key string, items []string) *BoolSliceFuture {
+	return queue(ctx, h.pipe, bloom_filter.MAddArgs(key, items), bloom_filter.MAddResult)
+}
+
+func (h *BloomFilterPipe) MExists(ctx context.Context, key string, items []string) *BoolSliceFuture {
+	return queue(ctx, h.pipe, bloom_filter.MExistsArgs(key, items), bloom_filter.MExistsResult)
+}
+
+func (h *BloomFilterPipe) Reserve(ctx context.Context, key string, option *bloom_filter.Option) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, bloom_filter.ReserveArgs(key, option))
+}
+
+func (h *BloomFilterPipe) ScanDump(ctx context.Context, key string, iter int64) *Future[*bloom_filter.ScanDump] {
+	return queue(ctx, h.pipe, bloom_filter.ScanDumpArgs(key, iter), bloom_filter.ScanDumpResult)
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+)
+
+type Client struct {
+	red redis.UniversalClient
+}
+
+func New(red redis.UniversalClient) *Client {
+	return &Client{red: red}
+}
+
+func (c *Client) Redis() redis.UniversalClient {
+	return c.red
+}
+
+func (c *Client) BF() *BloomFilter {
+	return &BloomFilter{c.red}
+}
+
+func (c *Client) CF() *CuckooFilter {
+	return &CuckooFilter{c.red}
+}
+
+func (c *Client) CMS() *CountMinSketch {
+	return &CountMinSketch{c.red}
+}
+
+func (c *Client) TopK() *TopK {
+	return &TopK{c.red}
+}
+
+func (c *Client) TDigest() *TDigest {
+	return &TDigest{c.red}
+}
+
+func (c *Client) TS() *TimeSeries {
+	return &TimeSeries{c.red}
+}
+
+func (c *Client) Graph() *Graph {
+	return &Graph{c.red}
+}
+
+func (c *Client) JSON() *JSON {
+	return &JSON{c.red}
+}
+
+func (c *Client) Search() *Search {
+	return &Search{c.red}
+}
+
+func do[T any](ctx context.Context, red redis.UniversalClient, args []any, parse func(any) (T, error)) (T, error) {
+	cmd := red.Do(ctx, args...)
+	if err := cmd.Err(); err != nil {
+		var t T
+		return t, redisstack.ClassifyError(err)
+	}
+	t, err := parse(cmd.Val())
+	return t, withCommand(err, args)
+}
+
+func doNoResult(ctx context.Context, red redis.UniversalClient, args []any) error {
+	return redisstack.ClassifyError(red.Do(ctx, args...).Err())
+}
+
+func isCluster(red redis.UniversalClient) bool {
+	return redisstack.ShardingOf(red) != redisstack.ShardingNone
+}
+
+func checkSameSlot(red redis.UniversalClient, keys ...string) error {
+	return redisstack.ShardingOf(red).CheckSameShard(keys...)
+}
+
+func withCommand(err error, args []any) error {
+	if err == nil {
+		return nil
+	}
+	command, _ := args[0].(string)
+	return redisstack.WithCommand(err, command)
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	count_min_sketch "github.com/ldeng7/go-redis-stack/redisstack/count_min_sketch"
+)
+
+type CountMinSketch struct {
+	red redis.UniversalClient
+}
+
+func (h *CountMinSketch) IncrBy(ctx context.Context, key string, itemAmounts []redisstack.ItemAmount) ([]int64, error) {
+	return do(ctx, h.red, count_min_sketch.IncrByArgs(key, itemAmounts), count_min_sketch.IncrByResult)
+}
+
+func (h *CountMinSketch) Info(ctx context.Context, key string) (*count_min_sketch.Info, error) {
+	return do(ctx, h.red, count_min_sketch.InfoArgs(key), count_min_sketch.InfoResult)
+}
+
+func (h *CountMinSketch) InitByDim(ctx context.Context, key string, width int64, depth int64) error {
+	return doNoResult(ctx, h.red, count_min_sketch.InitByDimArgs(key, width, depth))
+}
+
+func (h *CountMinSketch) InitByProb(ctx context.Context, key string, errorRate float64, probability float64) error {
+	return doNoResult(ctx, h.red, count_min_sketch.InitByProbArgs(key, errorRate, probability))
+}
+
+func (h *CountMinSketch) Merge(ctx context.Context, destKey string, srcKeys []string, weights []int64) error {
+	if err := checkSameSlot(h.red, append([]string{destKey}, srcKeys...)...); err != nil {
+		return err
+	}
+	return doNoResult(ctx, h.red, count_min_sketch.MergeArgs(destKey, srcKeys, weights))
+}
+
+func (h *CountMinSketch) Query(ctx context.Context, key string, items []string) ([]int64, error) {
+	return do(ctx, h.red, count_min_sketch.QueryArgs(key, items), count_min_sketch.QueryResult)
+}
+
+type CountMinSketchPipe struct {
+	pipe redis.Pipeliner
+}
+
+func (h *CountMinSketchPipe) IncrBy(ctx context.Context, key string, itemAmounts []redisstack.ItemAmount) *Int64SliceFuture {
+	return queue(ctx, h.pipe, count_min_sketch.IncrByArgs(key, itemAmounts), count_min_sketch.IncrByResult)
+}
+
+func (h *CountMinSketchPipe) Info(ctx context.Context, key string) *Future[*count_min_sketch.Info] {
+	return queue(ctx, h.pipe, count_min_sketch.InfoArgs(key), count_min_sketch.InfoResult)
+}
+
+func (h *CountMinSketchPipe) InitByDim(ctx context.Context, key string, width int64, depth int64) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, count_min_sketch.InitByDimArgs(key, width, depth))
+}
+
+func (h *CountMinSketchPipe) InitByProb(ctx context.Context, key string, errorRate float64, probability float64) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, count_min_sketch.InitByProbArgs(key, errorRate, probability))
+}
+
+func (h *CountMinSketchPipe) Merge(ctx context.Context, destKey string, srcKeys []string, weights []int64) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, count_min_sketch.MergeArgs(destKey, srcKeys, weights))
+}
+
+func (h *CountMinSketchPipe) Query(ctx context.Context, key string, items []string) *Int64SliceFuture {
+	return queue(ctx, h.pipe, count_min_sketch.QueryArgs(key, items), count_min_sketch.QueryResult)
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	cuckoo_filter "github.com/ldeng7/go-redis-stack/redisstack/cuckoo_filter"
+)
+
+type CuckooFilter struct {
+	red redis.UniversalClient
+}
+
+func (h *CuckooFilter) Add(ctx context.Context, key string, item string) (bool, error) {
+	return do(ctx, h.red, cuckoo_filter.AddArgs(key, item), cuckoo_filter.AddResult)
+}
+
+func (h *CuckooFilter) AddNX(ctx context.Context, key string, item string) (bool, error) {
+	return do(ctx, h.red, cuckoo_filter.AddNXArgs(key, item), cuckoo_filter.AddNXResult)
+}
+
+func (h *CuckooFilter) Count(ctx context.Context, key string, item string) (int64, error) {
+	return do(ctx, h.red, cuckoo_filter.CountArgs(key, item), cuckoo_filter.CountResult)
+}
+
+func (h *CuckooFilter) Del(ctx context.Context, key string, item string) (bool, error) {
+	return do(ctx, h.red, cuckoo_filter.DelArgs(key, item), cuckoo_filter.DelResult)
+}
+
+func (h *CuckooFilter) Exists(ctx context.Context, key string, item string) (bool, error) {
+	return do(ctx, h.red, cuckoo_filter.ExistsArgs(key, item), cuckoo_filter.ExistsResult)
+}
+
+func (h *CuckooFilter) Info(ctx context.Context, key string) (*cuckoo_filter.Info, error) {
+	return do(ctx, h.red, cuckoo_filter.InfoArgs(key), cuckoo_filter.InfoResult)
+}
+
+func (h *CuckooFilter) Insert(ctx context.Context, key string, capacity *int64, noCreate bool, items []string) ([]int64, error) {
+	return do(ctx, h.red, cuckoo_filter.InsertArgs(key, capacity, noCreate, items), cuckoo_filter.InsertResult)
+}
+
+func (h *CuckooFilter) InsertNX(ctx context.Context, key string, capacity *int64, noCreate bool, items []string) ([]int64, error) {
+	return do(ctx, h.red, cuckoo_filter.InsertNXArgs(key, capacity, noCreate, items), cuckoo_filter.InsertNXResult)
+}
+
+func (h *CuckooFilter) LoadChunk(ctx context.Context, key string, iter int64, data string) error {
+	return doNoResult(ctx, h.red, cuckoo_filter.LoadChunkArgs(key, iter, data))
+}
+
+func (h *CuckooFilter) MExists(ctx context.Context, key string, items []string) ([]bool, error) {
+	return do(ctx, h.red, cuckoo_filter.MExistsArgs(key, items), cuckoo_filter.MExistsResult)
+}
+
+func (h *CuckooFilter) Reserve(ctx context.Context, key string, capacity int64, bucketSize *int64, maxIterations *int64, expansionRate *int64) error {
+	return doNoResult(ctx, h.red, cuckoo_filter.ReserveArgs(key, capacity, bucketSize, maxIterations, expansionRate))
+}
+
+func (h *CuckooFilter) ScanDump(ctx context.Context, key string, iter int64) (*cuckoo_filter.ScanDump, error) {
+	return do(ctx, h.red, cuckoo_filter.ScanDumpArgs(key, iter), cuckoo_filter.ScanDumpResult)
+}
+
+func (h *CuckooFilter) DumpBatch(ctx context.Context, key string) ([]*cuckoo_filter.ScanDump, error) {
+	res, err := cuckoo_filter.DumpBatch(ctx, h.red, key)
+	return res, redisstack.ClassifyError(err)
+}
+
+type CuckooFilterPipe struct {
+	pipe redis.Pipeliner
+}
+
+func (h *CuckooFilterPipe) Add(ctx context.Context, key string, item string) *BoolFuture {
+	return queue(ctx, h.pipe, cuckoo_filter.AddArgs(key, item), cuckoo_filter.AddResult)
+}
+
+func (h *CuckooFilterPipe) AddNX(ctx context.Context, key string, item string) *BoolFuture {
+	return queue(ctx, h.pipe, cuckoo_filter.AddNXArgs(key, item), cuckoo_filter.AddNXResult)
+}
+
+func (h *CuckooFilterPipe) Count(ctx context.Context, key string, item string) *Int64Future {
+	return queue(ctx, h.pipe, cuckoo_filter.CountArgs(key, item), cuckoo_filter.CountResult)
+}
+
+func (h *CuckooFilterPipe) Del(ctx context.Context, key string, item string) *BoolFuture {
+	return queue(ctx, h.pipe, cuckoo_filter.DelArgs(key, item), cuckoo_filter.DelResult)
+}
+
+func (h *CuckooFilterPipe) Exists(ctx context.Context, key string, item string) *BoolFuture {
+	return queue(ctx, h.pipe, cuckoo_filter.ExistsArgs(key, item), cuckoo_filter.ExistsResult)
+}
+
+func (h *CuckooFilterPipe) Info(ctx context.Context, key string) *Future[*cuckoo_filter.Info] {
+	return queue(ctx, h.pipe, cuckoo_filter.InfoArgs(key), cuckoo_filter.InfoResult)
+}
+
+func (h *CuckooFilterPipe) Insert(ctx context.Context, key string, capacity *int64, noCreate bool, items []string) *Int64SliceFuture {
+	return queue(ctx, h.pipe, cuckoo_filter.InsertArgs(key, capacity, noCreate, items), cuckoo_filter.InsertResult)
+}
+
+func (h *CuckooFilterPipe) InsertNX(ctx context.Context, key string, capacity *int64, noCreate bool, items []string) *Int64SliceFuture {
+	return queue(ctx, h.pipe, cuckoo_filter.InsertNXArgs(key, capacity, noCreate, items), cuckoo_filter.InsertNXResult)
+}
+
+func (h *CuckooFilterPipe) LoadChunk(ctx context.Context, key string, iter int64, data string) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, cuckoo_filter.LoadChunkArgs(key, iter, data))
+}
+
+func (h *CuckooFilterPipe) MExists(ctx context.Context, key string, items []string) *BoolSliceFuture {
+	return queue(ctx, h.pipe, cuckoo_filter.MExistsArgs(key, items), cuckoo_filter.MExistsResult)
+}
+
+func (h *CuckooFilterPipe) Reserve(ctx context.Context, key string, capacity int64, bucketSize *int64, maxIterations *int64, expansionRate *int64) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, cuckoo_filter.ReserveArgs(key, capacity, bucketSize, maxIterations, expansionRate))
+}
+
+func (h *CuckooFilterPipe) ScanDump(ctx context.Context, key string, iter int64) *Future[*cuckoo_filter.ScanDump] {
+	return queue(ctx, h.pipe, cuckoo_filter.ScanDumpArgs(key, iter), cuckoo_filter.ScanDumpResult)
+}
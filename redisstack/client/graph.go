@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack/graph"
+)
+
+type Graph struct {
+	red redis.UniversalClient
+}
+
+func (h *Graph) Delete(ctx context.Context, key string) error {
+	return doNoResult(ctx, h.red, graph.DeleteArgs(key))
+}
+
+func (h *Graph) List(ctx context.Context) ([]string, error) {
+	return do(ctx, h.red, graph.ListArgs(), graph.ListResult)
+}
+
+func (h *Graph) Query(ctx context.Context, key string, query string) (*graph.ResultSet, error) {
+	return do(ctx, h.red, graph.QueryArgs(key, query), graph.QueryResult)
+}
+
+type GraphPipe struct {
+	pipe redis.Pipeliner
+}
+
+func (h *GraphPipe) Delete(ctx context.Context, key string) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, graph.DeleteArgs(key))
+}
+
+func (h *GraphPipe) List(ctx context.Context) *Future[[]string] {
+	return queue(ctx, h.pipe, graph.ListArgs(), graph.ListResult)
+}
+
+func (h *GraphPipe) Query(ctx context.Context, key string, query string) *Future[*graph.ResultSet] {
+	return queue(ctx, h.pipe, graph.QueryArgs(key, query), graph.QueryResult)
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack/json"
+)
+
+type JSON struct {
+	red redis.UniversalClient
+}
+
+func (h *JSON) ArrAppend(ctx context.Context, key string, path string, values []string) ([]*int64, error) {
+	return do(ctx, h.red, json.ArrAppendArgs(key, path, values), json.ArrAppendResult)
+}
+
+func (h *JSON) ArrIndex(ctx context.Context, key string, path string, value string, start *int64, stop *int64) ([]*int64, error) {
+	return do(ctx, h.red, json.ArrIndexArgs(key, path, value, start, stop), json.ArrIndexResult)
+}
+
+func (h *JSON) ArrInsert(ctx context.Context, key string, path string, index int64, values []string) ([]*int64, error) {
+	return do(ctx, h.red, json.ArrInsertArgs(key, path, index, values), json.ArrInsertResult)
+}
+
+func (h *JSON) ArrLen(ctx context.Context, key string, path string) ([]*int64, error) {
+	return do(ctx, h.red, json.ArrLenArgs(key, path), json.ArrLenResult)
+}
+
+func (h *JSON) ArrPop(ctx context.Context, key string, path string, index *int64) ([]*string, error) {
+	return do(ctx, h.red, json.ArrPopArgs(key, path, index), json.ArrPopResult)
+}
+
+func (h *JSON) ArrTrim(ctx context.Context, key string, path string, start int64, stop int64) ([]*int64, error) {
+	return do(ctx, h.red, json.ArrTrimArgs(key, path, start, stop), json.ArrTrimResult)
+}
+
+func (h *JSON) Del(ctx context.Context, key string, path string) (int64, error) {
+	return do(ctx, h.red, json.DelArgs(key, path), json.DelResult)
+}
+
+func (h *JSON) Get(ctx context.Context, key string, format *json.Format, paths []string) (*string, error) {
+	return do(ctx, h.red, json.GetArgs(key, format, paths), json.GetResult)
+}
+
+func (h *JSON) Merge(ctx context.Context, key string, path string, value string) error {
+	return doNoResult(ctx, h.red, json.MergeArgs(key, path, value))
+}
+
+func (h *JSON) MGet(ctx context.Context, keys []string, path string) ([]*string, error) {
+	return do(ctx, h.red, json.MGetArgs(keys, path), json.MGetResult)
+}
+
+func (h *JSON) MSet(ctx context.Context, kpvs []json.KeyPathValue) error {
+	return doNoResult(ctx, h.red, json.MSetArgs(kpvs))
+}
+
+func (h *JSON) NumIncrBy(ctx context.Context, key string, path string, value float64) ([]*float64, error) {
+	return do(ctx, h.red, json.NumIncrByArgs(key, path, value), json.NumIncrByResult)
+}
+
+func (h *JSON) ObjKeys(ctx context.Context, key string, path string) ([][]string, error) {
+	return do(ctx, h.red, json.ObjKeysArgs(key, path), json.ObjKeysResult)
+}
+
+func (h *JSON) Set(ctx context.Context, key string, path string, value string, mode json.SetMode) (bool, error) {
+	return do(ctx, h.red, json.SetArgs(key, path, value, mode), json.SetResult)
+}
+
+func (h *JSON) StrAppend(ctx context.Context, key string, path string, value string) ([]*int64, error) {
+	return do(ctx, h.red, json.StrAppendArgs(key, path, value), json.StrAppendResult)
+}
+
+func (h *JSON) Toggle(ctx context.Context, key string, path string) ([]*bool, error) {
+	return do(ctx, h.red, json.ToggleArgs(key, path), json.ToggleResult)
+}
+
+func (h *JSON) Type(ctx context.Context, key string, path string) ([]string, error) {
+	return do(ctx, h.red, json.TypeArgs(key, path), json.TypeResult)
+}
+
+type JSONPipe struct {
+	pipe redis.Pipeliner
+}
+
+func (h *JSONPipe) ArrAppend(ctx context.Context, key string, path string, values []string) *Future[[]*int64] {
+	return queue(ctx, h.pipe, json.ArrAppendArgs(key, path, values), json.ArrAppendResult)
+}
+
+func (h *JSONPipe) ArrIndex(ctx context.Context, key string, path string, value string, start *int64, stop *int64) *Future[[]*int64] {
+	return queue(ctx, h.pipe, json.ArrIndexArgs(key, path, value, start, stop), json.ArrIndexResult)
+}
+
+func (h *JSONPipe) ArrInsert(ctx context.Context, key string, path string, index int64, values []string) *Future[[]*int64] {
+	return queue(ctx, h.pipe, json.ArrInsertArgs(key, path, index, values), json.ArrInsertResult)
+}
+
+func (h *JSONPipe) ArrLen(ctx context.Context, key string, path string) *Future[[]*int64] {
+	return queue(ctx, h.pipe, json.ArrLenArgs(key, path), json.ArrLenResult)
+}
+
+func (h *JSONPipe) ArrPop(ctx context.Context, key string, path string, index *int64) *Future[[]*string] {
+	return queue(ctx, h.pipe, json.ArrPopArgs(key, path, index), json.ArrPopResult)
+}
+
+func (h *JSONPipe) ArrTrim(ctx context.Context, key string, path string, start int64, stop int64) *Future[[]*int64] {
+	return queue(ctx, h.pipe, json.ArrTrimArgs(key, path, start, stop), json.ArrTrimResult)
+}
+
+func (h *JSONPipe) Del(ctx context.Context, key string, path string) *Int64Future {
+	return queue(ctx, h.pipe, json.DelArgs(key, path), json.DelResult)
+}
+
+func (h *JSONPipe) Get(ctx context.Context, key string, format *json.Format, paths []string) *Future[*string] {
+	return queue(ctx, h.pipe, json.GetArgs(key, format, paths), json.GetResult)
+}
+
+func (h *JSONPipe) Merge(ctx context.Context, key string, path string, value string) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, json.MergeArgs(key, path, value))
+}
+
+func (h *JSONPipe) MGet(ctx context.Context, keys []string, path string) *Future[[]*string] {
+	return queue(ctx, h.pipe, json.MGetArgs(keys, path), json.MGetResult)
+}
+
+func (h *JSONPipe) MSet(ctx context.Context, kpvs []json.KeyPathValue) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, json.MSetArgs(kpvs))
+}
+
+func (h *JSONPipe) NumIncrBy(ctx context.Context, key string, path string, value float64) *Future[[]*float64] {
+	return queue(ctx, h.pipe, json.NumIncrByArgs(key, path, value), json.NumIncrByResult)
+}
+
+func (h *JSONPipe) ObjKeys(ctx context.Context, key string, path string) *Future[[][]string] {
+	return queue(ctx, h.pipe, json.ObjKeysArgs(key, path), json.ObjKeysResult)
+}
+
+func (h *JSONPipe) Set(ctx context.Context, key string, path string, value string, mode json.SetMode) *BoolFuture {
+	return queue(ctx, h.pipe, json.SetArgs(key, path, value, mode), json.SetResult)
+}
+
+func (h *JSONPipe) StrAppend(ctx context.Context, key string, path string, value string) *Future[[]*int64] {
+	return queue(ctx, h.pipe, json.StrAppendArgs(key, path, value), json.StrAppendResult)
+}
+
+func (h *JSONPipe) Toggle(ctx context.Context, key string, path string) *Future[[]*bool] {
+	return queue(ctx, h.pipe, json.ToggleArgs(key, path), json.ToggleResult)
+}
+
+func (h *JSONPipe) Type(ctx context.Context, key string, path string) *Future[[]string] {
+	return queue(ctx, h.pipe, json.TypeArgs(key, path), json.TypeResult)
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+)
+
+var ErrNotExecuted = errors.New("pipeline not executed")
+
+type Future[T any] struct {
+	args   []any
+	cmd    *redis.Cmd
+	parse  func(any) (T, error)
+	parsed bool
+	val    T
+	err    error
+}
+
+type StatusFuture = Future[string]
+type BoolFuture = Future[bool]
+type BoolSliceFuture = Future[[]bool]
+type Int64Future = Future[int64]
+type Int64SliceFuture = Future[[]int64]
+
+func (f *Future[T]) Result() (T, error) {
+	if !f.parsed {
+		if f.cmd.Err() == nil && f.cmd.Val() == nil {
+			var t T
+			return t, ErrNotExecuted
+		} else if err := f.cmd.Err(); err != nil {
+			f.err = redisstack.ClassifyError(err)
+		} else {
+			f.val, f.err = f.parse(f.cmd.Val())
+			f.err = withCommand(f.err, f.args)
+		}
+		f.parsed = true
+	}
+	return f.val, f.err
+}
+
+func (f *Future[T]) Val() T {
+	val, _ := f.Result()
+	return val
+}
+
+func (f *Future[T]) Err() error {
+	_, err := f.Result()
+	return err
+}
+
+type Pipeline struct {
+	pipe redis.Pipeliner
+}
+
+func (c *Client) Pipeline() *Pipeline {
+	return &Pipeline{c.red.Pipeline()}
+}
+
+func (c *Client) TxPipeline() *Pipeline {
+	return &Pipeline{c.red.TxPipeline()}
+}
+
+func (p *Pipeline) Pipeliner() redis.Pipeliner {
+	return p.pipe
+}
+
+func (p *Pipeline) Len() int {
+	return p.pipe.Len()
+}
+
+func (p *Pipeline) Discard() {
+	p.pipe.Discard()
+}
+
+func (p *Pipeline) Exec(ctx context.Context) error {
+	_, err := p.pipe.Exec(ctx)
+	return err
+}
+
+func (p *Pipeline) BF() *BloomFilterPipe {
+	return &BloomFilterPipe{p.pipe}
+}
+
+func (p *Pipeline) CF() *CuckooFilterPipe {
+	return &CuckooFilterPipe{p.pipe}
+}
+
+func (p *Pipeline) CMS() *CountMinSketchPipe {
+	return &CountMinSketchPipe{p.pipe}
+}
+
+func (p *Pipeline) TopK() *TopKPipe {
+	return &TopKPipe{p.pipe}
+}
+
+func (p *Pipeline) TDigest() *TDigestPipe {
+	return &TDigestPipe{p.pipe}
+}
+
+func (p *Pipeline) TS() *TimeSeriesPipe {
+	return &TimeSeriesPipe{p.pipe}
+}
+
+func (p *Pipeline) Graph() *GraphPipe {
+	return &GraphPipe{p.pipe}
+}
+
+func (p *Pipeline) JSON() *JSONPipe {
+	return &JSONPipe{p.pipe}
+}
+
+func (p *Pipeline) Search() *SearchPipe {
+	return &SearchPipe{p.pipe}
+}
+
+func queue[T any](ctx context.Context, pipe redis.Pipeliner, args []any, parse func(any) (T, error)) *Future[T] {
+	return &Future[T]{args: args, cmd: pipe.Do(ctx, args...), parse: parse}
+}
+
+func failed[T any](err error) *Future[T] {
+	return &Future[T]{parsed: true, err: err}
+}
+
+func queueNoResult(ctx context.Context, pipe redis.Pipeliner, args []any) *StatusFuture {
+	return queue(ctx, pipe, args, redisstack.ParseScalar[string])
+}
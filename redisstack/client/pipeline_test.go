@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+	time_series "github.com/ldeng7/go-redis-stack/redisstack/time_series"
+)
+
+func TestFutureBeforeExec(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		c := New(red)
+		if err := c.TS().Create(ctx, "ts", &time_series.Option{}); err != nil {
+			t.Fatal(err)
+		}
+		p := c.Pipeline()
+		add := p.TS().Add(ctx, &time_series.Sample{Key: "ts", Time: time_series.AtMilli(1000), Value: 1.5}, &time_series.Option{})
+		get := p.TS().Get(ctx, "ts")
+		missing := p.TS().Get(ctx, "missing")
+		if _, err := get.Result(); !errors.Is(err, ErrNotExecuted) {
+			t.Fatalf("expected ErrNotExecuted before Exec, got %v", err)
+		}
+		p.Exec(ctx)
+		if tm, err := add.Result(); err != nil || tm.UnixMilli() != 1000 {
+			t.Errorf("add: %v %v", tm, err)
+		}
+		if sample, err := get.Result(); err != nil || sample.Time.UnixMilli() != 1000 || sample.Value != 1.5 {
+			t.Errorf("get: %v %v", sample, err)
+		}
+		if _, err := missing.Result(); !errors.Is(err, redisstack.ErrKeyNotFound) {
+			t.Errorf("missing: %v", err)
+		}
+	})
+}
+
+func TestFailedFuture(t *testing.T) {
+	f := failed[time.Time](time_series.ErrInvalidQuery)
+	if _, err := f.Result(); err != time_series.ErrInvalidQuery {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestRangeValidate(t *testing.T) {
+	ctx := context.Background()
+	c := New(redisstacktest.NewTestClient(t, 3))
+	for _, q := range []*time_series.MultiQuery{
+		{FromTime: time_series.ServerTime},
+		{FiltersByTime: []time_series.Timestamp{time_series.Latest}},
+	} {
+		if _, err := c.TS().Range(ctx, "ts", q); !errors.Is(err, time_series.ErrInvalidQuery) {
+			t.Errorf("range %+v: %v", q, err)
+		}
+		if _, err := c.TS().RevRange(ctx, "ts", q); !errors.Is(err, time_series.ErrInvalidQuery) {
+			t.Errorf("revrange %+v: %v", q, err)
+		}
+		p := c.Pipeline()
+		if _, err := p.TS().Range(ctx, "ts", q).Result(); !errors.Is(err, time_series.ErrInvalidQuery) {
+			t.Errorf("pipelined range %+v: %v", q, err)
+		}
+		if _, err := p.TS().RevRange(ctx, "ts", q).Result(); !errors.Is(err, time_series.ErrInvalidQuery) {
+			t.Errorf("pipelined revrange %+v: %v", q, err)
+		}
+	}
+}
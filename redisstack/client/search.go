@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack/search"
+)
+
+type Search struct {
+	red redis.UniversalClient
+}
+
+func (h *Search) Aggregate(ctx context.Context, index string, agg *search.Aggregation) (*search.AggregateResultSet, error) {
+	return do(ctx, h.red, search.AggregateArgs(index, agg), search.AggregateResult)
+}
+
+func (h *Search) AggregateCursor(index string, agg *search.Aggregation) *search.AggregateCursor {
+	return search.NewAggregateCursor(h.red, index, agg)
+}
+
+func (h *Search) Create(ctx context.Context, index string, option *search.IndexOption, schema []*search.Field) error {
+	return doNoResult(ctx, h.red, search.CreateArgs(index, option, schema))
+}
+
+func (h *Search) CursorDel(ctx context.Context, index string, cursor int64) error {
+	return doNoResult(ctx, h.red, search.CursorDelArgs(index, cursor))
+}
+
+func (h *Search) CursorRead(ctx context.Context, index string, cursor int64, count int64) (*search.AggregateCursorResultSet, error) {
+	return do(ctx, h.red, search.CursorReadArgs(index, cursor, count), search.AggregateCursorResult)
+}
+
+func (h *Search) DropIndex(ctx context.Context, index string, deleteDocs bool) error {
+	return doNoResult(ctx, h.red, search.DropIndexArgs(index, deleteDocs))
+}
+
+func (h *Search) Info(ctx context.Context, index string) (map[string]any, error) {
+	return do(ctx, h.red, search.InfoArgs(index), search.InfoResult)
+}
+
+func (h *Search) KNN(ctx context.Context, index string, q *search.KNNQuery, blob []byte) ([]*search.VectorHit, error) {
+	args, err := search.KNNArgs(index, q, blob)
+	if err != nil {
+		return nil, err
+	}
+	return do(ctx, h.red, args, func(val any) ([]*search.VectorHit, error) {
+		return search.KNNResult(val, q)
+	})
+}
+
+func (h *Search) List(ctx context.Context) ([]string, error) {
+	return do(ctx, h.red, search.ListArgs(), search.ListResult)
+}
+
+func (h *Search) VectorRange(ctx context.Context, index string, q *search.VectorRangeQuery, blob []byte, filter search.QueryWritable) ([]*search.VectorHit, error) {
+	args, err := search.VectorRangeArgs(index, q, blob, filter)
+	if err != nil {
+		return nil, err
+	}
+	return do(ctx, h.red, args, func(val any) ([]*search.VectorHit, error) {
+		return search.VectorRangeResult(val, q)
+	})
+}
+
+func (h *Search) Search(ctx context.Context, index string, query string, option *search.SearchOption) (*search.ResultSet, error) {
+	return do(ctx, h.red, search.SearchArgs(index, query, option), func(val any) (*search.ResultSet, error) {
+		return search.SearchResult(val, option)
+	})
+}
+
+type SearchPipe struct {
+	pipe redis.Pipeliner
+}
+
+func (h *SearchPipe) Aggregate(ctx context.Context, index string, agg *search.Aggregation) *Future[*search.AggregateResultSet] {
+	return queue(ctx, h.pipe, search.AggregateArgs(index, agg), search.AggregateResult)
+}
+
+func (h *SearchPipe) Create(ctx context.Context, index string, option *search.IndexOption, schema []*search.Field) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, search.CreateArgs(index, option, schema))
+}
+
+func (h *SearchPipe) CursorDel(ctx context.Context, index string, cursor int64) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, search.CursorDelArgs(index, cursor))
+}
+
+func (h *SearchPipe) CursorRead(ctx context.Context, index string, cursor int64, count int64) *Future[*search.AggregateCursorResultSet] {
+	return queue(ctx, h.pipe, search.CursorReadArgs(index, cursor, count), search.AggregateCursorResult)
+}
+
+func (h *SearchPipe) DropIndex(ctx context.Context, index string, deleteDocs bool) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, search.DropIndexArgs(index, deleteDocs))
+}
+
+func (h *SearchPipe) Info(ctx context.Context, index string) *Future[map[string]any] {
+	return queue(ctx, h.pipe, search.InfoArgs(index), search.InfoResult)
+}
+
+func (h *SearchPipe) KNN(ctx context.Context, index string, q *search.KNNQuery, blob []byte) *Future[[]*search.VectorHit] {
+	args, err := search.KNNArgs(index, q, blob)
+	if err != nil {
+		return failed[[]*search.VectorHit](err)
+	}
+	return queue(ctx, h.pipe, args, func(val any) ([]*search.VectorHit, error) {
+		return search.KNNResult(val, q)
+	})
+}
+
+func (h *SearchPipe) List(ctx context.Context) *Future[[]string] {
+	return queue(ctx, h.pipe, search.ListArgs(), search.ListResult)
+}
+
+func (h *SearchPipe) VectorRange(ctx context.Context, index string, q *search.VectorRangeQuery, blob []byte, filter search.QueryWritable) *Future[[]*search.VectorHit] {
+	args, err := search.VectorRangeArgs(index, q, blob, filter)
+	if err != nil {
+		return failed[[]*search.VectorHit](err)
+	}
+	return queue(ctx, h.pipe, args, func(val any) ([]*search.VectorHit, error) {
+		return search.VectorRangeResult(val, q)
+	})
+}
+
+func (h *SearchPipe) Search(ctx context.Context, index string, query string, option *search.SearchOption) *Future[*search.ResultSet] {
+	return queue(ctx, h.pipe, search.SearchArgs(index, query, option), func(val any) (*search.ResultSet, error) {
+		return search.SearchResult(val, option)
+	})
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v9"
+	t_digest "github.com/ldeng7/go-redis-stack/redisstack/t_digest"
+)
+
+type TDigest struct {
+	red redis.UniversalClient
+}
+
+func (h *TDigest) Add(ctx context.Context, key string, values []float64) error {
+	return doNoResult(ctx, h.red, t_digest.AddArgs(key, values))
+}
+
+func (h *TDigest) ByRank(ctx context.Context, key string, ranks []int64) ([]float64, error) {
+	return do(ctx, h.red, t_digest.ByRankArgs(key, ranks), t_digest.ByRankResult)
+}
+
+func (h *TDigest) ByRevRank(ctx context.Context, key string, ranks []int64) ([]float64, error) {
+	return do(ctx, h.red, t_digest.ByRevRankArgs(key, ranks), t_digest.ByRevRankResult)
+}
+
+func (h *TDigest) CDF(ctx context.Context, key string, values []float64) ([]float64, error) {
+	return do(ctx, h.red, t_digest.CDFArgs(key, values), t_digest.CDFResult)
+}
+
+func (h *TDigest) Create(ctx context.Context, key string, compression *int64) error {
+	return doNoResult(ctx, h.red, t_digest.CreateArgs(key, compression))
+}
+
+func (h *TDigest) Info(ctx context.Context, key string) (*t_digest.Info, error) {
+	return do(ctx, h.red, t_digest.InfoArgs(key), t_digest.InfoResult)
+}
+
+func (h *TDigest) Max(ctx context.Context, key string) (float64, error) {
+	return do(ctx, h.red, t_digest.MaxArgs(key), t_digest.MaxResult)
+}
+
+func (h *TDigest) Merge(ctx context.Context, destKey string, srcKeys []string, compression *int64, override bool) error {
+	if err := checkSameSlot(h.red, append([]string{destKey}, srcKeys...)...); err != nil {
+		return err
+	}
+	return doNoResult(ctx, h.red, t_digest.MergeArgs(destKey, srcKeys, compression, override))
+}
+
+func (h *TDigest) Min(ctx context.Context, key string) (float64, error) {
+	return do(ctx, h.red, t_digest.MinArgs(key), t_digest.MinResult)
+}
+
+func (h *TDigest) Quantile(ctx context.Context, key string, quantiles []float64) ([]float64, error) {
+	return do(ctx, h.red, t_digest.QuantileArgs(key, quantiles), t_digest.QuantileResult)
+}
+
+func (h *TDigest) Rank(ctx context.Context, key string, values []float64) ([]int64, error) {
+	return do(ctx, h.red, t_digest.RankArgs(key, values), t_digest.RankResult)
+}
+
+func (h *TDigest) Reset(ctx context.Context, key string) error {
+	return doNoResult(ctx, h.red, t_digest.ResetArgs(key))
+}
+
+func (h *TDigest) RevRank(ctx context.Context, key string, values []float64) ([]int64, error) {
+	return do(ctx, h.red, t_digest.RevRankArgs(key, values), t_digest.RevRankResult)
+}
+
+func (h *TDigest) TrimmedMean(ctx context.Context, key string, lowCutQuantile float64, highCutQuantile float64) (float64, error) {
+	return do(ctx, h.red, t_digest.TrimmedMeanArgs(key, lowCutQuantile, highCutQuantile), t_digest.TrimmedMeanResult)
+}
+
+type TDigestPipe struct {
+	pipe redis.Pipeliner
+}
+
+func (h *TDigestPipe) Add(ctx context.Context, key string, values []float64) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, t_digest.AddArgs(key, values))
+}
+
+func (h *TDigestPipe) ByRank(ctx context.Context, key string, ranks []int64) *Future[[]float64] {
+	return queue(ctx, h.pipe, t_digest.ByRankArgs(key, ranks), t_digest.ByRankResult)
+}
+
+func (h *TDigestPipe) ByRevRank(ctx context.Context, key string, ranks []int64) *Future[[]float64] {
+	return queue(ctx, h.pipe, t_digest.ByRevRankArgs(key, ranks), t_digest.ByRevRankResult)
+}
+
+func (h *TDigestPipe) CDF(ctx context.Context, key string, values []float64) *Future[[]float64] {
+	return queue(ctx, h.pipe, t_digest.CDFArgs(key, values), t_digest.CDFResult)
+}
+
+func (h *TDigestPipe) Create(ctx context.Context, key string, compression *int64) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, t_digest.CreateArgs(key, compression))
+}
+
+func (h *TDigestPipe) Info(ctx context.Context, key string) *Future[*t_digest.Info] {
+	return queue(ctx, h.pipe, t_digest.InfoArgs(key), t_digest.InfoResult)
+}
+
+func (h *TDigestPipe) Max(ctx context.Context, key string) *Future[float64] {
+	return queue(ctx, h.pipe, t_digest.MaxArgs(key), t_digest.MaxResult)
+}
+
+func (h *TDigestPipe) Merge(ctx context.Context, destKey string, srcKeys []string, compression *int64, override bool) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, t_digest.MergeArgs(destKey, srcKeys, compression, override))
+}
+
+func (h *TDigestPipe) Min(ctx context.Context, key string) *Future[float64] {
+	return queue(ctx, h.pipe, t_digest.MinArgs(key), t_digest.MinResult)
+}
+
+func (h *TDigestPipe) Quantile(ctx context.Context, key string, quantiles []float64) *Future[[]float64] {
+	return queue(ctx, h.pipe, t_digest.QuantileArgs(key, quantiles), t_digest.QuantileResult)
+}
+
+func (h *TDigestPipe) Rank(ctx context.Context, key string, values []float64) *Future[[]int64] {
+	return queue(ctx, h.pipe, t_digest.RankArgs(key, values), t_digest.RankResult)
+}
+
+func (h *TDigestPipe) Reset(ctx context.Context, key string) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, t_digest.ResetArgs(key))
+}
+
+func (h *TDigestPipe) RevRank(ctx context.Context, key string, values []float64) *Future[[]int64] {
+	return queue(ctx, h.pipe, t_digest.RevRankArgs(key, values), t_digest.RevRankResult)
+}
+
+func (h *TDigestPipe) TrimmedMean(ctx context.Context, key string, lowCutQuantile float64, highCutQuantile float64) *Future[float64] {
+	return queue(ctx, h.pipe, t_digest.TrimmedMeanArgs(key, lowCutQuantile, highCutQuantile), t_digest.TrimmedMeanResult)
+}
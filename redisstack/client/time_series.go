@@ -0,0 +1,277 @@
+package client
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	time_series "github.com/ldeng7/go-redis-stack/redisstack/time_series"
+)
+
+type TimeSeries struct {
+	red redis.UniversalClient
+}
+
+func (h *TimeSeries) Add(ctx context.Context, sample *time_series.Sample, option *time_series.Option) (*time.Time, error) {
+	return do(ctx, h.red, time_series.AddArgs(sample, option), time_series.AddResult)
+}
+
+func (h *TimeSeries) Alter(ctx context.Context, key string, option *time_series.Option) error {
+	return doNoResult(ctx, h.red, time_series.AlterArgs(key, option))
+}
+
+func (h *TimeSeries) Create(ctx context.Context, key string, option *time_series.Option) error {
+	return doNoResult(ctx, h.red, time_series.CreateArgs(key, option))
+}
+
+func (h *TimeSeries) CreateRule(ctx context.Context, srcKey string, destKey string, aggregateType time_series.AggregateType, bucketDuration time.Duration, alignTime *time.Duration) error {
+	if err := checkSameSlot(h.red, srcKey, destKey); err != nil {
+		return err
+	}
+	return doNoResult(ctx, h.red, time_series.CreateRuleArgs(srcKey, destKey, aggregateType, bucketDuration, alignTime))
+}
+
+func (h *TimeSeries) DecrBy(ctx context.Context, key string, value float64, t time_series.Timestamp, option *time_series.Option) (*time.Time, error) {
+	args, err := time_series.DecrByArgs(key, value, t, option)
+	if err != nil {
+		return nil, err
+	}
+	return do(ctx, h.red, args, time_series.DecrByResult)
+}
+
+func (h *TimeSeries) Diff(ctx context.Context, c *time_series.Compaction) ([]*time_series.Drift, error) {
+	return c.Diff(ctx, h.red)
+}
+
+func (h *TimeSeries) Del(ctx context.Context, key string, fromTime time_series.Timestamp, toTime time_series.Timestamp) (int64, error) {
+	return do(ctx, h.red, time_series.DelArgs(key, fromTime, toTime), time_series.DelResult)
+}
+
+func (h *TimeSeries) DeleteRule(ctx context.Context, srcKey string, destKey string) error {
+	return doNoResult(ctx, h.red, time_series.DeleteRuleArgs(srcKey, destKey))
+}
+
+func (h *TimeSeries) Export(ctx context.Context, w io.Writer, opt *time_series.ExportOption) error {
+	return time_series.Export(ctx, h.red, w, opt)
+}
+
+func (h *TimeSeries) Get(ctx context.Context, key string) (*time_series.Sample, error) {
+	return do(ctx, h.red, time_series.GetArgs(key), time_series.GetResult)
+}
+
+func (h *TimeSeries) Import(ctx context.Context, r io.Reader, opt *time_series.ImportOption) (*time_series.ImportStats, error) {
+	return time_series.Import(ctx, h.red, r, opt)
+}
+
+func (h *TimeSeries) IncrBy(ctx context.Context, key string, value float64, t time_series.Timestamp, option *time_series.Option) (*time.Time, error) {
+	args, err := time_series.IncrByArgs(key, value, t, option)
+	if err != nil {
+		return nil, err
+	}
+	return do(ctx, h.red, args, time_series.IncrByResult)
+}
+
+func (h *TimeSeries) Info(ctx context.Context, key string, debug bool) (*time_series.Info, error) {
+	return do(ctx, h.red, time_series.InfoArgs(key, debug), time_series.InfoResult)
+}
+
+func (h *TimeSeries) MAdd(ctx context.Context, samples []*time_series.Sample) ([]*time.Time, error) {
+	if isCluster(h.red) {
+		res, err := time_series.MAddBatch(ctx, h.red, samples)
+		return res, redisstack.ClassifyError(err)
+	}
+	return do(ctx, h.red, time_series.MAddArgs(samples), time_series.MAddResult)
+}
+
+func (h *TimeSeries) MAddAutoCreate(ctx context.Context, samples []*time_series.Sample, resolve time_series.OptionResolver) ([]*time_series.MAddReply, error) {
+	res, err := time_series.MAddAutoCreate(ctx, h.red, samples, resolve)
+	return res, redisstack.ClassifyError(err)
+}
+
+func (h *TimeSeries) MGet(ctx context.Context, q *time_series.MultiQuery) (map[string]*time_series.MultiSample, error) {
+	return do(ctx, h.red, time_series.MGetArgs(q), time_series.MGetResult)
+}
+
+func (h *TimeSeries) MRange(ctx context.Context, q *time_series.MultiQuery) (map[string]*time_series.MultiSample, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	return do(ctx, h.red, time_series.MRangeArgs(q), time_series.MRangeResult)
+}
+
+func (h *TimeSeries) MRangeGroup(ctx context.Context, q *time_series.MultiQuery) (map[string]*time_series.GroupedSeries, error) {
+	if err := q.ValidateGroup(); err != nil {
+		return nil, err
+	}
+	return do(ctx, h.red, time_series.MRangeArgs(q), time_series.MRangeGroupResult)
+}
+
+func (h *TimeSeries) MRangeIterator(q *time_series.MultiQuery, pageSize int) *time_series.MRangeIterator {
+	return time_series.NewMRangeIterator(h.red, q, pageSize)
+}
+
+func (h *TimeSeries) MRevRange(ctx context.Context, q *time_series.MultiQuery) (map[string]*time_series.MultiSample, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	return do(ctx, h.red, time_series.MRevRangeArgs(q), time_series.MRevRangeResult)
+}
+
+func (h *TimeSeries) MRevRangeGroup(ctx context.Context, q *time_series.MultiQuery) (map[string]*time_series.GroupedSeries, error) {
+	if err := q.ValidateGroup(); err != nil {
+		return nil, err
+	}
+	return do(ctx, h.red, time_series.MRevRangeArgs(q), time_series.MRevRangeGroupResult)
+}
+
+func (h *TimeSeries) MRevRangeIterator(q *time_series.MultiQuery, pageSize int) *time_series.MRangeIterator {
+	return time_series.NewMRevRangeIterator(h.red, q, pageSize)
+}
+
+func (h *TimeSeries) QueryIndex(ctx context.Context, filters []string) ([]string, error) {
+	return do(ctx, h.red, time_series.QueryIndexArgs(filters), time_series.QueryIndexResult)
+}
+
+func (h *TimeSeries) Range(ctx context.Context, key string, q *time_series.MultiQuery) ([]*time_series.Sample, error) {
+	if err := q.ValidateRange(); err != nil {
+		return nil, err
+	}
+	return do(ctx, h.red, time_series.RangeArgs(key, q), time_series.RangeResult)
+}
+
+func (h *TimeSeries) RangeIterator(key string, q *time_series.MultiQuery, pageSize int) *time_series.RangeIterator {
+	return time_series.NewRangeIterator(h.red, key, q, pageSize)
+}
+
+func (h *TimeSeries) Reconcile(ctx context.Context, c *time_series.Compaction) ([]*time_series.Drift, error) {
+	if err := checkSameSlot(h.red, c.Keys()...); err != nil {
+		return nil, err
+	}
+	return c.Reconcile(ctx, h.red)
+}
+
+func (h *TimeSeries) RevRange(ctx context.Context, key string, q *time_series.MultiQuery) ([]*time_series.Sample, error) {
+	if err := q.ValidateRange(); err != nil {
+		return nil, err
+	}
+	return do(ctx, h.red, time_series.RevRangeArgs(key, q), time_series.RevRangeResult)
+}
+
+func (h *TimeSeries) RevRangeIterator(key string, q *time_series.MultiQuery, pageSize int) *time_series.RangeIterator {
+	return time_series.NewRevRangeIterator(h.red, key, q, pageSize)
+}
+
+func (h *TimeSeries) Writer(opt *time_series.WriterOption) *time_series.Writer {
+	return time_series.NewWriter(h.red, opt)
+}
+
+type SampleFuture = Future[*time_series.Sample]
+type SampleSliceFuture = Future[[]*time_series.Sample]
+
+type TimeSeriesPipe struct {
+	pipe redis.Pipeliner
+}
+
+func (h *TimeSeriesPipe) Add(ctx context.Context, sample *time_series.Sample, option *time_series.Option) *Future[*time.Time] {
+	return queue(ctx, h.pipe, time_series.AddArgs(sample, option), time_series.AddResult)
+}
+
+func (h *TimeSeriesPipe) Alter(ctx context.Context, key string, option *time_series.Option) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, time_series.AlterArgs(key, option))
+}
+
+func (h *TimeSeriesPipe) Create(ctx context.Context, key string, option *time_series.Option) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, time_series.CreateArgs(key, option))
+}
+
+func (h *TimeSeriesPipe) CreateRule(ctx context.Context, srcKey string, destKey string, aggregateType time_series.AggregateType, bucketDuration time.Duration, alignTime *time.Duration) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, time_series.CreateRuleArgs(srcKey, destKey, aggregateType, bucketDuration, alignTime))
+}
+
+func (h *TimeSeriesPipe) DecrBy(ctx context.Context, key string, value float64, t time_series.Timestamp, option *time_series.Option) *Future[*time.Time] {
+	args, err := time_series.DecrByArgs(key, value, t, option)
+	if err != nil {
+		return failed[*time.Time](err)
+	}
+	return queue(ctx, h.pipe, args, time_series.DecrByResult)
+}
+
+func (h *TimeSeriesPipe) Del(ctx context.Context, key string, fromTime time_series.Timestamp, toTime time_series.Timestamp) *Int64Future {
+	return queue(ctx, h.pipe, time_series.DelArgs(key, fromTime, toTime), time_series.DelResult)
+}
+
+func (h *TimeSeriesPipe) DeleteRule(ctx context.Context, srcKey string, destKey string) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, time_series.DeleteRuleArgs(srcKey, destKey))
+}
+
+func (h *TimeSeriesPipe) Get(ctx context.Context, key string) *SampleFuture {
+	return queue(ctx, h.pipe, time_series.GetArgs(key), time_series.GetResult)
+}
+
+func (h *TimeSeriesPipe) IncrBy(ctx context.Context, key string, value float64, t time_series.Timestamp, option *time_series.Option) *Future[*time.Time] {
+	args, err := time_series.IncrByArgs(key, value, t, option)
+	if err != nil {
+		return failed[*time.Time](err)
+	}
+	return queue(ctx, h.pipe, args, time_series.IncrByResult)
+}
+
+func (h *TimeSeriesPipe) Info(ctx context.Context, key string, debug bool) *Future[*time_series.Info] {
+	return queue(ctx, h.pipe, time_series.InfoArgs(key, debug), time_series.InfoResult)
+}
+
+func (h *TimeSeriesPipe) MAdd(ctx context.Context, samples []*time_series.Sample) *Future[[]*time.Time] {
+	return queue(ctx, h.pipe, time_series.MAddArgs(samples), time_series.MAddResult)
+}
+
+func (h *TimeSeriesPipe) MGet(ctx context.Context, q *time_series.MultiQuery) *Future[map[string]*time_series.MultiSample] {
+	return queue(ctx, h.pipe, time_series.MGetArgs(q), time_series.MGetResult)
+}
+
+func (h *TimeSeriesPipe) MRange(ctx context.Context, q *time_series.MultiQuery) *Future[map[string]*time_series.MultiSample] {
+	if err := q.Validate(); err != nil {
+		return failed[map[string]*time_series.MultiSample](err)
+	}
+	return queue(ctx, h.pipe, time_series.MRangeArgs(q), time_series.MRangeResult)
+}
+
+func (h *TimeSeriesPipe) MRangeGroup(ctx context.Context, q *time_series.MultiQuery) *Future[map[string]*time_series.GroupedSeries] {
+	if err := q.ValidateGroup(); err != nil {
+		return failed[map[string]*time_series.GroupedSeries](err)
+	}
+	return queue(ctx, h.pipe, time_series.MRangeArgs(q), time_series.MRangeGroupResult)
+}
+
+func (h *TimeSeriesPipe) MRevRange(ctx context.Context, q *time_series.MultiQuery) *Future[map[string]*time_series.MultiSample] {
+	if err := q.Validate(); err != nil {
+		return failed[map[string]*time_series.MultiSample](err)
+	}
+	return queue(ctx, h.pipe, time_series.MRevRangeArgs(q), time_series.MRevRangeResult)
+}
+
+func (h *TimeSeriesPipe) MRevRangeGroup(ctx context.Context, q *time_series.MultiQuery) *Future[map[string]*time_series.GroupedSeries] {
+	if err := q.ValidateGroup(); err != nil {
+		return failed[map[string]*time_series.GroupedSeries](err)
+	}
+	return queue(ctx, h.pipe, time_series.MRevRangeArgs(q), time_series.MRevRangeGroupResult)
+}
+
+func (h *TimeSeriesPipe) QueryIndex(ctx context.Context, filters []string) *Future[[]string] {
+	return queue(ctx, h.pipe, time_series.QueryIndexArgs(filters), time_series.QueryIndexResult)
+}
+
+func (h *TimeSeriesPipe) Range(ctx context.Context, key string, q *time_series.MultiQuery) *SampleSliceFuture {
+	if err := q.ValidateRange(); err != nil {
+		return failed[[]*time_series.Sample](err)
+	}
+	return queue(ctx, h.pipe, time_series.RangeArgs(key, q), time_series.RangeResult)
+}
+
+func (h *TimeSeriesPipe) RevRange(ctx context.Context, key string, q *time_series.MultiQuery) *SampleSliceFuture {
+	if err := q.ValidateRange(); err != nil {
+		return failed[[]*time_series.Sample](err)
+	}
+	return queue(ctx, h.pipe, time_series.RevRangeArgs(key, q), time_series.RevRangeResult)
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	"github.com/ldeng7/go-redis-stack/redisstack/top_k"
+)
+
+type TopK struct {
+	red redis.UniversalClient
+}
+
+func (h *TopK) Add(ctx context.Context, key string, items []string) ([]*string, error) {
+	return do(ctx, h.red, top_k.AddArgs(key, items), top_k.AddResult)
+}
+
+func (h *TopK) Count(ctx context.Context, key string, items []string) ([]int64, error) {
+	return do(ctx, h.red, top_k.CountArgs(key, items), top_k.CountResult)
+}
+
+func (h *TopK) IncrBy(ctx context.Context, key string, itemAmounts []redisstack.ItemAmount) ([]*string, error) {
+	return do(ctx, h.red, top_k.IncrByArgs(key, itemAmounts), top_k.IncrByResult)
+}
+
+func (h *TopK) Info(ctx context.Context, key string) (*top_k.Info, error) {
+	return do(ctx, h.red, top_k.InfoArgs(key), top_k.InfoResult)
+}
+
+func (h *TopK) List(ctx context.Context, key string) ([]string, error) {
+	return do(ctx, h.red, top_k.ListArgs(key), top_k.ListResult)
+}
+
+func (h *TopK) ListWithCount(ctx context.Context, key string) ([]redisstack.ItemAmount, error) {
+	return do(ctx, h.red, top_k.ListWithCountArgs(key), top_k.ListWithCountResult)
+}
+
+func (h *TopK) Query(ctx context.Context, key string, items []string) ([]bool, error) {
+	return do(ctx, h.red, top_k.QueryArgs(key, items), top_k.QueryResult)
+}
+
+func (h *TopK) Reserve(ctx context.Context, key string, topK int64, info *top_k.Info) error {
+	return doNoResult(ctx, h.red, top_k.ReserveArgs(key, topK, info))
+}
+
+type TopKPipe struct {
+	pipe redis.Pipeliner
+}
+
+func (h *TopKPipe) Add(ctx context.Context, key string, items []string) *Future[[]*string] {
+	return queue(ctx, h.pipe, top_k.AddArgs(key, items), top_k.AddResult)
+}
+
+func (h *TopKPipe) Count(ctx context.Context, key string, items []string) *Int64SliceFuture {
+	return queue(ctx, h.pipe, top_k.CountArgs(key, items), top_k.CountResult)
+}
+
+func (h *TopKPipe) IncrBy(ctx context.Context, key string, itemAmounts []redisstack.ItemAmount) *Future[[]*string] {
+	return queue(ctx, h.pipe, top_k.IncrByArgs(key, itemAmounts), top_k.IncrByResult)
+}
+
+func (h *TopKPipe) Info(ctx context.Context, key string) *Future[*top_k.Info] {
+	return queue(ctx, h.pipe, top_k.InfoArgs(key), top_k.InfoResult)
+}
+
+func (h *TopKPipe) List(ctx context.Context, key string) *Future[[]string] {
+	return queue(ctx, h.pipe, top_k.ListArgs(key), top_k.ListResult)
+}
+
+func (h *TopKPipe) ListWithCount(ctx context.Context, key string) *Future[[]redisstack.ItemAmount] {
+	return queue(ctx, h.pipe, top_k.ListWithCountArgs(key), top_k.ListWithCountResult)
+}
+
+func (h *TopKPipe) Query(ctx context.Context, key string, items []string) *BoolSliceFuture {
+	return queue(ctx, h.pipe, top_k.QueryArgs(key, items), top_k.QueryResult)
+}
+
+func (h *TopKPipe) Reserve(ctx context.Context, key string, topK int64, info *top_k.Info) *StatusFuture {
+	return queueNoResult(ctx, h.pipe, top_k.ReserveArgs(key, topK, info))
+}
@@ -0,0 +1,149 @@
+package redisstack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-redis/redis/v9"
+)
+
+const SlotCount = 16384
+
+var ErrCrossSlot = errors.New("keys do not hash to the same slot")
+
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func HashTag(key string) string {
+	if s := strings.IndexByte(key, '{'); s >= 0 {
+		if e := strings.IndexByte(key[s+1:], '}'); e > 0 {
+			return key[s+1 : s+1+e]
+		}
+	}
+	return key
+}
+
+func KeySlot(key string) int {
+	return int(crc16(HashTag(key)) % SlotCount)
+}
+
+func KeyWithHashTag(tag string, name string) string {
+	return "{" + tag + "}" + name
+}
+
+func CheckSameSlot(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	slot := KeySlot(keys[0])
+	for _, key := range keys[1:] {
+		if slot1 := KeySlot(key); slot1 != slot {
+			return fmt.Errorf("%w: %q (slot %d) and %q (slot %d)", ErrCrossSlot, keys[0], slot, key, slot1)
+		}
+	}
+	return nil
+}
+
+func CheckSameHashTag(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	tag := HashTag(keys[0])
+	for _, key := range keys[1:] {
+		if tag1 := HashTag(key); tag1 != tag {
+			return fmt.Errorf("%w: %q (hash tag %q) and %q (hash tag %q)", ErrCrossSlot, keys[0], tag, key, tag1)
+		}
+	}
+	return nil
+}
+
+func groupKeys[T comparable](keys []string, f func(string) T) [][]int {
+	groups := [][]int{}
+	keyGroups := map[T]int{}
+	for i, key := range keys {
+		k := f(key)
+		g, ok := keyGroups[k]
+		if !ok {
+			g = len(groups)
+			keyGroups[k] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+func GroupBySlot(keys []string) [][]int {
+	return groupKeys(keys, KeySlot)
+}
+
+func GroupByHashTag(keys []string) [][]int {
+	return groupKeys(keys, HashTag)
+}
+
+type Sharding byte
+
+const (
+	ShardingNone = Sharding(iota)
+	ShardingSlot
+	ShardingHashTag
+)
+
+type slotShardedClient interface {
+	ForEachMaster(ctx context.Context, fn func(ctx context.Context, client *redis.Client) error) error
+}
+
+type hashTagShardedClient interface {
+	ForEachShard(ctx context.Context, fn func(ctx context.Context, client *redis.Client) error) error
+}
+
+func ShardingOf(red redis.UniversalClient) Sharding {
+	switch red.(type) {
+	case slotShardedClient:
+		return ShardingSlot
+	case hashTagShardedClient:
+		return ShardingHashTag
+	}
+	return ShardingNone
+}
+
+func (s Sharding) CheckSameShard(keys ...string) error {
+	switch s {
+	case ShardingSlot:
+		return CheckSameSlot(keys...)
+	case ShardingHashTag:
+		return CheckSameHashTag(keys...)
+	}
+	return nil
+}
+
+func (s Sharding) Group(keys []string) [][]int {
+	switch s {
+	case ShardingSlot:
+		return GroupBySlot(keys)
+	case ShardingHashTag:
+		return GroupByHashTag(keys)
+	}
+	if len(keys) == 0 {
+		return [][]int{}
+	}
+	group := make([]int, len(keys))
+	for i := range group {
+		group[i] = i
+	}
+	return [][]int{group}
+}
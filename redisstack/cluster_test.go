@@ -0,0 +1,87 @@
+package redisstack
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-redis/redis/v9"
+)
+
+func TestKeySlot(t *testing.T) {
+	for key, slot := range map[string]int{
+		"foo":                  12182,
+		"{user1000}.following": KeySlot("user1000"),
+		"foo{}{bar}":           KeySlot("foo{}{bar}"),
+		"foo{bar}{zap}":        KeySlot("bar"),
+	} {
+		if s := KeySlot(key); s != slot {
+			t.Errorf("%s: slot %d, expected %d", key, s, slot)
+		}
+	}
+	if HashTag("foo{}{bar}") != "foo{}{bar}" {
+		t.Error("empty hash tag must hash the whole key")
+	}
+}
+
+func TestCheckSameShard(t *testing.T) {
+	a, b := KeyWithHashTag("u1", "a"), KeyWithHashTag("u1", "b")
+	for _, s := range []Sharding{ShardingNone, ShardingSlot, ShardingHashTag} {
+		if err := s.CheckSameShard(a, b); err != nil {
+			t.Errorf("%d: %v", s, err)
+		}
+	}
+	if err := ShardingNone.CheckSameShard("a", "b"); err != nil {
+		t.Error(err)
+	}
+	if err := ShardingSlot.CheckSameShard("a", "b"); !errors.Is(err, ErrCrossSlot) {
+		t.Errorf("slot: %v", err)
+	}
+	if err := ShardingHashTag.CheckSameShard("{x}1", "{y}1"); !errors.Is(err, ErrCrossSlot) {
+		t.Errorf("hash tag: %v", err)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	keys := []string{"{a}1", "{b}1", "{a}2", "{b}2", "c"}
+	exp := [][]int{{0, 2}, {1, 3}, {4}}
+	for _, s := range []Sharding{ShardingSlot, ShardingHashTag} {
+		if groups := s.Group(keys); !reflect.DeepEqual(groups, exp) {
+			t.Errorf("%d: %v", s, groups)
+		}
+	}
+	if groups := ShardingNone.Group(keys); !reflect.DeepEqual(groups, [][]int{{0, 1, 2, 3, 4}}) {
+		t.Errorf("none: %v", groups)
+	}
+	if groups := ShardingNone.Group(nil); len(groups) != 0 {
+		t.Errorf("none, empty: %v", groups)
+	}
+}
+
+type wrappedClusterClient struct {
+	*redis.ClusterClient
+}
+
+func TestShardingOf(t *testing.T) {
+	cluster := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"127.0.0.1:0"}})
+	defer cluster.Close()
+	ring := redis.NewRing(&redis.RingOptions{Addrs: map[string]string{"a": "127.0.0.1:0"}})
+	defer ring.Close()
+	single := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	defer single.Close()
+
+	for _, c := range []struct {
+		name string
+		red  redis.UniversalClient
+		exp  Sharding
+	}{
+		{"Cluster", cluster, ShardingSlot},
+		{"WrappedCluster", wrappedClusterClient{cluster}, ShardingSlot},
+		{"Ring", ring, ShardingHashTag},
+		{"Client", single, ShardingNone},
+	} {
+		if s := ShardingOf(c.red); s != c.exp {
+			t.Errorf("%s: %d, expected %d", c.name, s, c.exp)
+		}
+	}
+}
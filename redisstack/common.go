@@ -1,137 +1,292 @@
-package redisstack
-
-import (
-	"errors"
-)
-
-var ErrInvalidType = errors.New("invalid type")
-var ErrInvalidData = errors.New("invalid data")
-
-type StringAnyPair struct {
-	Key   string
-	Value any
-}
-
-type ItemAmount struct {
-	Item   string
-	Amount int64
-}
-
-func ArgsByKeyAndItems(command string, key string, items []string) []any {
-	args := make([]any, 2+len(items))
-	args[0], args[1] = command, key
-	for i, item := range items {
-		args[i+2] = item
-	}
-	return args
-}
-
-func ArgsByKeyAndItemAmounts(command string, key string, itemAmounts []ItemAmount) []any {
-	args := make([]any, 2+len(itemAmounts)*2)
-	args[0], args[1] = command, key
-	for i, itemAmount := range itemAmounts {
-		args[i*2+2], args[i*2+3] = itemAmount.Item, itemAmount.Amount
-	}
-	return args
-}
-
-func ParseScalar[T any](val any) (T, error) {
-	t, ok := val.(T)
-	if !ok {
-		var t T
-		return t, ErrInvalidType
-	}
-	return t, nil
-}
-
-func ParseIntBool(val any) (bool, error) {
-	i, err := ParseScalar[int64](val)
-	return i != 0, err
-}
-
-func ParseArray(val any, minLen int) ([]any, error) {
-	arr, ok := val.([]any)
-	if !ok {
-		return nil, ErrInvalidType
-	} else if minLen > 0 && len(arr) < minLen {
-		return nil, ErrInvalidData
-	}
-	return arr, nil
-}
-
-func ParseToMappedArray[T any](val any, minLen int, f func(any) (T, error)) ([]T, error) {
-	arr, err := ParseArray(val, minLen)
-	if err != nil {
-		return nil, err
-	}
-	res := make([]T, len(arr))
-	for i, e := range arr {
-		if res[i], err = f(e); err != nil {
-			return nil, err
-		}
-	}
-	return res, nil
-}
-
-func ParseScalarArray[T any](val any, minLen int) ([]T, error) {
-	return ParseToMappedArray(val, minLen, ParseScalar[T])
-}
-
-func ParseNullableScalarArray[T any](val any, minLen int) ([]*T, error) {
-	return ParseToMappedArray(val, minLen, func(e any) (*T, error) {
-		if e1, ok := e.(T); ok {
-			return &e1, nil
-		}
-		return nil, nil
-	})
-}
-
-func ParseIntBoolArray(val any, minLen int) ([]bool, error) {
-	return ParseToMappedArray(val, minLen, ParseIntBool)
-}
-
-func ParseStringPairArray(val any, minLen int) ([][2]string, error) {
-	return ParseToMappedArray(val, minLen, func(e any) ([2]string, error) {
-		arr, err := ParseArray(e, 2)
-		if err != nil {
-			return [2]string{}, err
-		}
-		s0, _ := arr[0].(string)
-		s1, _ := arr[1].(string)
-		return [2]string{s0, s1}, nil
-	})
-}
-
-func ParseStringAnyPairArray(val any, minLen int) ([]StringAnyPair, error) {
-	return ParseToMappedArray(val, minLen, func(e any) (StringAnyPair, error) {
-		arr, err := ParseArray(e, 2)
-		if err != nil {
-			return StringAnyPair{}, err
-		}
-		k, _ := arr[0].(string)
-		return StringAnyPair{k, arr[1]}, nil
-	})
-}
-
-func ParseToInterlacedMappedArray[T any](val any, minLen int, f func(any, any) (T, error)) ([]T, error) {
-	arr, err := ParseArray(val, minLen*2)
-	if err != nil {
-		return nil, err
-	}
-	l := len(arr) / 2
-	res := make([]T, l)
-	for i := 0; i < l; i++ {
-		if res[i], err = f(arr[i*2], arr[i*2+1]); err != nil {
-			return nil, err
-		}
-	}
-	return res, nil
-}
-
-func ParseItemAmountInterlacedArray(val any, minLen int) ([]ItemAmount, error) {
-	return ParseToInterlacedMappedArray(val, minLen, func(e1, e2 any) (ItemAmount, error) {
-		item, _ := e1.(string)
-		amount, _ := e2.(int64)
-		return ItemAmount{item, amount}, nil
-	})
-}
+package redisstack
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidType = errors.New("invalid type")
+var ErrInvalidData = errors.New("invalid data")
+
+type StringAnyPair struct {
+	Key   string
+	Value any
+}
+
+type ItemAmount struct {
+	Item   string
+	Amount int64
+}
+
+func ArgsByKeyAndItems(command string, key string, items []string) []any {
+	args := make([]any, 2+len(items))
+	args[0], args[1] = command, key
+	for i, item := range items {
+		args[i+2] = item
+	}
+	return args
+}
+
+func ArgsByKeyAndItemAmounts(command string, key string, itemAmounts []ItemAmount) []any {
+	args := make([]any, 2+len(itemAmounts)*2)
+	args[0], args[1] = command, key
+	for i, itemAmount := range itemAmounts {
+		args[i*2+2], args[i*2+3] = itemAmount.Item, itemAmount.Amount
+	}
+	return args
+}
+
+func ParseScalar[T any](val any) (T, error) {
+	t, ok := val.(T)
+	if !ok {
+		return t, NewTypeError(fmt.Sprintf("%T", t), val)
+	}
+	return t, nil
+}
+
+func ParseIntBool(val any) (bool, error) {
+	if b, ok := val.(bool); ok {
+		return b, nil
+	}
+	i, err := ParseScalar[int64](val)
+	return i != 0, err
+}
+
+func ParseFloat(val any) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			if strings.EqualFold(strings.TrimLeft(v, "+-"), "nan") {
+				return math.NaN(), nil
+			}
+			return 0, NewDataError("float", "string", val)
+		}
+		return f, nil
+	}
+	return 0, NewTypeError("float64", val)
+}
+
+func ParseArray(val any, minLen int) ([]any, error) {
+	arr, ok := val.([]any)
+	if !ok {
+		return nil, NewTypeError("[]interface {}", val)
+	} else if minLen > 0 && len(arr) < minLen {
+		return nil, NewDataError(fmt.Sprintf("array of length >= %d", minLen), fmt.Sprintf("array of length %d", len(arr)), val)
+	}
+	return arr, nil
+}
+
+func mapEntries(m map[any]any) [][2]any {
+	entries := make([][2]any, 0, len(m))
+	for k, v := range m {
+		entries = append(entries, [2]any{k, v})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return fmt.Sprint(entries[i][0]) < fmt.Sprint(entries[j][0])
+	})
+	return entries
+}
+
+func ParseMap(val any) (map[string]any, error) {
+	switch v := val.(type) {
+	case map[any]any:
+		res := make(map[string]any, len(v))
+		for k, e := range v {
+			k1, err := ParseScalar[string](k)
+			if err != nil {
+				return nil, WithPathKey(err, fmt.Sprint(k))
+			}
+			res[k1] = e
+		}
+		return res, nil
+	case []any:
+		if len(v)%2 != 0 {
+			return nil, NewDataError("array of even length", fmt.Sprintf("array of length %d", len(v)), val)
+		}
+		res := make(map[string]any, len(v)/2)
+		for i := 0; i < len(v); i += 2 {
+			k, err := ParseScalar[string](v[i])
+			if err != nil {
+				return nil, WithPathIndex(err, i)
+			}
+			res[k] = v[i+1]
+		}
+		return res, nil
+	}
+	return nil, NewTypeError("map[interface {}]interface {}", val)
+}
+
+func ParseInfo(val any, fields map[string]any) (map[string]any, error) {
+	m, err := ParseMap(val)
+	if err != nil {
+		return nil, err
+	}
+	extra := map[string]any{}
+	for k, v := range m {
+		field, ok := fields[k]
+		if !ok {
+			extra[k] = v
+			continue
+		} else if v == nil {
+			continue
+		}
+		switch f := field.(type) {
+		case *int64:
+			*f, err = ParseScalar[int64](v)
+		case **int64:
+			var i int64
+			i, err = ParseScalar[int64](v)
+			*f = &i
+		case *float64:
+			*f, err = ParseFloat(v)
+		case *string:
+			*f, err = ParseScalar[string](v)
+		case *any:
+			*f = v
+		}
+		if err != nil {
+			return nil, WithPathKey(err, k)
+		}
+	}
+	return extra, nil
+}
+
+func ParseToMappedArray[T any](val any, minLen int, f func(any) (T, error)) ([]T, error) {
+	arr, err := ParseArray(val, minLen)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]T, len(arr))
+	for i, e := range arr {
+		if res[i], err = f(e); err != nil {
+			return nil, WithPathIndex(err, i)
+		}
+	}
+	return res, nil
+}
+
+func ParseScalarArray[T any](val any, minLen int) ([]T, error) {
+	return ParseToMappedArray(val, minLen, ParseScalar[T])
+}
+
+func ParseFloatArray(val any, minLen int) ([]float64, error) {
+	return ParseToMappedArray(val, minLen, ParseFloat)
+}
+
+func ParseNullableScalarArray[T any](val any, minLen int) ([]*T, error) {
+	return ParseToMappedArray(val, minLen, func(e any) (*T, error) {
+		if e == nil {
+			return nil, nil
+		}
+		e1, err := ParseScalar[T](e)
+		if err != nil {
+			return nil, err
+		}
+		return &e1, nil
+	})
+}
+
+func ParseIntBoolArray(val any, minLen int) ([]bool, error) {
+	return ParseToMappedArray(val, minLen, ParseIntBool)
+}
+
+func parseStringPair(e1 any, e2 any) ([2]string, error) {
+	s0, err := ParseScalar[string](e1)
+	if err != nil {
+		return [2]string{}, err
+	}
+	s1, err := ParseScalar[string](e2)
+	if err != nil {
+		return [2]string{}, err
+	}
+	return [2]string{s0, s1}, nil
+}
+
+func ParseStringPairArray(val any, minLen int) ([][2]string, error) {
+	if m, ok := val.(map[any]any); ok {
+		return ParseToInterlacedMappedArray(m, minLen, parseStringPair)
+	}
+	return ParseToMappedArray(val, minLen, func(e any) ([2]string, error) {
+		arr, err := ParseArray(e, 2)
+		if err != nil {
+			return [2]string{}, err
+		}
+		s0, err := ParseScalar[string](arr[0])
+		if err != nil {
+			return [2]string{}, WithPathIndex(err, 0)
+		}
+		s1, err := ParseScalar[string](arr[1])
+		if err != nil {
+			return [2]string{}, WithPathIndex(err, 1)
+		}
+		return [2]string{s0, s1}, nil
+	})
+}
+
+func ParseStringAnyPairArray(val any, minLen int) ([]StringAnyPair, error) {
+	if m, ok := val.(map[any]any); ok {
+		return ParseToInterlacedMappedArray(m, minLen, func(e1, e2 any) (StringAnyPair, error) {
+			k, err := ParseScalar[string](e1)
+			return StringAnyPair{k, e2}, err
+		})
+	}
+	return ParseToMappedArray(val, minLen, func(e any) (StringAnyPair, error) {
+		arr, err := ParseArray(e, 2)
+		if err != nil {
+			return StringAnyPair{}, err
+		}
+		k, err := ParseScalar[string](arr[0])
+		if err != nil {
+			return StringAnyPair{}, WithPathIndex(err, 0)
+		}
+		return StringAnyPair{k, arr[1]}, nil
+	})
+}
+
+func ParseToInterlacedMappedArray[T any](val any, minLen int, f func(any, any) (T, error)) ([]T, error) {
+	if m, ok := val.(map[any]any); ok {
+		if minLen > 0 && len(m) < minLen {
+			return nil, NewDataError(fmt.Sprintf("map of length >= %d", minLen), fmt.Sprintf("map of length %d", len(m)), val)
+		}
+		res := make([]T, len(m))
+		var err error
+		for i, entry := range mapEntries(m) {
+			if res[i], err = f(entry[0], entry[1]); err != nil {
+				return nil, WithPathKey(err, fmt.Sprint(entry[0]))
+			}
+		}
+		return res, nil
+	}
+	arr, err := ParseArray(val, minLen*2)
+	if err != nil {
+		return nil, err
+	}
+	l := len(arr) / 2
+	res := make([]T, l)
+	for i := 0; i < l; i++ {
+		if res[i], err = f(arr[i*2], arr[i*2+1]); err != nil {
+			return nil, WithPathIndex(err, i*2+1)
+		}
+	}
+	return res, nil
+}
+
+func ParseItemAmountInterlacedArray(val any, minLen int) ([]ItemAmount, error) {
+	return ParseToInterlacedMappedArray(val, minLen, func(e1, e2 any) (ItemAmount, error) {
+		item, err := ParseScalar[string](e1)
+		if err != nil {
+			return ItemAmount{}, err
+		}
+		amount, err := ParseScalar[int64](e2)
+		return ItemAmount{item, amount}, err
+	})
+}
@@ -0,0 +1,66 @@
+package redisstack
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+)
+
+func TestArgs(t *testing.T) {
+	for _, c := range []struct {
+		args []any
+		exp  []any
+	}{
+		{IncrByArgs("k", []redisstack.ItemAmount{{Item: "a", Amount: 1}, {Item: "b", Amount: 2}}), []any{"CMS.INCRBY", "k", "a", int64(1), "b", int64(2)}},
+		{InfoArgs("k"), []any{"CMS.INFO", "k"}},
+		{InitByDimArgs("k", 2000, 5), []any{"CMS.INITBYDIM", "k", int64(2000), int64(5)}},
+		{InitByProbArgs("k", 0.001, 0.01), []any{"CMS.INITBYPROB", "k", 0.001, 0.01}},
+		{MergeArgs("d", []string{"a", "b"}, nil), []any{"CMS.MERGE", "d", 2, "a", "b"}},
+		{MergeArgs("d", []string{"a", "b"}, []int64{1, 3}), []any{"CMS.MERGE", "d", 2, "a", "b", "WEIGHTS", int64(1), int64(3)}},
+		{QueryArgs("k", []string{"a", "b"}), []any{"CMS.QUERY", "k", "a", "b"}},
+	} {
+		if !reflect.DeepEqual(c.args, c.exp) {
+			t.Errorf("got %#v, expected %#v", c.args, c.exp)
+		}
+	}
+}
+
+func TestReplies(t *testing.T) {
+	exchanges, err := redisstacktest.LoadExchanges("testdata/replies.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name  string
+		args  []any
+		parse func(any) (any, error)
+		exp   any
+	}{
+		{"IncrBy", IncrByArgs("k", []redisstack.ItemAmount{{Item: "a", Amount: 1}, {Item: "b", Amount: 2}}), func(val any) (any, error) { return IncrByResult(val) }, []int64{1, 2}},
+		{"Info", InfoArgs("k"), func(val any) (any, error) { return InfoResult(val) }, &Info{Width: 2000, Depth: 5, Count: 3, Extra: map[string]any{}}},
+		{"Query", QueryArgs("k", []string{"a", "b"}), func(val any) (any, error) { return QueryResult(val) }, []int64{3, 0}},
+	} {
+		redisstacktest.CheckExchange(t, exchanges, c.name, c.args, c.parse, c.exp)
+	}
+}
+
+func TestResultErrors(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		parse func(any) (any, error)
+		val   any
+	}{
+		{"IncrBy", func(val any) (any, error) { return IncrByResult(val) }, []any{"x"}},
+		{"Info", func(val any) (any, error) { return InfoResult(val) }, []any{"width", "x"}},
+		{"InfoOdd", func(val any) (any, error) { return InfoResult(val) }, []any{"width"}},
+		{"Query", func(val any) (any, error) { return QueryResult(val) }, int64(1)},
+	} {
+		var perr *redisstack.ParseError
+		if _, err := c.parse(c.val); !errors.As(err, &perr) {
+			t.Errorf("%s: expected a parse error, got %v", c.name, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package redisstack
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+)
+
+func TestArgs(t *testing.T) {
+	capacity, bucketSize, maxIterations, expansion := int64(1000), int64(4), int64(20), int64(2)
+	for _, c := range []struct {
+		args []any
+		exp  []any
+	}{
+		{AddArgs("k", "a"), []any{"CF.ADD", "k", "a"}},
+		{AddNXArgs("k", "a"), []any{"CF.ADDNX", "k", "a"}},
+		{CountArgs("k", "a"), []any{"CF.COUNT", "k", "a"}},
+		{DelArgs("k", "a"), []any{"CF.DEL", "k", "a"}},
+		{ExistsArgs("k", "a"), []any{"CF.EXISTS", "k", "a"}},
+		{InfoArgs("k"), []any{"CF.INFO", "k"}},
+		{InsertArgs("k", nil, false, []string{"a", "b"}), []any{"CF.INSERT", "k", "ITEMS", "a", "b"}},
+		{InsertArgs("k", &capacity, true, []string{"a"}), []any{"CF.INSERT", "k", "CAPACITY", int64(1000), "NOCREATE", "ITEMS", "a"}},
+		{InsertNXArgs("k", &capacity, false, []string{"a"}), []any{"CF.INSERTNX", "k", "CAPACITY", int64(1000), "ITEMS", "a"}},
+		{LoadChunkArgs("k", 1, "data"), []any{"CF.LOADCHUNK", "k", int64(1), "data"}},
+		{MExistsArgs("k", []string{"a", "b"}), []any{"CF.MEXISTS", "k", "a", "b"}},
+		{ReserveArgs("k", 1000, nil, nil, nil), []any{"CF.RESERVE", "k", int64(1000)}},
+		{ReserveArgs("k", 1000, &bucketSize, &maxIterations, &expansion),
+			[]any{"CF.RESERVE", "k", int64(1000), "BUCKETSIZE", int64(4), "MAXITERATIONS", int64(20), "EXPANSION", int64(2)}},
+		{ScanDumpArgs("k", 0), []any{"CF.SCANDUMP", "k", int64(0)}},
+	} {
+		if !reflect.DeepEqual(c.args, c.exp) {
+			t.Errorf("got %#v, expected %#v", c.args, c.exp)
+		}
+	}
+}
+
+func TestReplies(t *testing.T) {
+	exchanges, err := redisstacktest.LoadExchanges("testdata/replies.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &Info{Size: 1080, NumBuckets: 512, NumFilters: 1, NumItemsInserted: 3, NumItemsDeleted: 1,
+		BucketSize: 2, ExpansionRate: 1, MaxIteration: 20, Extra: map[string]any{}}
+	for _, c := range []struct {
+		name  string
+		args  []any
+		parse func(any) (any, error)
+		exp   any
+	}{
+		{"Add", AddArgs("k", "a"), func(val any) (any, error) { return AddResult(val) }, true},
+		{"AddNX", AddNXArgs("k", "a"), func(val any) (any, error) { return AddNXResult(val) }, false},
+		{"Count", CountArgs("k", "a"), func(val any) (any, error) { return CountResult(val) }, int64(2)},
+		{"Del", DelArgs("k", "a"), func(val any) (any, error) { return DelResult(val) }, true},
+		{"Exists", ExistsArgs("k", "b"), func(val any) (any, error) { return ExistsResult(val) }, false},
+		{"Info", InfoArgs("k"), func(val any) (any, error) { return InfoResult(val) }, info},
+		{"Insert", InsertArgs("k", nil, false, []string{"a", "b"}), func(val any) (any, error) { return InsertResult(val) }, []int64{1, -1}},
+		{"InsertNX", InsertNXArgs("k", nil, false, []string{"a", "b"}), func(val any) (any, error) { return InsertNXResult(val) }, []int64{1, 0}},
+		{"MExists", MExistsArgs("k", []string{"a", "b"}), func(val any) (any, error) { return MExistsResult(val) }, []bool{true, false}},
+		{"ScanDump", ScanDumpArgs("k", 0), func(val any) (any, error) { return ScanDumpResult(val) }, &ScanDump{1, "data"}},
+		{"ScanDumpEnd", ScanDumpArgs("k", 1), func(val any) (any, error) { return ScanDumpResult(val) }, &ScanDump{}},
+	} {
+		redisstacktest.CheckExchange(t, exchanges, c.name, c.args, c.parse, c.exp)
+	}
+}
+
+func TestResultErrors(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		parse func(any) (any, error)
+		val   any
+	}{
+		{"Count", func(val any) (any, error) { return CountResult(val) }, "x"},
+		{"Insert", func(val any) (any, error) { return InsertResult(val) }, []any{"x"}},
+		{"InfoWrongType", func(val any) (any, error) { return InfoResult(val) }, []any{"Size", "x"}},
+		{"ScanDumpShort", func(val any) (any, error) { return ScanDumpResult(val) }, []any{int64(1)}},
+		{"ScanDumpData", func(val any) (any, error) { return ScanDumpResult(val) }, []any{int64(1), int64(2)}},
+	} {
+		var perr *redisstack.ParseError
+		if _, err := c.parse(c.val); !errors.As(err, &perr) {
+			t.Errorf("%s: expected a parse error, got %v", c.name, err)
+		}
+	}
+}
+
+func TestDumpBatch(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		if err := red.Do(ctx, InsertArgs("src", nil, false, []string{"a", "b"})...).Err(); err != nil {
+			t.Fatal(err)
+		}
+		dumps, err := DumpBatch(ctx, red, "src")
+		if err != nil {
+			t.Fatal(err)
+		} else if len(dumps) == 0 {
+			t.Fatal("empty dump")
+		}
+		for _, d := range dumps {
+			if err := red.Do(ctx, LoadChunkArgs("dst", d.Iter, d.Data)...).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		res, err := MExistsResult(red.Do(ctx, MExistsArgs("dst", []string{"a", "b", "c"})...).Val())
+		if err != nil || !reflect.DeepEqual(res, []bool{true, true, false}) {
+			t.Errorf("restored filter: %v %v", res, err)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package redisstack
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type ParseError struct {
+	Command  string
+	Path     string
+	Expected string
+	Actual   string
+	Value    string
+	Err      error
+}
+
+const parseErrorValueMaxLen = 64
+
+func newParseError(err error, expected string, actual string, val any) *ParseError {
+	v := fmt.Sprintf("%v", val)
+	if len(v) > parseErrorValueMaxLen {
+		v = v[:parseErrorValueMaxLen] + "..."
+	}
+	return &ParseError{Expected: expected, Actual: actual, Value: v, Err: err}
+}
+
+func NewTypeError(expected string, val any) *ParseError {
+	return newParseError(ErrInvalidType, expected, fmt.Sprintf("%T", val), val)
+}
+
+func NewDataError(expected string, actual string, val any) *ParseError {
+	return newParseError(ErrInvalidData, expected, actual, val)
+}
+
+func (e *ParseError) Error() string {
+	sb := &strings.Builder{}
+	sb.WriteString(e.Err.Error())
+	if len(e.Command) > 0 || len(e.Path) > 0 {
+		sb.WriteString(" at ")
+		if len(e.Command) > 0 {
+			sb.WriteString(e.Command)
+			sb.WriteByte(' ')
+		}
+		sb.WriteString("reply")
+		sb.WriteString(e.Path)
+	}
+	sb.WriteString(": expected ")
+	sb.WriteString(e.Expected)
+	sb.WriteString(", got ")
+	sb.WriteString(e.Actual)
+	if len(e.Value) > 0 {
+		sb.WriteString(" (")
+		sb.WriteString(e.Value)
+		sb.WriteByte(')')
+	}
+	return sb.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func prependPath(err error, elem string) error {
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		pe.Path = elem + pe.Path
+	}
+	return err
+}
+
+func WithPathIndex(err error, i int) error {
+	return prependPath(err, "["+strconv.Itoa(i)+"]")
+}
+
+func WithPathKey(err error, key string) error {
+	return prependPath(err, "["+strconv.Quote(key)+"]")
+}
+
+func WithCommand(err error, command string) error {
+	var pe *ParseError
+	if errors.As(err, &pe) && len(pe.Command) == 0 {
+		pe.Command = command
+	}
+	return err
+}
+
+var (
+	ErrKeyNotFound     = errors.New("key not found")
+	ErrKeyExists       = errors.New("key already exists")
+	ErrWrongType       = errors.New("wrong type")
+	ErrDuplicateSample = errors.New("duplicate sample")
+	ErrTimestampTooOld = errors.New("timestamp too old")
+	ErrInvalidValue    = errors.New("invalid value")
+	ErrQuerySyntax     = errors.New("query syntax error")
+)
+
+type ServerError struct {
+	Err  error
+	Kind error
+}
+
+func (e *ServerError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ServerError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ServerError) Is(target error) bool {
+	return target == e.Kind
+}
+
+var serverErrorKinds = []struct {
+	pattern string
+	kind    error
+}{
+	{"WRONGTYPE", ErrWrongType},
+	{"TSDB: the key is not a TSDB key", ErrWrongType},
+	{"TSDB: the key does not exist", ErrKeyNotFound},
+	{"TSDB: key already exists", ErrKeyExists},
+	{"update is not supported when DUPLICATE_POLICY is set to BLOCK", ErrDuplicateSample},
+	{"TSDB: Timestamp is older than retention", ErrTimestampTooOld},
+	{"TSDB: timestamp must be equal to or higher than the maximum existing timestamp", ErrTimestampTooOld},
+	{"TSDB: invalid value", ErrInvalidValue},
+	{"TopK: key does not exist", ErrKeyNotFound},
+	{"TopK: key already exists", ErrKeyExists},
+	{"CMS: key does not exist", ErrKeyNotFound},
+	{"CMS: key already exists", ErrKeyExists},
+	{"T-Digest: key does not exist", ErrKeyNotFound},
+	{"T-Digest: key already exists", ErrKeyExists},
+	{"ERR not found", ErrKeyNotFound},
+	{"ERR item exists", ErrKeyExists},
+	{"errMsg: Invalid input", ErrQuerySyntax},
+	{"Syntax error", ErrQuerySyntax},
+}
+
+type redisError interface {
+	error
+	RedisError()
+}
+
+func ClassifyError(err error) error {
+	re, ok := err.(redisError)
+	if !ok {
+		return err
+	}
+	msg := re.Error()
+	for _, k := range serverErrorKinds {
+		if strings.Contains(msg, k.pattern) {
+			return &ServerError{Err: err, Kind: k.kind}
+		}
+	}
+	return err
+}
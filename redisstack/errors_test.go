@@ -0,0 +1,19 @@
+package redisstack
+
+import "testing"
+
+func TestParseErrorMessage(t *testing.T) {
+	for _, c := range []struct {
+		err error
+		exp string
+	}{
+		{NewTypeError("int64", "x"), "invalid type: expected int64, got string (x)"},
+		{WithPathKey(WithPathIndex(NewTypeError("int64", "x"), 1), "k"), `invalid type at reply["k"][1]: expected int64, got string (x)`},
+		{WithCommand(WithPathIndex(NewDataError("array of length 2", "array of length 1", nil), 0), "TS.GET"),
+			"invalid data at TS.GET reply[0]: expected array of length 2, got array of length 1 (<nil>)"},
+	} {
+		if s := c.err.Error(); s != c.exp {
+			t.Errorf("got %q, expected %q", s, c.exp)
+		}
+	}
+}
@@ -1,134 +1,147 @@
-package graph
-
-import "github.com/ldeng7/go-redis-stack/redisstack"
-
-func DeleteArgs(key string) []any {
-	return []any{"GRAPH.DELETE", key}
-}
-
-func ListResult(val any) ([]string, error) {
-	return redisstack.ParseScalarArray[string](val, 0)
-}
-
-func QueryArgs(key string, query string) []any {
-	return []any{"GRAPH.QUERY", key, query}
-}
-
-type ResultSet struct {
-	Header []string
-	Rows   [][]any
-}
-
-func QueryResult(val any) (*ResultSet, error) {
-	arr, err := redisstack.ParseArray(val, 2)
-	if err != nil {
-		return nil, err
-	}
-	res := &ResultSet{}
-	if res.Header, err = redisstack.ParseScalarArray[string](arr[0], 0); err != nil {
-		return nil, err
-	}
-
-	nCols := len(res.Header)
-	arr1, err := redisstack.ParseArray(arr[1], 0)
-	if err != nil {
-		return nil, err
-	}
-	rows := make([][]any, len(arr1))
-	for i, e := range arr1 {
-		rows[i], err = redisstack.ParseArray(e, nCols)
-		if err != nil {
-			return nil, err
-		}
-	}
-	res.Rows = rows
-
-	return res, nil
-}
-
-type Node struct {
-	ID         int64
-	Label      *string
-	Properties []redisstack.StringAnyPair
-}
-
-func ParseNode(val any) (*Node, error) {
-	arr, err := redisstack.ParseArray(val, 3)
-	if err != nil {
-		return nil, err
-	}
-
-	res := &Node{}
-	var arr1, arr2 []any
-	if arr1, err = redisstack.ParseArray(arr[0], 2); err != nil {
-		return nil, err
-	}
-	res.ID, _ = arr1[1].(int64)
-
-	if arr1, err = redisstack.ParseArray(arr[1], 2); err != nil {
-		return nil, err
-	} else if arr2, err = redisstack.ParseArray(arr1[1], 0); err != nil {
-		return nil, err
-	}
-	if len(arr2) > 0 {
-		label, _ := arr2[0].(string)
-		res.Label = &label
-	}
-
-	if arr1, err = redisstack.ParseArray(arr[2], 2); err != nil {
-		return nil, err
-	} else if arr2, err = redisstack.ParseArray(arr1[1], 0); err != nil {
-		return nil, err
-	} else if res.Properties, err = redisstack.ParseStringAnyPairArray(arr2, 0); err != nil {
-		return nil, err
-	}
-
-	return res, nil
-}
-
-type Relationship struct {
-	ID         int64
-	Type       string
-	SrcNodeID  int64
-	DestNodeID int64
-	Properties []redisstack.StringAnyPair
-}
-
-func ParseRelationship(val any) (*Relationship, error) {
-	arr, err := redisstack.ParseArray(val, 5)
-	if err != nil {
-		return nil, err
-	}
-
-	res := &Relationship{}
-	var arr1, arr2 []any
-	if arr1, err = redisstack.ParseArray(arr[0], 2); err != nil {
-		return nil, err
-	}
-	res.ID, _ = arr1[1].(int64)
-
-	if arr1, err = redisstack.ParseArray(arr[1], 2); err != nil {
-		return nil, err
-	}
-	res.Type, _ = arr1[1].(string)
-
-	if arr1, err = redisstack.ParseArray(arr[2], 2); err != nil {
-		return nil, err
-	}
-	res.SrcNodeID, _ = arr1[1].(int64)
-
-	if arr1, err = redisstack.ParseArray(arr[3], 2); err != nil {
-		return nil, err
-	}
-	res.DestNodeID, _ = arr1[1].(int64)
-
-	if arr1, err = redisstack.ParseArray(arr[4], 2); err != nil {
-		return nil, err
-	} else if arr2, err = redisstack.ParseArray(arr1[1], 0); err != nil {
-		return nil, err
-	} else if res.Properties, err = redisstack.ParseStringAnyPairArray(arr2, 0); err != nil {
-		return nil, err
-	}
-
-	return res, nil
-}
+package graph
+
+import "github.com/ldeng7/go-redis-stack/redisstack"
+
+func DeleteArgs(key string) []any {
+	return []any{"GRAPH.DELETE", key}
+}
+
+func ListArgs() []any {
+	return []any{"GRAPH.LIST"}
+}
+
+func ListResult(val any) ([]string, error) {
+	return redisstack.ParseScalarArray[string](val, 0)
+}
+
+func QueryArgs(key string, query string) []any {
+	return []any{"GRAPH.QUERY", key, query}
+}
+
+type ResultSet struct {
+	Header []string
+	Rows   [][]any
+}
+
+func QueryResult(val any) (*ResultSet, error) {
+	arr, err := redisstack.ParseArray(val, 1)
+	if err != nil {
+		return nil, err
+	}
+	res := &ResultSet{Header: []string{}, Rows: [][]any{}}
+	if len(arr) < 3 {
+		return res, nil
+	}
+	if res.Header, err = redisstack.ParseScalarArray[string](arr[0], 0); err != nil {
+		return nil, redisstack.WithPathIndex(err, 0)
+	}
+
+	nCols := len(res.Header)
+	arr1, err := redisstack.ParseArray(arr[1], 0)
+	if err != nil {
+		return nil, redisstack.WithPathIndex(err, 1)
+	}
+	rows := make([][]any, len(arr1))
+	for i, e := range arr1 {
+		rows[i], err = redisstack.ParseArray(e, nCols)
+		if err != nil {
+			return nil, redisstack.WithPathIndex(redisstack.WithPathIndex(err, i), 1)
+		}
+	}
+	res.Rows = rows
+
+	return res, nil
+}
+
+func parseEntity(val any, fields ...string) (map[string]any, error) {
+	var m map[string]any
+	if _, ok := val.(map[any]any); ok {
+		var err error
+		if m, err = redisstack.ParseMap(val); err != nil {
+			return nil, err
+		}
+	} else {
+		pairs, err := redisstack.ParseStringAnyPairArray(val, 0)
+		if err != nil {
+			return nil, err
+		}
+		m = make(map[string]any, len(pairs))
+		for _, pair := range pairs {
+			m[pair.Key] = pair.Value
+		}
+	}
+	for _, field := range fields {
+		if _, ok := m[field]; !ok {
+			return nil, redisstack.NewDataError("field "+field, "none", val)
+		}
+	}
+	return m, nil
+}
+
+func parseProperties(m map[string]any) ([]redisstack.StringAnyPair, error) {
+	res, err := redisstack.ParseStringAnyPairArray(m["properties"], 0)
+	if err != nil {
+		return nil, redisstack.WithPathKey(err, "properties")
+	}
+	return res, nil
+}
+
+type Node struct {
+	ID         int64
+	Label      *string
+	Properties []redisstack.StringAnyPair
+}
+
+func ParseNode(val any) (*Node, error) {
+	m, err := parseEntity(val, "id", "labels", "properties")
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Node{}
+	if res.ID, err = redisstack.ParseScalar[int64](m["id"]); err != nil {
+		return nil, redisstack.WithPathKey(err, "id")
+	}
+	labels, err := redisstack.ParseScalarArray[string](m["labels"], 0)
+	if err != nil {
+		return nil, redisstack.WithPathKey(err, "labels")
+	} else if len(labels) > 0 {
+		res.Label = &labels[0]
+	}
+	if res.Properties, err = parseProperties(m); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+type Relationship struct {
+	ID         int64
+	Type       string
+	SrcNodeID  int64
+	DestNodeID int64
+	Properties []redisstack.StringAnyPair
+}
+
+func ParseRelationship(val any) (*Relationship, error) {
+	m, err := parseEntity(val, "id", "type", "src_node", "dest_node", "properties")
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Relationship{}
+	if res.ID, err = redisstack.ParseScalar[int64](m["id"]); err != nil {
+		return nil, redisstack.WithPathKey(err, "id")
+	} else if res.Type, err = redisstack.ParseScalar[string](m["type"]); err != nil {
+		return nil, redisstack.WithPathKey(err, "type")
+	} else if res.SrcNodeID, err = redisstack.ParseScalar[int64](m["src_node"]); err != nil {
+		return nil, redisstack.WithPathKey(err, "src_node")
+	} else if res.DestNodeID, err = redisstack.ParseScalar[int64](m["dest_node"]); err != nil {
+		return nil, redisstack.WithPathKey(err, "dest_node")
+	}
+	if res.Properties, err = parseProperties(m); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+)
+
+func TestArgs(t *testing.T) {
+	for _, c := range []struct {
+		args []any
+		exp  []any
+	}{
+		{DeleteArgs("g"), []any{"GRAPH.DELETE", "g"}},
+		{ListArgs(), []any{"GRAPH.LIST"}},
+		{QueryArgs("g", "MATCH (n) RETURN n"), []any{"GRAPH.QUERY", "g", "MATCH (n) RETURN n"}},
+	} {
+		if !reflect.DeepEqual(c.args, c.exp) {
+			t.Errorf("got %#v, expected %#v", c.args, c.exp)
+		}
+	}
+}
+
+func firstCell[T any](val any, parse func(any) (T, error)) (any, error) {
+	rs, err := QueryResult(val)
+	if err != nil {
+		return nil, err
+	} else if len(rs.Rows) == 0 {
+		return nil, errors.New("no rows")
+	}
+	return parse(rs.Rows[0][0])
+}
+
+func TestReplies(t *testing.T) {
+	exchanges, err := redisstacktest.LoadExchanges("testdata/replies.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	label := "person"
+	for _, c := range []struct {
+		name  string
+		args  []any
+		parse func(any) (any, error)
+		exp   any
+	}{
+		{"List", ListArgs(), func(val any) (any, error) { return ListResult(val) }, []string{"g"}},
+		{"Query", QueryArgs("g", "MATCH (n) RETURN n.a AS a, n.b AS b"), func(val any) (any, error) { return QueryResult(val) }, &ResultSet{Header: []string{"a", "b"}, Rows: [][]any{{int64(1), "x"}}}},
+		{"QueryStats", QueryArgs("g", "CREATE (:person {name: 'x'})"), func(val any) (any, error) { return QueryResult(val) }, &ResultSet{Header: []string{}, Rows: [][]any{}}},
+		{"Node", QueryArgs("g", "MATCH (n:person) RETURN n"), func(val any) (any, error) { return firstCell(val, ParseNode) }, &Node{ID: 3, Label: &label, Properties: []redisstack.StringAnyPair{{Key: "name", Value: "x"}}}},
+		{"NodeNoLabel", QueryArgs("g", "MATCH (n) WHERE id(n) = 3 RETURN n"), func(val any) (any, error) { return firstCell(val, ParseNode) }, &Node{ID: 3, Properties: []redisstack.StringAnyPair{}}},
+		{"Relationship", QueryArgs("g", "MATCH ()-[r:knows]->() RETURN r"), func(val any) (any, error) { return firstCell(val, ParseRelationship) }, &Relationship{ID: 1, Type: "knows", SrcNodeID: 3, DestNodeID: 4, Properties: []redisstack.StringAnyPair{{Key: "since", Value: int64(2020)}}}},
+	} {
+		redisstacktest.CheckExchange(t, exchanges, c.name, c.args, c.parse, c.exp)
+	}
+}
+
+func TestResultErrors(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		parse func(any) (any, error)
+		val   any
+		path  string
+	}{
+		{"QueryHeader", func(val any) (any, error) { return QueryResult(val) }, []any{[]any{int64(1)}, []any{}, []any{}}, "[0][0]"},
+		{"QueryRow", func(val any) (any, error) { return QueryResult(val) }, []any{[]any{"a", "b"}, []any{[]any{int64(1)}}, []any{}}, "[1][0]"},
+		{"NodeMissingField", func(val any) (any, error) { return ParseNode(val) }, map[any]any{"id": int64(1)}, ""},
+		{"NodeID", func(val any) (any, error) { return ParseNode(val) },
+			map[any]any{"id": "1", "labels": []any{}, "properties": map[any]any{}}, `["id"]`},
+		{"RelationshipType", func(val any) (any, error) { return ParseRelationship(val) },
+			[]any{[]any{"id", int64(1)}, []any{"type", int64(1)}, []any{"src_node", int64(3)}, []any{"dest_node", int64(4)}, []any{"properties", []any{}}},
+			`["type"]`},
+		{"RelationshipProperties", func(val any) (any, error) { return ParseRelationship(val) },
+			[]any{[]any{"id", int64(1)}, []any{"type", "knows"}, []any{"src_node", int64(3)}, []any{"dest_node", int64(4)}, []any{"properties", []any{[]any{int64(1), "x"}}}},
+			`["properties"][0][0]`},
+	} {
+		_, err := c.parse(c.val)
+		var perr *redisstack.ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%s: expected a parse error, got %v", c.name, err)
+		} else if perr.Path != c.path {
+			t.Errorf("%s: path %q, expected %q", c.name, perr.Path, c.path)
+		}
+	}
+}
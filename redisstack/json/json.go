@@ -0,0 +1,359 @@
+package json
+
+import (
+	encjson "encoding/json"
+	"strconv"
+
+	"github.com/ldeng7/go-redis-stack/redisstack"
+)
+
+const RootPath = "$"
+
+type SetMode byte
+
+const (
+	SetModeNone = SetMode(iota)
+	SetModeNX
+	SetModeXX
+)
+
+var setModeNames = map[SetMode]string{
+	SetModeNX: "NX",
+	SetModeXX: "XX",
+}
+
+type Format struct {
+	Indent  string
+	Newline string
+	Space   string
+}
+
+type KeyPathValue struct {
+	Key   string
+	Path  string
+	Value string
+}
+
+func Marshal(v any) (string, error) {
+	b, err := encjson.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func Unmarshal[T any](val any) (T, error) {
+	var t T
+	s, err := redisstack.ParseScalar[string](val)
+	if err != nil {
+		return t, err
+	} else if err = encjson.Unmarshal([]byte(s), &t); err != nil {
+		return t, redisstack.NewDataError("JSON", "string", val)
+	}
+	return t, nil
+}
+
+func UnmarshalPath[T any](val any) ([]T, error) {
+	return Unmarshal[[]T](val)
+}
+
+func UnmarshalArray[T any](val any) ([]*T, error) {
+	return redisstack.ParseToMappedArray(val, 0, func(e any) (*T, error) {
+		if e == nil {
+			return nil, nil
+		}
+		t, err := Unmarshal[T](e)
+		return &t, err
+	})
+}
+
+func parseNullableInts(val any) ([]*int64, error) {
+	if _, ok := val.([]any); ok {
+		return redisstack.ParseNullableScalarArray[int64](val, 0)
+	} else if val == nil {
+		return []*int64{nil}, nil
+	}
+	i, err := redisstack.ParseScalar[int64](val)
+	if err != nil {
+		return nil, err
+	}
+	return []*int64{&i}, nil
+}
+
+func parseNullableStrings(val any) ([]*string, error) {
+	if _, ok := val.([]any); ok {
+		return redisstack.ParseNullableScalarArray[string](val, 0)
+	} else if val == nil {
+		return []*string{nil}, nil
+	}
+	s, err := redisstack.ParseScalar[string](val)
+	if err != nil {
+		return nil, err
+	}
+	return []*string{&s}, nil
+}
+
+func appendValues(args []any, values []string) []any {
+	for _, value := range values {
+		args = append(args, value)
+	}
+	return args
+}
+
+func ArrAppendArgs(key string, path string, values []string) []any {
+	args := make([]any, 0, 3+len(values))
+	args = append(args, "JSON.ARRAPPEND", key, path)
+	return appendValues(args, values)
+}
+
+func ArrAppendResult(val any) ([]*int64, error) {
+	return parseNullableInts(val)
+}
+
+func ArrIndexArgs(key string, path string, value string, start *int64, stop *int64) []any {
+	args := make([]any, 0, 6)
+	args = append(args, "JSON.ARRINDEX", key, path, value)
+	if start != nil {
+		args = append(args, *start)
+		if stop != nil {
+			args = append(args, *stop)
+		}
+	}
+	return args
+}
+
+func ArrIndexResult(val any) ([]*int64, error) {
+	return parseNullableInts(val)
+}
+
+func ArrInsertArgs(key string, path string, index int64, values []string) []any {
+	args := make([]any, 0, 4+len(values))
+	args = append(args, "JSON.ARRINSERT", key, path, index)
+	return appendValues(args, values)
+}
+
+func ArrInsertResult(val any) ([]*int64, error) {
+	return parseNullableInts(val)
+}
+
+func ArrLenArgs(key string, path string) []any {
+	return []any{"JSON.ARRLEN", key, path}
+}
+
+func ArrLenResult(val any) ([]*int64, error) {
+	return parseNullableInts(val)
+}
+
+func ArrPopArgs(key string, path string, index *int64) []any {
+	args := make([]any, 0, 4)
+	args = append(args, "JSON.ARRPOP", key, path)
+	if index != nil {
+		args = append(args, *index)
+	}
+	return args
+}
+
+func ArrPopResult(val any) ([]*string, error) {
+	return parseNullableStrings(val)
+}
+
+func ArrTrimArgs(key string, path string, start int64, stop int64) []any {
+	return []any{"JSON.ARRTRIM", key, path, start, stop}
+}
+
+func ArrTrimResult(val any) ([]*int64, error) {
+	return parseNullableInts(val)
+}
+
+func DelArgs(key string, path string) []any {
+	if len(path) == 0 {
+		return []any{"JSON.DEL", key}
+	}
+	return []any{"JSON.DEL", key, path}
+}
+
+func DelResult(val any) (int64, error) {
+	return redisstack.ParseScalar[int64](val)
+}
+
+func GetArgs(key string, format *Format, paths []string) []any {
+	args := make([]any, 0, 8+len(paths))
+	args = append(args, "JSON.GET", key)
+	if format != nil {
+		if len(format.Indent) > 0 {
+			args = append(args, "INDENT", format.Indent)
+		}
+		if len(format.Newline) > 0 {
+			args = append(args, "NEWLINE", format.Newline)
+		}
+		if len(format.Space) > 0 {
+			args = append(args, "SPACE", format.Space)
+		}
+	}
+	for _, path := range paths {
+		args = append(args, path)
+	}
+	return args
+}
+
+func GetResult(val any) (*string, error) {
+	if val == nil {
+		return nil, nil
+	}
+	s, err := redisstack.ParseScalar[string](val)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func MergeArgs(key string, path string, value string) []any {
+	return []any{"JSON.MERGE", key, path, value}
+}
+
+func MGetArgs(keys []string, path string) []any {
+	args := make([]any, 0, 2+len(keys))
+	args = append(args, "JSON.MGET")
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	return append(args, path)
+}
+
+func MGetResult(val any) ([]*string, error) {
+	return redisstack.ParseNullableScalarArray[string](val, 0)
+}
+
+func MSetArgs(kpvs []KeyPathValue) []any {
+	args := make([]any, 1+len(kpvs)*3)
+	args[0] = "JSON.MSET"
+	for i, kpv := range kpvs {
+		args[i*3+1], args[i*3+2], args[i*3+3] = kpv.Key, kpv.Path, kpv.Value
+	}
+	return args
+}
+
+func NumIncrByArgs(key string, path string, value float64) []any {
+	return []any{"JSON.NUMINCRBY", key, path, value}
+}
+
+func NumIncrByResult(val any) ([]*float64, error) {
+	if arr, ok := val.([]any); ok {
+		return redisstack.ParseToMappedArray(arr, 0, func(e any) (*float64, error) {
+			if e == nil {
+				return nil, nil
+			}
+			f, err := redisstack.ParseFloat(e)
+			return &f, err
+		})
+	}
+	s, err := redisstack.ParseScalar[string](val)
+	if err != nil {
+		return nil, err
+	} else if len(s) > 0 && s[0] == '[' {
+		return Unmarshal[[]*float64](val)
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, redisstack.NewDataError("number", "string", val)
+	}
+	return []*float64{&f}, nil
+}
+
+func ObjKeysArgs(key string, path string) []any {
+	return []any{"JSON.OBJKEYS", key, path}
+}
+
+func ObjKeysResult(val any) ([][]string, error) {
+	arr, err := redisstack.ParseArray(val, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(arr) > 0 {
+		if _, ok := arr[0].(string); ok {
+			keys, err := redisstack.ParseScalarArray[string](arr, 0)
+			return [][]string{keys}, err
+		}
+	}
+	return redisstack.ParseToMappedArray(arr, 0, func(e any) ([]string, error) {
+		if e == nil {
+			return nil, nil
+		}
+		return redisstack.ParseScalarArray[string](e, 0)
+	})
+}
+
+func SetArgs(key string, path string, value string, mode SetMode) []any {
+	args := make([]any, 0, 5)
+	args = append(args, "JSON.SET", key, path, value)
+	if mode != SetModeNone {
+		args = append(args, setModeNames[mode])
+	}
+	return args
+}
+
+func SetResult(val any) (bool, error) {
+	return val != nil, nil
+}
+
+func SetValueArgs(key string, path string, v any, mode SetMode) ([]any, error) {
+	value, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return SetArgs(key, path, value, mode), nil
+}
+
+func StrAppendArgs(key string, path string, value string) []any {
+	b, _ := encjson.Marshal(value)
+	return []any{"JSON.STRAPPEND", key, path, string(b)}
+}
+
+func StrAppendResult(val any) ([]*int64, error) {
+	return parseNullableInts(val)
+}
+
+func ToggleArgs(key string, path string) []any {
+	return []any{"JSON.TOGGLE", key, path}
+}
+
+func ToggleResult(val any) ([]*bool, error) {
+	switch v := val.(type) {
+	case []any:
+		return redisstack.ParseToMappedArray(v, 0, func(e any) (*bool, error) {
+			if e == nil {
+				return nil, nil
+			}
+			b, err := redisstack.ParseIntBool(e)
+			return &b, err
+		})
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, redisstack.NewDataError("boolean", "string", val)
+		}
+		return []*bool{&b}, nil
+	}
+	return nil, redisstack.NewTypeError("[]interface {}", val)
+}
+
+func TypeArgs(key string, path string) []any {
+	return []any{"JSON.TYPE", key, path}
+}
+
+func TypeResult(val any) ([]string, error) {
+	switch v := val.(type) {
+	case []any:
+		if len(v) == 1 {
+			if _, ok := v[0].([]any); ok {
+				return redisstack.ParseScalarArray[string](v[0], 0)
+			}
+		}
+		return redisstack.ParseScalarArray[string](v, 0)
+	case string:
+		return []string{v}, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, redisstack.NewTypeError("[]interface {}", val)
+}
@@ -0,0 +1,123 @@
+package json
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+)
+
+func TestArgs(t *testing.T) {
+	start, stop, index := int64(1), int64(3), int64(-1)
+	setArgs, err := SetValueArgs("k", RootPath, map[string]any{"a": 1}, SetModeNX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		args []any
+		exp  []any
+	}{
+		{ArrAppendArgs("k", "$.a", []string{"1", `"x"`}), []any{"JSON.ARRAPPEND", "k", "$.a", "1", `"x"`}},
+		{ArrIndexArgs("k", "$.a", "1", nil, &stop), []any{"JSON.ARRINDEX", "k", "$.a", "1"}},
+		{ArrIndexArgs("k", "$.a", "1", &start, &stop), []any{"JSON.ARRINDEX", "k", "$.a", "1", int64(1), int64(3)}},
+		{ArrInsertArgs("k", "$.a", 0, []string{"1"}), []any{"JSON.ARRINSERT", "k", "$.a", int64(0), "1"}},
+		{ArrLenArgs("k", "$.a"), []any{"JSON.ARRLEN", "k", "$.a"}},
+		{ArrPopArgs("k", "$.a", nil), []any{"JSON.ARRPOP", "k", "$.a"}},
+		{ArrPopArgs("k", "$.a", &index), []any{"JSON.ARRPOP", "k", "$.a", int64(-1)}},
+		{ArrTrimArgs("k", "$.a", 1, 3), []any{"JSON.ARRTRIM", "k", "$.a", int64(1), int64(3)}},
+		{DelArgs("k", ""), []any{"JSON.DEL", "k"}},
+		{DelArgs("k", "$.a"), []any{"JSON.DEL", "k", "$.a"}},
+		{GetArgs("k", nil, nil), []any{"JSON.GET", "k"}},
+		{GetArgs("k", &Format{Indent: "\t", Newline: "\n", Space: " "}, []string{"$.a", "$.b"}),
+			[]any{"JSON.GET", "k", "INDENT", "\t", "NEWLINE", "\n", "SPACE", " ", "$.a", "$.b"}},
+		{MergeArgs("k", RootPath, `{"a":2}`), []any{"JSON.MERGE", "k", "$", `{"a":2}`}},
+		{MGetArgs([]string{"a", "b"}, "$.x"), []any{"JSON.MGET", "a", "b", "$.x"}},
+		{MSetArgs([]KeyPathValue{{"a", "$", "1"}, {"b", "$.x", "2"}}), []any{"JSON.MSET", "a", "$", "1", "b", "$.x", "2"}},
+		{NumIncrByArgs("k", "$.a", 2), []any{"JSON.NUMINCRBY", "k", "$.a", 2.0}},
+		{ObjKeysArgs("k", RootPath), []any{"JSON.OBJKEYS", "k", "$"}},
+		{SetArgs("k", RootPath, "1", SetModeNone), []any{"JSON.SET", "k", "$", "1"}},
+		{SetArgs("k", RootPath, "1", SetModeXX), []any{"JSON.SET", "k", "$", "1", "XX"}},
+		{setArgs, []any{"JSON.SET", "k", "$", `{"a":1}`, "NX"}},
+		{StrAppendArgs("k", "$.s", `a"b`), []any{"JSON.STRAPPEND", "k", "$.s", `"a\"b"`}},
+		{ToggleArgs("k", "$.b"), []any{"JSON.TOGGLE", "k", "$.b"}},
+		{TypeArgs("k", "$.a"), []any{"JSON.TYPE", "k", "$.a"}},
+	} {
+		if !reflect.DeepEqual(c.args, c.exp) {
+			t.Errorf("got %#v, expected %#v", c.args, c.exp)
+		}
+	}
+}
+
+func TestReplies(t *testing.T) {
+	exchanges, err := redisstacktest.LoadExchanges("testdata/replies.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	i1, i3, f3, s, tr, fa := int64(1), int64(3), 3.0, `"x"`, true, false
+	for _, c := range []struct {
+		name  string
+		args  []any
+		parse func(any) (any, error)
+		exp   any
+	}{
+		{"ArrAppend", ArrAppendArgs("k", "$..a", []string{"1"}), func(val any) (any, error) { return ArrAppendResult(val) }, []*int64{&i3, nil}},
+		{"ArrAppendLegacy", ArrAppendArgs("k", ".a", []string{"1"}), func(val any) (any, error) { return ArrAppendResult(val) }, []*int64{&i3}},
+		{"ArrIndex", ArrIndexArgs("k", "$.a", "1", nil, nil), func(val any) (any, error) { return ArrIndexResult(val) }, []*int64{&i1}},
+		{"ArrLen", ArrLenArgs("missing", "$.a"), func(val any) (any, error) { return ArrLenResult(val) }, []*int64{nil}},
+		{"ArrPop", ArrPopArgs("k", "$..a", nil), func(val any) (any, error) { return ArrPopResult(val) }, []*string{&s, nil}},
+		{"Del", DelArgs("k", "$.a"), func(val any) (any, error) { return DelResult(val) }, int64(1)},
+		{"Get", GetArgs("k", nil, []string{".s"}), func(val any) (any, error) { return GetResult(val) }, &s},
+		{"GetMissing", GetArgs("missing", nil, nil), func(val any) (any, error) { return GetResult(val) }, (*string)(nil)},
+		{"MGet", MGetArgs([]string{"a", "missing"}, ".s"), func(val any) (any, error) { return MGetResult(val) }, []*string{&s, nil}},
+		{"NumIncrBy", NumIncrByArgs("k", "$..n", 2), func(val any) (any, error) { return NumIncrByResult(val) }, []*float64{&f3, nil}},
+		{"NumIncrByLegacy", NumIncrByArgs("k", ".n", 2), func(val any) (any, error) { return NumIncrByResult(val) }, []*float64{&f3}},
+		{"ObjKeys", ObjKeysArgs("k", "$..o"), func(val any) (any, error) { return ObjKeysResult(val) }, [][]string{{"a", "b"}, nil}},
+		{"ObjKeysLegacy", ObjKeysArgs("k", ".o"), func(val any) (any, error) { return ObjKeysResult(val) }, [][]string{{"a"}}},
+		{"Set", SetArgs("k", RootPath, "{}", SetModeNone), func(val any) (any, error) { return SetResult(val) }, true},
+		{"SetNotApplied", SetArgs("k", RootPath, "{}", SetModeNX), func(val any) (any, error) { return SetResult(val) }, false},
+		{"StrAppend", StrAppendArgs("k", "$.s", "yz"), func(val any) (any, error) { return StrAppendResult(val) }, []*int64{&i3}},
+		{"Toggle", ToggleArgs("k", "$..b"), func(val any) (any, error) { return ToggleResult(val) }, []*bool{&tr, &fa, nil}},
+		{"ToggleLegacy", ToggleArgs("k", ".b"), func(val any) (any, error) { return ToggleResult(val) }, []*bool{&tr}},
+		{"Type", TypeArgs("k", RootPath), func(val any) (any, error) { return TypeResult(val) }, []string{"object"}},
+		{"TypeLegacy", TypeArgs("k", ".n"), func(val any) (any, error) { return TypeResult(val) }, []string{"integer"}},
+	} {
+		redisstacktest.CheckExchange(t, exchanges, c.name, c.args, c.parse, c.exp)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	type doc struct {
+		A int `json:"a"`
+	}
+	if res, err := Unmarshal[doc](`{"a":1}`); err != nil || res.A != 1 {
+		t.Errorf("%v %v", res, err)
+	}
+	if res, err := UnmarshalPath[doc](`[{"a":1},{"a":2}]`); err != nil || len(res) != 2 || res[1].A != 2 {
+		t.Errorf("%v %v", res, err)
+	}
+	if res, err := UnmarshalArray[doc]([]any{`{"a":1}`, nil}); err != nil || len(res) != 2 || res[0].A != 1 || res[1] != nil {
+		t.Errorf("%v %v", res, err)
+	}
+}
+
+func TestResultErrors(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		parse func(any) (any, error)
+		val   any
+	}{
+		{"ArrLen", func(val any) (any, error) { return ArrLenResult(val) }, []any{"x"}},
+		{"Get", func(val any) (any, error) { return GetResult(val) }, int64(1)},
+		{"NumIncrBy", func(val any) (any, error) { return NumIncrByResult(val) }, "x"},
+		{"Toggle", func(val any) (any, error) { return ToggleResult(val) }, "x"},
+		{"Type", func(val any) (any, error) { return TypeResult(val) }, int64(1)},
+		{"Unmarshal", func(val any) (any, error) { return Unmarshal[map[string]any](val) }, "{"},
+	} {
+		var perr *redisstack.ParseError
+		if _, err := c.parse(c.val); !errors.As(err, &perr) {
+			t.Errorf("%s: expected a parse error, got %v", c.name, err)
+		}
+	}
+}
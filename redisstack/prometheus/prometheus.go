@@ -0,0 +1,353 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/golang/snappy"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	time_series "github.com/ldeng7/go-redis-stack/redisstack/time_series"
+)
+
+const (
+	seriesLabel      = "__redisstack__"
+	seriesLabelValue = "prometheus"
+)
+
+type Option struct {
+	KeyPrefix    string
+	SeriesOption *time_series.Option
+}
+
+type Adapter struct {
+	red     redis.UniversalClient
+	opt     Option
+	created sync.Map
+}
+
+func NewAdapter(red redis.UniversalClient, opt *Option) *Adapter {
+	a := &Adapter{red: red}
+	if opt != nil {
+		a.opt = *opt
+	}
+	return a
+}
+
+func sortLabels(labels []Label) []Label {
+	labels1 := make([]Label, len(labels))
+	copy(labels1, labels)
+	sort.Slice(labels1, func(i, j int) bool { return labels1[i].Name < labels1[j].Name })
+	return labels1
+}
+
+func SeriesKey(prefix string, labels []Label) string {
+	sb := &strings.Builder{}
+	sb.WriteString(prefix)
+	var name string
+	for _, label := range labels {
+		if label.Name == "__name__" {
+			name = label.Value
+		}
+	}
+	sb.WriteString(name)
+	sb.WriteByte('{')
+	first := true
+	for _, label := range sortLabels(labels) {
+		if label.Name == "__name__" {
+			continue
+		}
+		if !first {
+			sb.WriteByte(',')
+		}
+		first = false
+		sb.WriteString(label.Name)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(label.Value))
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func (a *Adapter) seriesOption(labels []Label) *time_series.Option {
+	opt := &time_series.Option{}
+	if a.opt.SeriesOption != nil {
+		*opt = *a.opt.SeriesOption
+	}
+	opt.Labels = make([][2]string, 0, len(opt.Labels)+len(labels)+1)
+	if a.opt.SeriesOption != nil {
+		opt.Labels = append(opt.Labels, a.opt.SeriesOption.Labels...)
+	}
+	opt.Labels = append(opt.Labels, [2]string{seriesLabel, seriesLabelValue})
+	for _, label := range labels {
+		opt.Labels = append(opt.Labels, [2]string{label.Name, label.Value})
+	}
+	return opt
+}
+
+func (a *Adapter) create(ctx context.Context, key string, labels []Label) error {
+	if _, ok := a.created.Load(key); ok {
+		return nil
+	}
+	err := redisstack.ClassifyError(a.red.Do(ctx, time_series.CreateArgs(key, a.seriesOption(labels))...).Err())
+	if err != nil && !errors.Is(err, redisstack.ErrKeyExists) {
+		return err
+	}
+	a.created.Store(key, struct{}{})
+	return nil
+}
+
+type SampleError struct {
+	Key       string
+	Timestamp int64
+	Err       error
+}
+
+type WriteError struct {
+	Errors []*SampleError
+}
+
+func (e *WriteError) Error() string {
+	se := e.Errors[0]
+	return strconv.Itoa(len(e.Errors)) + " samples rejected, first at " + se.Key + "@" +
+		strconv.FormatInt(se.Timestamp, 10) + ": " + se.Err.Error()
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Errors[0].Err
+}
+
+var rejectedSampleErrors = []error{
+	redisstack.ErrDuplicateSample,
+	redisstack.ErrTimestampTooOld,
+	redisstack.ErrInvalidValue,
+	redisstack.ErrWrongType,
+}
+
+func (e *WriteError) Retryable() bool {
+outer:
+	for _, se := range e.Errors {
+		for _, err := range rejectedSampleErrors {
+			if errors.Is(se.Err, err) {
+				continue outer
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (a *Adapter) Write(ctx context.Context, req *WriteRequest) error {
+	var samples []*time_series.Sample
+	labels := make(map[string][]Label, len(req.Timeseries))
+	for i := range req.Timeseries {
+		ts := &req.Timeseries[i]
+		key := SeriesKey(a.opt.KeyPrefix, ts.Labels)
+		if err := a.create(ctx, key, ts.Labels); err != nil {
+			return err
+		}
+		labels[key] = ts.Labels
+		for _, sample := range ts.Samples {
+			samples = append(samples, &time_series.Sample{Key: key, Time: time_series.AtMilli(sample.Timestamp), Value: sample.Value})
+		}
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+	replies, err := time_series.MAddAutoCreate(ctx, a.red, samples, func(key string) *time_series.Option {
+		return a.seriesOption(labels[key])
+	})
+	if err != nil {
+		return redisstack.ClassifyError(err)
+	}
+	var errs []*SampleError
+	for i, reply := range replies {
+		if reply.Err != nil {
+			errs = append(errs, &SampleError{samples[i].Key, samples[i].Time.UnixMilli(), reply.Err})
+		}
+	}
+	if len(errs) > 0 {
+		return &WriteError{errs}
+	}
+	return nil
+}
+
+type matcher struct {
+	name string
+	re   *regexp.Regexp
+	not  bool
+}
+
+func (m *matcher) matches(labels [][2]string) bool {
+	value := ""
+	for _, label := range labels {
+		if label[0] == m.name {
+			value = label[1]
+			break
+		}
+	}
+	return m.re.MatchString(value) != m.not
+}
+
+var literalAlternatives = regexp.MustCompile(`^[a-zA-Z0-9_:\-]+(\|[a-zA-Z0-9_:\-]+)*$`)
+
+func translateMatchers(matchers []LabelMatcher) ([]string, []*matcher, error) {
+	filters := make([]time_series.Filter, 0, len(matchers))
+	var rest []*matcher
+	for _, m := range matchers {
+		switch {
+		case m.Type == MatchEqual && time_series.ValidFilterValue(m.Value):
+			filters = append(filters, time_series.Eq(m.Name, m.Value))
+		case m.Type == MatchNotEqual && time_series.ValidFilterValue(m.Value):
+			filters = append(filters, time_series.NotEq(m.Name, m.Value))
+		case m.Type == MatchEqual, m.Type == MatchNotEqual:
+			re := regexp.MustCompile("^" + regexp.QuoteMeta(m.Value) + "$")
+			rest = append(rest, &matcher{m.Name, re, m.Type == MatchNotEqual})
+		case m.Type == MatchRegexp, m.Type == MatchNotRegexp:
+			if literalAlternatives.MatchString(m.Value) {
+				values := strings.Split(m.Value, "|")
+				if m.Type == MatchRegexp {
+					filters = append(filters, time_series.In(m.Name, values...))
+				} else {
+					filters = append(filters, time_series.NotIn(m.Name, values...))
+				}
+				continue
+			}
+			re, err := regexp.Compile("^(?:" + m.Value + ")$")
+			if err != nil {
+				return nil, nil, err
+			}
+			rest = append(rest, &matcher{m.Name, re, m.Type == MatchNotRegexp})
+		}
+	}
+	res, err := time_series.Filters(filters...)
+	if errors.Is(err, time_series.ErrNoPositiveFilter) {
+		res, err = time_series.Filters(append(filters, time_series.Eq(seriesLabel, seriesLabelValue))...)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, rest, nil
+}
+
+func (a *Adapter) query(ctx context.Context, q *Query) (*QueryResult, error) {
+	filters, rest, err := translateMatchers(q.Matchers)
+	if err != nil {
+		return nil, err
+	}
+	from, to := time_series.AtMilli(q.StartTimestampMs), time_series.AtMilli(q.EndTimestampMs)
+	mq := &time_series.MultiQuery{FromTime: from, ToTime: to, WithLabels: true, Filters: filters}
+	cmd := a.red.Do(ctx, time_series.MRangeArgs(mq)...)
+	if err := cmd.Err(); err != nil {
+		return nil, redisstack.ClassifyError(err)
+	}
+	res, err := time_series.MRangeResult(cmd.Val())
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(res))
+	for key := range res {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	qr := &QueryResult{Timeseries: make([]TimeSeries, 0, len(keys))}
+outer:
+	for _, key := range keys {
+		series := res[key]
+		for _, m := range rest {
+			if !m.matches(series.Labels) {
+				continue outer
+			}
+		}
+		ts := TimeSeries{Labels: make([]Label, 0, len(series.Labels)), Samples: make([]Sample, len(series.Samples))}
+		for _, label := range series.Labels {
+			if label[0] != seriesLabel {
+				ts.Labels = append(ts.Labels, Label{label[0], label[1]})
+			}
+		}
+		ts.Labels = sortLabels(ts.Labels)
+		for i, sample := range series.Samples {
+			ts.Samples[i] = Sample{sample.Value, sample.Time.UnixMilli()}
+		}
+		qr.Timeseries = append(qr.Timeseries, ts)
+	}
+	return qr, nil
+}
+
+func (a *Adapter) Read(ctx context.Context, req *ReadRequest) (*ReadResponse, error) {
+	resp := &ReadResponse{Results: make([]QueryResult, len(req.Queries))}
+	for i := range req.Queries {
+		qr, err := a.query(ctx, &req.Queries[i])
+		if err != nil {
+			return nil, err
+		}
+		resp.Results[i] = *qr
+	}
+	return resp, nil
+}
+
+func readSnappy(r *http.Request) ([]byte, error) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Decode(nil, b)
+}
+
+func (a *Adapter) WriteHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := readSnappy(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req := &WriteRequest{}
+		if err = req.Unmarshal(b); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err = a.Write(r.Context(), req); err != nil {
+			status := http.StatusInternalServerError
+			var werr *WriteError
+			if errors.As(err, &werr) && !werr.Retryable() {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (a *Adapter) ReadHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := readSnappy(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req := &ReadRequest{}
+		if err = req.Unmarshal(b); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp, err := a.Read(r.Context(), req)
+		if errors.Is(err, time_series.ErrNoPositiveFilter) || errors.Is(err, time_series.ErrInvalidFilter) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Header().Set("Content-Encoding", "snappy")
+		w.Write(snappy.Encode(nil, resp.Marshal()))
+	})
+}
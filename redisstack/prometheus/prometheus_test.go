@@ -0,0 +1,159 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/golang/snappy"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+	time_series "github.com/ldeng7/go-redis-stack/redisstack/time_series"
+)
+
+func series(job string, samples ...Sample) TimeSeries {
+	return TimeSeries{Labels: []Label{{"job", job}, {"__name__", "up"}}, Samples: samples}
+}
+
+func TestWriteRead(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		a := NewAdapter(red, &Option{KeyPrefix: "prom:"})
+		req := &WriteRequest{Timeseries: []TimeSeries{
+			series("a", Sample{1, 1000}, Sample{2, 2000}),
+			series("b", Sample{3, 1000}),
+		}}
+		if err := a.Write(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range []struct {
+			name     string
+			matchers []LabelMatcher
+			exp      []TimeSeries
+		}{
+			{"Equal", []LabelMatcher{{MatchEqual, "__name__", "up"}}, []TimeSeries{
+				{[]Label{{"__name__", "up"}, {"job", "a"}}, []Sample{{1, 1000}, {2, 2000}}},
+				{[]Label{{"__name__", "up"}, {"job", "b"}}, []Sample{{3, 1000}}},
+			}},
+			{"RegexpOnly", []LabelMatcher{{MatchRegexp, "job", "a.*"}}, []TimeSeries{
+				{[]Label{{"__name__", "up"}, {"job", "a"}}, []Sample{{1, 1000}, {2, 2000}}},
+			}},
+			{"NotEqualOnly", []LabelMatcher{{MatchNotEqual, "job", "a"}}, []TimeSeries{
+				{[]Label{{"__name__", "up"}, {"job", "b"}}, []Sample{{3, 1000}}},
+			}},
+		} {
+			resp, err := a.Read(ctx, &ReadRequest{Queries: []Query{{0, 3000, c.matchers}}})
+			if err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			} else if got := resp.Results[0].Timeseries; !reflect.DeepEqual(got, c.exp) {
+				t.Errorf("%s: %+v", c.name, got)
+			}
+		}
+	})
+}
+
+func TestReadUnfilterableValue(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		a := NewAdapter(red, nil)
+		if err := a.Write(ctx, &WriteRequest{Timeseries: []TimeSeries{series("a,b", Sample{1, 1000}), series("c", Sample{2, 1000})}}); err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range []struct {
+			name     string
+			matchers []LabelMatcher
+			exp      string
+		}{
+			{"Equal", []LabelMatcher{{MatchEqual, "job", "a,b"}}, "a,b"},
+			{"NotEqual", []LabelMatcher{{MatchEqual, "__name__", "up"}, {MatchNotEqual, "job", "a,b"}}, "c"},
+		} {
+			resp, err := a.Read(ctx, &ReadRequest{Queries: []Query{{0, 3000, c.matchers}}})
+			if err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			} else if ts := resp.Results[0].Timeseries; len(ts) != 1 || ts[0].Labels[1].Value != c.exp {
+				t.Errorf("%s: %+v", c.name, ts)
+			}
+		}
+	})
+}
+
+func TestWriteRejected(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		a := NewAdapter(red, &Option{SeriesOption: &time_series.Option{DupPolicy: time_series.DupPolicyBlock}})
+		if err := a.Write(ctx, &WriteRequest{Timeseries: []TimeSeries{series("a", Sample{1, 1000})}}); err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range []struct {
+			name   string
+			sample Sample
+			exp    error
+		}{
+			{"Duplicate", Sample{2, 1000}, redisstack.ErrDuplicateSample},
+			{"NaN", Sample{math.NaN(), 2000}, redisstack.ErrInvalidValue},
+		} {
+			err := a.Write(ctx, &WriteRequest{Timeseries: []TimeSeries{series("a", Sample{5, c.sample.Timestamp + 10000}, c.sample)}})
+			var werr *WriteError
+			if !errors.As(err, &werr) {
+				t.Fatalf("%s: %v", c.name, err)
+			} else if len(werr.Errors) != 1 || werr.Errors[0].Timestamp != c.sample.Timestamp || !errors.Is(werr.Errors[0].Err, c.exp) {
+				t.Errorf("%s: %v", c.name, err)
+			} else if werr.Retryable() {
+				t.Errorf("%s: retryable", c.name)
+			}
+		}
+	})
+}
+
+func TestWriteRecreatesDeletedSeries(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		a := NewAdapter(red, nil)
+		req := &WriteRequest{Timeseries: []TimeSeries{series("a", Sample{1, 1000})}}
+		if err := a.Write(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		if err := red.Del(ctx, SeriesKey("", req.Timeseries[0].Labels)).Err(); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Write(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := a.Read(ctx, &ReadRequest{Queries: []Query{{0, 3000, []LabelMatcher{{MatchEqual, "job", "a"}}}}})
+		if err != nil {
+			t.Fatal(err)
+		} else if ts := resp.Results[0].Timeseries; len(ts) != 1 || len(ts[0].Labels) != 2 {
+			t.Errorf("%+v", ts)
+		}
+	})
+}
+
+func TestWriteHandler(t *testing.T) {
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		a := NewAdapter(red, &Option{SeriesOption: &time_series.Option{DupPolicy: time_series.DupPolicyBlock}})
+		h := a.WriteHandler()
+		post := func(req *WriteRequest) int {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/write", bytes.NewReader(snappy.Encode(nil, req.Marshal()))))
+			return rec.Code
+		}
+		req := &WriteRequest{Timeseries: []TimeSeries{series("a", Sample{1, 1000})}}
+		if code := post(req); code != http.StatusNoContent {
+			t.Errorf("write: %d", code)
+		}
+		if code := post(req); code != http.StatusBadRequest {
+			t.Errorf("duplicate: %d", code)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/write", bytes.NewReader([]byte("x"))))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("malformed: %d", rec.Code)
+		}
+	})
+}
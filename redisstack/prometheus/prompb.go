@@ -0,0 +1,334 @@
+package prometheus
+
+import (
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+type WriteRequest struct {
+	Timeseries []TimeSeries
+}
+
+type MatchType byte
+
+const (
+	MatchEqual = MatchType(iota)
+	MatchNotEqual
+	MatchRegexp
+	MatchNotRegexp
+)
+
+type LabelMatcher struct {
+	Type  MatchType
+	Name  string
+	Value string
+}
+
+type Query struct {
+	StartTimestampMs int64
+	EndTimestampMs   int64
+	Matchers         []LabelMatcher
+}
+
+type ReadRequest struct {
+	Queries []Query
+}
+
+type QueryResult struct {
+	Timeseries []TimeSeries
+}
+
+type ReadResponse struct {
+	Results []QueryResult
+}
+
+func consumeMessage(b []byte, f func(num protowire.Number, typ protowire.Type, b []byte) int) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if n = f(num, typ, b); n == 0 {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func consumeEmbedded(typ protowire.Type, b []byte, unmarshal func([]byte) error) int {
+	if typ != protowire.BytesType {
+		return 0
+	}
+	v, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return n
+	} else if unmarshal(v) != nil {
+		return -1
+	}
+	return n
+}
+
+func consumeString(typ protowire.Type, b []byte, s *string) int {
+	if typ != protowire.BytesType {
+		return 0
+	}
+	v, n := protowire.ConsumeString(b)
+	if n >= 0 {
+		*s = v
+	}
+	return n
+}
+
+func consumeInt64(typ protowire.Type, b []byte, i *int64) int {
+	if typ != protowire.VarintType {
+		return 0
+	}
+	v, n := protowire.ConsumeVarint(b)
+	if n >= 0 {
+		*i = int64(v)
+	}
+	return n
+}
+
+func appendEmbedded(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if len(s) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendInt64(b []byte, num protowire.Number, i int64) []byte {
+	if i == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(i))
+}
+
+func (l *Label) Unmarshal(b []byte) error {
+	return consumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			return consumeString(typ, b, &l.Name)
+		case 2:
+			return consumeString(typ, b, &l.Value)
+		}
+		return 0
+	})
+}
+
+func (l *Label) Marshal() []byte {
+	b := appendString(nil, 1, l.Name)
+	return appendString(b, 2, l.Value)
+}
+
+func (s *Sample) Unmarshal(b []byte) error {
+	return consumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			if typ != protowire.Fixed64Type {
+				return 0
+			}
+			v, n := protowire.ConsumeFixed64(b)
+			s.Value = math.Float64frombits(v)
+			return n
+		case 2:
+			return consumeInt64(typ, b, &s.Timestamp)
+		}
+		return 0
+	})
+}
+
+func (s *Sample) Marshal() []byte {
+	var b []byte
+	if s.Value != 0 || math.Signbit(s.Value) {
+		b = protowire.AppendTag(b, 1, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(s.Value))
+	}
+	return appendInt64(b, 2, s.Timestamp)
+}
+
+func (ts *TimeSeries) Unmarshal(b []byte) error {
+	return consumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			return consumeEmbedded(typ, b, func(b []byte) error {
+				ts.Labels = append(ts.Labels, Label{})
+				return ts.Labels[len(ts.Labels)-1].Unmarshal(b)
+			})
+		case 2:
+			return consumeEmbedded(typ, b, func(b []byte) error {
+				ts.Samples = append(ts.Samples, Sample{})
+				return ts.Samples[len(ts.Samples)-1].Unmarshal(b)
+			})
+		}
+		return 0
+	})
+}
+
+func (ts *TimeSeries) Marshal() []byte {
+	var b []byte
+	for i := range ts.Labels {
+		b = appendEmbedded(b, 1, ts.Labels[i].Marshal())
+	}
+	for i := range ts.Samples {
+		b = appendEmbedded(b, 2, ts.Samples[i].Marshal())
+	}
+	return b
+}
+
+func (req *WriteRequest) Unmarshal(b []byte) error {
+	return consumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		if num != 1 {
+			return 0
+		}
+		return consumeEmbedded(typ, b, func(b []byte) error {
+			req.Timeseries = append(req.Timeseries, TimeSeries{})
+			return req.Timeseries[len(req.Timeseries)-1].Unmarshal(b)
+		})
+	})
+}
+
+func (req *WriteRequest) Marshal() []byte {
+	var b []byte
+	for i := range req.Timeseries {
+		b = appendEmbedded(b, 1, req.Timeseries[i].Marshal())
+	}
+	return b
+}
+
+func (m *LabelMatcher) Unmarshal(b []byte) error {
+	return consumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			var t int64
+			n := consumeInt64(typ, b, &t)
+			m.Type = MatchType(t)
+			return n
+		case 2:
+			return consumeString(typ, b, &m.Name)
+		case 3:
+			return consumeString(typ, b, &m.Value)
+		}
+		return 0
+	})
+}
+
+func (m *LabelMatcher) Marshal() []byte {
+	b := appendInt64(nil, 1, int64(m.Type))
+	b = appendString(b, 2, m.Name)
+	return appendString(b, 3, m.Value)
+}
+
+func (q *Query) Unmarshal(b []byte) error {
+	return consumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			return consumeInt64(typ, b, &q.StartTimestampMs)
+		case 2:
+			return consumeInt64(typ, b, &q.EndTimestampMs)
+		case 3:
+			return consumeEmbedded(typ, b, func(b []byte) error {
+				q.Matchers = append(q.Matchers, LabelMatcher{})
+				return q.Matchers[len(q.Matchers)-1].Unmarshal(b)
+			})
+		}
+		return 0
+	})
+}
+
+func (q *Query) Marshal() []byte {
+	b := appendInt64(nil, 1, q.StartTimestampMs)
+	b = appendInt64(b, 2, q.EndTimestampMs)
+	for i := range q.Matchers {
+		b = appendEmbedded(b, 3, q.Matchers[i].Marshal())
+	}
+	return b
+}
+
+func (req *ReadRequest) Unmarshal(b []byte) error {
+	return consumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		if num != 1 {
+			return 0
+		}
+		return consumeEmbedded(typ, b, func(b []byte) error {
+			req.Queries = append(req.Queries, Query{})
+			return req.Queries[len(req.Queries)-1].Unmarshal(b)
+		})
+	})
+}
+
+func (req *ReadRequest) Marshal() []byte {
+	var b []byte
+	for i := range req.Queries {
+		b = appendEmbedded(b, 1, req.Queries[i].Marshal())
+	}
+	return b
+}
+
+func (r *QueryResult) Unmarshal(b []byte) error {
+	return consumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		if num != 1 {
+			return 0
+		}
+		return consumeEmbedded(typ, b, func(b []byte) error {
+			r.Timeseries = append(r.Timeseries, TimeSeries{})
+			return r.Timeseries[len(r.Timeseries)-1].Unmarshal(b)
+		})
+	})
+}
+
+func (r *QueryResult) Marshal() []byte {
+	var b []byte
+	for i := range r.Timeseries {
+		b = appendEmbedded(b, 1, r.Timeseries[i].Marshal())
+	}
+	return b
+}
+
+func (resp *ReadResponse) Unmarshal(b []byte) error {
+	return consumeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		if num != 1 {
+			return 0
+		}
+		return consumeEmbedded(typ, b, func(b []byte) error {
+			resp.Results = append(resp.Results, QueryResult{})
+			return resp.Results[len(resp.Results)-1].Unmarshal(b)
+		})
+	})
+}
+
+func (resp *ReadResponse) Marshal() []byte {
+	var b []byte
+	for i := range resp.Results {
+		b = appendEmbedded(b, 1, resp.Results[i].Marshal())
+	}
+	return b
+}
@@ -0,0 +1,332 @@
+package redisstacktest
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+)
+
+type bloomFilter struct {
+	Capacity   int64
+	ErrorRate  float64
+	Expansion  int64
+	NonScaling bool
+	Items      map[string]struct{}
+}
+
+var errBFNotFound = errors.New("ERR not found")
+
+func init() {
+	registerCommands(map[string]commandFunc{
+		"BF.ADD":       cmdBFAdd,
+		"BF.EXISTS":    cmdBFExists,
+		"BF.INFO":      cmdBFInfo,
+		"BF.INSERT":    cmdBFInsert,
+		"BF.LOADCHUNK": cmdBFLoadChunk,
+		"BF.MADD":      cmdBFMAdd,
+		"BF.MEXISTS":   cmdBFMExists,
+		"BF.RESERVE":   cmdBFReserve,
+		"BF.SCANDUMP":  cmdBFScanDump,
+	})
+}
+
+func newBloomFilter(errorRate float64, capacity int64, expansion int64, nonScaling bool) (*bloomFilter, error) {
+	if errorRate <= 0 || errorRate >= 1 {
+		return nil, errors.New("ERR (0 < error rate range < 1)")
+	} else if capacity <= 0 {
+		return nil, errors.New("ERR (capacity should be larger than 0)")
+	} else if expansion <= 0 {
+		return nil, errors.New("ERR expansion should be greater or equal to 1")
+	}
+	return &bloomFilter{capacity, errorRate, expansion, nonScaling, map[string]struct{}{}}, nil
+}
+
+func (bf *bloomFilter) numFilters() int64 {
+	n, total, capacity := int64(1), bf.Capacity, bf.Capacity
+	for !bf.NonScaling && int64(len(bf.Items)) > total {
+		capacity *= bf.Expansion
+		total += capacity
+		n++
+	}
+	return n
+}
+
+func (bf *bloomFilter) size() int64 {
+	bits := -float64(bf.Capacity) * math.Log(bf.ErrorRate) / (math.Ln2 * math.Ln2)
+	return int64(bits/8)*bf.numFilters() + 128
+}
+
+func (bf *bloomFilter) add(item string) (bool, error) {
+	if _, ok := bf.Items[item]; ok {
+		return false, nil
+	} else if bf.NonScaling && int64(len(bf.Items)) >= bf.Capacity {
+		return false, errors.New("ERR non scaling filter is full")
+	}
+	bf.Items[item] = struct{}{}
+	return true, nil
+}
+
+func (c *conn) bloomFilter(key string, create bool) (*bloomFilter, error) {
+	bf, ok, err := lookup[*bloomFilter](c, key)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		if !create {
+			return nil, nil
+		}
+		bf, _ = newBloomFilter(0.01, 100, 2, false)
+		c.s.keys[key] = bf
+	}
+	return bf, nil
+}
+
+func (c *conn) bloomFilterAdd(key string, items []string) any {
+	bf, err := c.bloomFilter(key, true)
+	if err != nil {
+		return err
+	}
+	res := make([]any, len(items))
+	for i, item := range items {
+		if ok, err := bf.add(item); err != nil {
+			res[i] = err
+		} else {
+			res[i] = ok
+		}
+	}
+	return res
+}
+
+func (c *conn) bloomFilterExists(key string, items []string) any {
+	bf, err := c.bloomFilter(key, false)
+	if err != nil {
+		return err
+	}
+	res := make([]any, len(items))
+	for i, item := range items {
+		res[i] = false
+		if bf != nil {
+			_, res[i] = bf.Items[item]
+		}
+	}
+	return res
+}
+
+func cmdBFAdd(c *conn, args []string) any {
+	if len(args) != 2 {
+		return errWrongArgs("bf.add")
+	}
+	res := c.bloomFilterAdd(args[0], args[1:])
+	if arr, ok := res.([]any); ok {
+		return arr[0]
+	}
+	return res
+}
+
+func cmdBFMAdd(c *conn, args []string) any {
+	if len(args) < 2 {
+		return errWrongArgs("bf.madd")
+	}
+	return c.bloomFilterAdd(args[0], args[1:])
+}
+
+func cmdBFExists(c *conn, args []string) any {
+	if len(args) != 2 {
+		return errWrongArgs("bf.exists")
+	}
+	res := c.bloomFilterExists(args[0], args[1:])
+	if arr, ok := res.([]any); ok {
+		return arr[0]
+	}
+	return res
+}
+
+func cmdBFMExists(c *conn, args []string) any {
+	if len(args) < 2 {
+		return errWrongArgs("bf.mexists")
+	}
+	return c.bloomFilterExists(args[0], args[1:])
+}
+
+func cmdBFReserve(c *conn, args []string) any {
+	if len(args) < 3 {
+		return errWrongArgs("bf.reserve")
+	}
+	errorRate, err := parseFloat(args[1])
+	if err != nil {
+		return errors.New("ERR bad error rate")
+	}
+	capacity, err := parseInt(args[2])
+	if err != nil {
+		return errors.New("ERR bad capacity")
+	}
+	expansion, nonScaling := int64(2), false
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "EXPANSION":
+			if i++; i >= len(args) {
+				return errSyntax
+			} else if expansion, err = parseInt(args[i]); err != nil {
+				return errors.New("ERR bad expansion")
+			}
+		case "NONSCALING":
+			nonScaling = true
+		default:
+			return errSyntax
+		}
+	}
+	if _, ok := c.s.keys[args[0]]; ok {
+		return errors.New("ERR item exists")
+	}
+	bf, err := newBloomFilter(errorRate, capacity, expansion, nonScaling)
+	if err != nil {
+		return err
+	}
+	c.s.keys[args[0]] = bf
+	return status("OK")
+}
+
+func cmdBFInsert(c *conn, args []string) any {
+	if len(args) < 1 {
+		return errWrongArgs("bf.insert")
+	}
+	errorRate, capacity, expansion, nonScaling, noCreate := 0.01, int64(100), int64(2), false, false
+	var items []string
+	var err error
+loop:
+	for i := 1; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "CAPACITY":
+			if i++; i >= len(args) {
+				return errSyntax
+			} else if capacity, err = parseInt(args[i]); err != nil {
+				return errors.New("ERR bad capacity")
+			}
+		case "ERROR":
+			if i++; i >= len(args) {
+				return errSyntax
+			} else if errorRate, err = parseFloat(args[i]); err != nil {
+				return errors.New("ERR bad error rate")
+			}
+		case "EXPANSION":
+			if i++; i >= len(args) {
+				return errSyntax
+			} else if expansion, err = parseInt(args[i]); err != nil {
+				return errors.New("ERR bad expansion")
+			}
+		case "NOCREATE":
+			noCreate = true
+		case "NONSCALING":
+			nonScaling = true
+		case "ITEMS":
+			items = args[i+1:]
+			break loop
+		default:
+			return errSyntax
+		}
+	}
+	if len(items) == 0 {
+		return errWrongArgs("bf.insert")
+	}
+	bf, err := c.bloomFilter(args[0], false)
+	if err != nil {
+		return err
+	} else if bf == nil {
+		if noCreate {
+			return errBFNotFound
+		} else if bf, err = newBloomFilter(errorRate, capacity, expansion, nonScaling); err != nil {
+			return err
+		}
+		c.s.keys[args[0]] = bf
+	}
+	return c.bloomFilterAdd(args[0], items)
+}
+
+func cmdBFInfo(c *conn, args []string) any {
+	if len(args) != 1 && len(args) != 2 {
+		return errWrongArgs("bf.info")
+	}
+	bf, err := c.bloomFilter(args[0], false)
+	if err != nil {
+		return err
+	} else if bf == nil {
+		return errBFNotFound
+	}
+	var expansion any
+	if !bf.NonScaling {
+		expansion = bf.Expansion
+	}
+	if len(args) == 2 {
+		var v any
+		switch strings.ToUpper(args[1]) {
+		case "CAPACITY":
+			v = bf.Capacity
+		case "SIZE":
+			v = bf.size()
+		case "FILTERS":
+			v = bf.numFilters()
+		case "ITEMS":
+			v = int64(len(bf.Items))
+		case "EXPANSION":
+			v = expansion
+		default:
+			return errors.New("ERR Invalid information value")
+		}
+		return []any{v}
+	}
+	return replyMap{
+		{"Capacity", bf.Capacity},
+		{"Size", bf.size()},
+		{"Number of filters", bf.numFilters()},
+		{"Number of items inserted", int64(len(bf.Items))},
+		{"Expansion rate", expansion},
+	}
+}
+
+func cmdBFScanDump(c *conn, args []string) any {
+	if len(args) != 2 {
+		return errWrongArgs("bf.scandump")
+	}
+	iter, err := parseInt(args[1])
+	if err != nil {
+		return err
+	}
+	bf, err := c.bloomFilter(args[0], false)
+	if err != nil {
+		return err
+	} else if bf == nil {
+		return errBFNotFound
+	}
+	return scanDump(bf, iter)
+}
+
+func cmdBFLoadChunk(c *conn, args []string) any {
+	if len(args) != 3 {
+		return errWrongArgs("bf.loadchunk")
+	}
+	bf := &bloomFilter{}
+	if err := loadChunk(bf, args[1], args[2]); err != nil {
+		return err
+	}
+	c.s.keys[args[0]] = bf
+	return status("OK")
+}
+
+func scanDump(v any, iter int64) any {
+	if iter != 0 {
+		return []any{int64(0), ""}
+	}
+	data, _ := json.Marshal(v)
+	return []any{int64(1), string(data)}
+}
+
+func loadChunk(v any, iter string, data string) error {
+	if i, err := parseInt(iter); err != nil {
+		return err
+	} else if i != 1 {
+		return errors.New("ERR invalid offset - must be >= 1")
+	} else if err := json.Unmarshal([]byte(data), v); err != nil {
+		return errors.New("ERR received bad data")
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package redisstacktest
+
+import "testing"
+
+func TestBloomFilter(t *testing.T) {
+	runCases(t, []testCase{
+		{[]any{"BF.RESERVE", "bf", "0.01", "100"}, "OK", "OK"},
+		{[]any{"BF.ADD", "bf", "a"}, int64(1), true},
+		{[]any{"BF.ADD", "bf", "a"}, int64(0), false},
+		{[]any{"BF.MEXISTS", "bf", "a", "b"}, []any{int64(1), int64(0)}, []any{true, false}},
+		{[]any{"BF.INFO", "bf", "CAPACITY"}, []any{int64(100)}, []any{int64(100)}},
+		{[]any{"BF.INFO", "bf"},
+			[]any{"Capacity", int64(100), "Size", int64(247), "Number of filters", int64(1), "Number of items inserted", int64(1), "Expansion rate", int64(2)},
+			map[any]any{"Capacity": int64(100), "Size": int64(247), "Number of filters": int64(1), "Number of items inserted": int64(1), "Expansion rate": int64(2)}},
+	})
+}
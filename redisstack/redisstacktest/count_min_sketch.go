@@ -0,0 +1,179 @@
+package redisstacktest
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+type countMinSketch struct {
+	width  int64
+	depth  int64
+	count  int64
+	counts map[string]int64
+}
+
+var (
+	errCMSNotFound = errors.New("CMS: key does not exist")
+	errCMSExists   = errors.New("CMS: key already exists")
+)
+
+func init() {
+	registerCommands(map[string]commandFunc{
+		"CMS.INCRBY":     cmdCMSIncrBy,
+		"CMS.INFO":       cmdCMSInfo,
+		"CMS.INITBYDIM":  cmdCMSInitByDim,
+		"CMS.INITBYPROB": cmdCMSInitByProb,
+		"CMS.MERGE":      cmdCMSMerge,
+		"CMS.QUERY":      cmdCMSQuery,
+	})
+}
+
+func (c *conn) countMinSketch(key string) (*countMinSketch, error) {
+	cms, ok, err := lookup[*countMinSketch](c, key)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errCMSNotFound
+	}
+	return cms, nil
+}
+
+func (c *conn) initCountMinSketch(key string, width int64, depth int64) any {
+	if _, ok := c.s.keys[key]; ok {
+		return errCMSExists
+	} else if width <= 0 || depth <= 0 {
+		return errors.New("CMS: invalid width/depth")
+	}
+	c.s.keys[key] = &countMinSketch{width, depth, 0, map[string]int64{}}
+	return status("OK")
+}
+
+func cmdCMSInitByDim(c *conn, args []string) any {
+	if len(args) != 3 {
+		return errWrongArgs("cms.initbydim")
+	}
+	width, err := parseInt(args[1])
+	if err != nil {
+		return errors.New("CMS: invalid width")
+	}
+	depth, err := parseInt(args[2])
+	if err != nil {
+		return errors.New("CMS: invalid depth")
+	}
+	return c.initCountMinSketch(args[0], width, depth)
+}
+
+func cmdCMSInitByProb(c *conn, args []string) any {
+	if len(args) != 3 {
+		return errWrongArgs("cms.initbyprob")
+	}
+	errorRate, err := parseFloat(args[1])
+	if err != nil || errorRate <= 0 || errorRate >= 1 {
+		return errors.New("CMS: invalid overestimation value")
+	}
+	prob, err := parseFloat(args[2])
+	if err != nil || prob <= 0 || prob >= 1 {
+		return errors.New("CMS: invalid prob value")
+	}
+	width := int64(math.Ceil(2 / errorRate))
+	depth := int64(math.Ceil(math.Log10(prob) / math.Log10(0.5)))
+	return c.initCountMinSketch(args[0], width, depth)
+}
+
+func cmdCMSIncrBy(c *conn, args []string) any {
+	if len(args) < 3 || len(args)%2 != 1 {
+		return errWrongArgs("cms.incrby")
+	}
+	cms, err := c.countMinSketch(args[0])
+	if err != nil {
+		return err
+	}
+	res := make([]any, 0, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		incr, err := parseInt(args[i+1])
+		if err != nil || incr < 0 {
+			return errors.New("CMS: Cannot parse number")
+		}
+		cms.counts[args[i]] += incr
+		cms.count += incr
+		res = append(res, cms.counts[args[i]])
+	}
+	return res
+}
+
+func cmdCMSQuery(c *conn, args []string) any {
+	if len(args) < 2 {
+		return errWrongArgs("cms.query")
+	}
+	cms, err := c.countMinSketch(args[0])
+	if err != nil {
+		return err
+	}
+	res := make([]any, len(args)-1)
+	for i, item := range args[1:] {
+		res[i] = cms.counts[item]
+	}
+	return res
+}
+
+func cmdCMSMerge(c *conn, args []string) any {
+	if len(args) < 3 {
+		return errWrongArgs("cms.merge")
+	}
+	dest, err := c.countMinSketch(args[0])
+	if err != nil {
+		return err
+	}
+	n, err := parseInt(args[1])
+	if err != nil || n <= 0 || int64(len(args)) < 2+n {
+		return errors.New("CMS: invalid numkeys")
+	}
+	srcKeys, rest := args[2:2+n], args[2+n:]
+	weights := make([]int64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+	if len(rest) > 0 {
+		if strings.ToUpper(rest[0]) != "WEIGHTS" || int64(len(rest)) != 1+n {
+			return errSyntax
+		}
+		for i, w := range rest[1:] {
+			if weights[i], err = parseInt(w); err != nil {
+				return errors.New("CMS: invalid weight value")
+			}
+		}
+	}
+	srcs := make([]*countMinSketch, n)
+	for i, key := range srcKeys {
+		if srcs[i], err = c.countMinSketch(key); err != nil {
+			return err
+		} else if srcs[i].width != dest.width || srcs[i].depth != dest.depth {
+			return errors.New("CMS: width/depth is not equal")
+		}
+	}
+	counts, count := map[string]int64{}, int64(0)
+	for i, src := range srcs {
+		for item, v := range src.counts {
+			counts[item] += v * weights[i]
+		}
+		count += src.count * weights[i]
+	}
+	dest.counts, dest.count = counts, count
+	return status("OK")
+}
+
+func cmdCMSInfo(c *conn, args []string) any {
+	if len(args) != 1 {
+		return errWrongArgs("cms.info")
+	}
+	cms, err := c.countMinSketch(args[0])
+	if err != nil {
+		return err
+	}
+	return replyMap{
+		{"width", cms.width},
+		{"depth", cms.depth},
+		{"count", cms.count},
+	}
+}
@@ -0,0 +1,14 @@
+package redisstacktest
+
+import "testing"
+
+func TestCountMinSketch(t *testing.T) {
+	runCases(t, []testCase{
+		{[]any{"CMS.INITBYDIM", "cms", 10, 2}, "OK", "OK"},
+		{[]any{"CMS.INCRBY", "cms", "a", 3, "b", 1}, []any{int64(3), int64(1)}, []any{int64(3), int64(1)}},
+		{[]any{"CMS.QUERY", "cms", "a", "c"}, []any{int64(3), int64(0)}, []any{int64(3), int64(0)}},
+		{[]any{"CMS.INFO", "cms"},
+			[]any{"width", int64(10), "depth", int64(2), "count", int64(4)},
+			map[any]any{"width": int64(10), "depth": int64(2), "count": int64(4)}},
+	})
+}
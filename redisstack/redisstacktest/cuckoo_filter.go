@@ -0,0 +1,288 @@
+package redisstacktest
+
+import (
+	"errors"
+	"strings"
+)
+
+type cuckooFilter struct {
+	Capacity      int64
+	BucketSize    int64
+	MaxIterations int64
+	Expansion     int64
+	Items         map[string]int64
+	NumInserted   int64
+	NumDeleted    int64
+}
+
+func init() {
+	registerCommands(map[string]commandFunc{
+		"CF.ADD":       cmdCFAdd,
+		"CF.ADDNX":     cmdCFAddNX,
+		"CF.COUNT":     cmdCFCount,
+		"CF.DEL":       cmdCFDel,
+		"CF.EXISTS":    cmdCFExists,
+		"CF.INFO":      cmdCFInfo,
+		"CF.INSERT":    cmdCFInsert,
+		"CF.INSERTNX":  cmdCFInsertNX,
+		"CF.LOADCHUNK": cmdCFLoadChunk,
+		"CF.MEXISTS":   cmdCFMExists,
+		"CF.RESERVE":   cmdCFReserve,
+		"CF.SCANDUMP":  cmdCFScanDump,
+	})
+}
+
+func newCuckooFilter(capacity int64) *cuckooFilter {
+	return &cuckooFilter{capacity, 2, 20, 1, map[string]int64{}, 0, 0}
+}
+
+func (cf *cuckooFilter) numBuckets() int64 {
+	n := int64(1)
+	for n*cf.BucketSize < cf.Capacity {
+		n <<= 1
+	}
+	return n
+}
+
+func (cf *cuckooFilter) add(item string, nx bool) bool {
+	if nx && cf.Items[item] > 0 {
+		return false
+	}
+	cf.Items[item]++
+	cf.NumInserted++
+	return true
+}
+
+func (c *conn) cuckooFilter(key string, create bool, capacity int64) (*cuckooFilter, error) {
+	cf, ok, err := lookup[*cuckooFilter](c, key)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		if !create {
+			return nil, nil
+		}
+		cf = newCuckooFilter(capacity)
+		c.s.keys[key] = cf
+	}
+	return cf, nil
+}
+
+func cmdCFReserve(c *conn, args []string) any {
+	if len(args) < 2 {
+		return errWrongArgs("cf.reserve")
+	}
+	capacity, err := parseInt(args[1])
+	if err != nil || capacity <= 0 {
+		return errors.New("ERR Bad capacity")
+	}
+	cf := newCuckooFilter(capacity)
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return errSyntax
+		}
+		v, err := parseInt(args[i+1])
+		if err != nil {
+			return err
+		}
+		switch strings.ToUpper(args[i]) {
+		case "BUCKETSIZE":
+			cf.BucketSize = v
+		case "MAXITERATIONS":
+			cf.MaxIterations = v
+		case "EXPANSION":
+			cf.Expansion = v
+		default:
+			return errSyntax
+		}
+	}
+	if _, ok := c.s.keys[args[0]]; ok {
+		return errors.New("ERR item exists")
+	}
+	c.s.keys[args[0]] = cf
+	return status("OK")
+}
+
+func cfAdd(c *conn, args []string, name string, nx bool) any {
+	if len(args) != 2 {
+		return errWrongArgs(name)
+	}
+	cf, err := c.cuckooFilter(args[0], true, 1024)
+	if err != nil {
+		return err
+	}
+	return cf.add(args[1], nx)
+}
+
+func cmdCFAdd(c *conn, args []string) any {
+	return cfAdd(c, args, "cf.add", false)
+}
+
+func cmdCFAddNX(c *conn, args []string) any {
+	return cfAdd(c, args, "cf.addnx", true)
+}
+
+func cfInsert(c *conn, args []string, name string, nx bool) any {
+	if len(args) < 1 {
+		return errWrongArgs(name)
+	}
+	capacity, noCreate := int64(1024), false
+	var items []string
+	var err error
+loop:
+	for i := 1; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "CAPACITY":
+			if i++; i >= len(args) {
+				return errSyntax
+			} else if capacity, err = parseInt(args[i]); err != nil {
+				return err
+			}
+		case "NOCREATE":
+			noCreate = true
+		case "ITEMS":
+			items = args[i+1:]
+			break loop
+		default:
+			return errSyntax
+		}
+	}
+	if len(items) == 0 {
+		return errWrongArgs(name)
+	}
+	cf, err := c.cuckooFilter(args[0], !noCreate, capacity)
+	if err != nil {
+		return err
+	} else if cf == nil {
+		return errBFNotFound
+	}
+	res := make([]any, len(items))
+	for i, item := range items {
+		if cf.add(item, nx) {
+			res[i] = int64(1)
+		} else {
+			res[i] = int64(0)
+		}
+	}
+	return res
+}
+
+func cmdCFInsert(c *conn, args []string) any {
+	return cfInsert(c, args, "cf.insert", false)
+}
+
+func cmdCFInsertNX(c *conn, args []string) any {
+	return cfInsert(c, args, "cf.insertnx", true)
+}
+
+func cfExists(c *conn, key string, items []string) any {
+	cf, err := c.cuckooFilter(key, false, 0)
+	if err != nil {
+		return err
+	}
+	res := make([]any, len(items))
+	for i, item := range items {
+		res[i] = cf != nil && cf.Items[item] > 0
+	}
+	return res
+}
+
+func cmdCFExists(c *conn, args []string) any {
+	if len(args) != 2 {
+		return errWrongArgs("cf.exists")
+	}
+	res := cfExists(c, args[0], args[1:])
+	if arr, ok := res.([]any); ok {
+		return arr[0]
+	}
+	return res
+}
+
+func cmdCFMExists(c *conn, args []string) any {
+	if len(args) < 2 {
+		return errWrongArgs("cf.mexists")
+	}
+	return cfExists(c, args[0], args[1:])
+}
+
+func cmdCFDel(c *conn, args []string) any {
+	if len(args) != 2 {
+		return errWrongArgs("cf.del")
+	}
+	cf, err := c.cuckooFilter(args[0], false, 0)
+	if err != nil {
+		return err
+	} else if cf == nil {
+		return errBFNotFound
+	} else if cf.Items[args[1]] == 0 {
+		return false
+	}
+	if cf.Items[args[1]]--; cf.Items[args[1]] == 0 {
+		delete(cf.Items, args[1])
+	}
+	cf.NumDeleted++
+	return true
+}
+
+func cmdCFCount(c *conn, args []string) any {
+	if len(args) != 2 {
+		return errWrongArgs("cf.count")
+	}
+	cf, err := c.cuckooFilter(args[0], false, 0)
+	if err != nil {
+		return err
+	} else if cf == nil {
+		return int64(0)
+	}
+	return cf.Items[args[1]]
+}
+
+func cmdCFInfo(c *conn, args []string) any {
+	if len(args) != 1 {
+		return errWrongArgs("cf.info")
+	}
+	cf, err := c.cuckooFilter(args[0], false, 0)
+	if err != nil {
+		return err
+	} else if cf == nil {
+		return errBFNotFound
+	}
+	return replyMap{
+		{"Size", cf.numBuckets()*cf.BucketSize + 56},
+		{"Number of buckets", cf.numBuckets()},
+		{"Number of filters", int64(1)},
+		{"Number of items inserted", cf.NumInserted - cf.NumDeleted},
+		{"Number of items deleted", cf.NumDeleted},
+		{"Bucket size", cf.BucketSize},
+		{"Expansion rate", cf.Expansion},
+		{"Max iterations", cf.MaxIterations},
+	}
+}
+
+func cmdCFScanDump(c *conn, args []string) any {
+	if len(args) != 2 {
+		return errWrongArgs("cf.scandump")
+	}
+	iter, err := parseInt(args[1])
+	if err != nil {
+		return err
+	}
+	cf, err := c.cuckooFilter(args[0], false, 0)
+	if err != nil {
+		return err
+	} else if cf == nil {
+		return errBFNotFound
+	}
+	return scanDump(cf, iter)
+}
+
+func cmdCFLoadChunk(c *conn, args []string) any {
+	if len(args) != 3 {
+		return errWrongArgs("cf.loadchunk")
+	}
+	cf := &cuckooFilter{}
+	if err := loadChunk(cf, args[1], args[2]); err != nil {
+		return err
+	}
+	c.s.keys[args[0]] = cf
+	return status("OK")
+}
@@ -0,0 +1,17 @@
+package redisstacktest
+
+import "testing"
+
+func TestCuckooFilter(t *testing.T) {
+	runCases(t, []testCase{
+		{[]any{"CF.RESERVE", "cf", "100"}, "OK", "OK"},
+		{[]any{"CF.ADD", "cf", "a"}, int64(1), true},
+		{[]any{"CF.ADDNX", "cf", "a"}, int64(0), false},
+		{[]any{"CF.ADD", "cf", "a"}, int64(1), true},
+		{[]any{"CF.COUNT", "cf", "a"}, int64(2), int64(2)},
+		{[]any{"CF.DEL", "cf", "a"}, int64(1), true},
+		{[]any{"CF.EXISTS", "cf", "a"}, int64(1), true},
+		{[]any{"CF.DEL", "cf", "a"}, int64(1), true},
+		{[]any{"CF.MEXISTS", "cf", "a", "b"}, []any{int64(0), int64(0)}, []any{false, false}},
+	})
+}
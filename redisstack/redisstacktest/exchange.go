@@ -0,0 +1,161 @@
+package redisstacktest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-redis/redis/v9"
+)
+
+type Exchange struct {
+	Name    string
+	Command string
+	Replies map[int]string
+}
+
+func (e *Exchange) Reply(protocol int) (any, error) {
+	raw, ok := e.Replies[protocol]
+	if !ok {
+		return nil, fmt.Errorf("%s: no RESP%d reply recorded", e.Name, protocol)
+	}
+	return DecodeReply(raw)
+}
+
+func LoadExchanges(path string) (map[string]*Exchange, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]*Exchange{}
+	var e *Exchange
+	var reply []string
+	protocol := 0
+	flush := func() {
+		if e != nil && protocol != 0 {
+			e.Replies[protocol] = strings.Join(reply, "\r\n") + "\r\n"
+		}
+		reply, protocol = nil, 0
+	}
+	for i, line := range strings.Split(strings.TrimRight(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "=== "):
+			flush()
+			e = &Exchange{Name: line[4:], Replies: map[int]string{}}
+			if _, ok := res[e.Name]; ok {
+				return nil, fmt.Errorf("%s:%d: duplicate exchange %s", path, i+1, e.Name)
+			}
+			res[e.Name] = e
+		case e == nil:
+			if len(line) > 0 && line[0] != '#' {
+				return nil, fmt.Errorf("%s:%d: content outside an exchange", path, i+1)
+			}
+		case strings.HasPrefix(line, "> ") && protocol == 0:
+			e.Command = line[2:]
+		case line == "--- RESP2" || line == "--- RESP3":
+			flush()
+			protocol = int(line[len(line)-1] - '0')
+		case protocol != 0:
+			reply = append(reply, line)
+		default:
+			return nil, fmt.Errorf("%s:%d: unexpected line %q", path, i+1, line)
+		}
+	}
+	flush()
+	return res, nil
+}
+
+func formatArg(arg any) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	}
+	return fmt.Sprint(arg)
+}
+
+func FormatCommand(args []any) string {
+	ss := make([]string, len(args))
+	for i, arg := range args {
+		s := formatArg(arg)
+		if len(s) == 0 || strings.Contains(s, " ") || strconv.Quote(s) != `"`+s+`"` {
+			s = strconv.Quote(s)
+		}
+		ss[i] = s
+	}
+	return strings.Join(ss, " ")
+}
+
+func replay(nc net.Conn, raw string) {
+	defer nc.Close()
+	c := &conn{rd: bufio.NewReader(nc)}
+	for {
+		args, err := c.readCommand()
+		if err != nil {
+			return
+		}
+		reply := raw
+		if len(args) > 0 && strings.EqualFold(args[0], "HELLO") {
+			reply = "-ERR unknown command 'hello'\r\n"
+		}
+		if _, err := io.WriteString(nc, reply); err != nil {
+			return
+		}
+	}
+}
+
+func DecodeReply(raw string) (any, error) {
+	red := redis.NewClient(&redis.Options{
+		Dialer: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+			nc, sc := net.Pipe()
+			go replay(sc, raw)
+			return nc, nil
+		},
+		MaxRetries: -1,
+	})
+	defer red.Close()
+	val, err := red.Do(context.Background(), "REPLAY").Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return val, err
+}
+
+func CheckExchange(t testing.TB, exchanges map[string]*Exchange, name string, args []any, parse func(any) (any, error), exp any) {
+	t.Helper()
+	e, ok := exchanges[name]
+	if !ok {
+		t.Errorf("%s: no recorded exchange", name)
+		return
+	} else if cmd := FormatCommand(args); cmd != e.Command {
+		t.Errorf("%s: got command %s, recorded %s", name, cmd, e.Command)
+	}
+	for _, protocol := range []int{2, 3} {
+		val, err := e.Reply(protocol)
+		if err != nil {
+			t.Errorf("%s RESP%d: %v", name, protocol, err)
+		} else if res, err := parse(val); err != nil {
+			t.Errorf("%s RESP%d: %v", name, protocol, err)
+		} else if !reflect.DeepEqual(res, exp) {
+			t.Errorf("%s RESP%d: got %#v, expected %#v", name, protocol, res, exp)
+		}
+	}
+}
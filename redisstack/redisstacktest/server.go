@@ -0,0 +1,460 @@
+package redisstacktest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Option struct {
+	Addr     string
+	Protocol int
+}
+
+type Server struct {
+	ln       net.Listener
+	protocol int
+
+	mu   sync.Mutex
+	keys map[string]any
+
+	connMu sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func NewServer(opt *Option) (*Server, error) {
+	addr, protocol := "127.0.0.1:0", 3
+	if opt != nil {
+		if len(opt.Addr) > 0 {
+			addr = opt.Addr
+		}
+		if opt.Protocol != 0 {
+			protocol = opt.Protocol
+		}
+	}
+	if protocol != 2 && protocol != 3 {
+		return nil, fmt.Errorf("unsupported protocol %d", protocol)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		ln:       ln,
+		protocol: protocol,
+		keys:     map[string]any{},
+		conns:    map[net.Conn]struct{}{},
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *Server) FlushAll() {
+	s.mu.Lock()
+	s.keys = map[string]any{}
+	s.mu.Unlock()
+}
+
+func (s *Server) Close() error {
+	s.connMu.Lock()
+	if s.closed {
+		s.connMu.Unlock()
+		return nil
+	}
+	s.closed = true
+	err := s.ln.Close()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.connMu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		nc, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.connMu.Lock()
+		if s.closed {
+			s.connMu.Unlock()
+			nc.Close()
+			return
+		}
+		s.conns[nc] = struct{}{}
+		s.wg.Add(1)
+		s.connMu.Unlock()
+		go s.serveConn(nc)
+	}
+}
+
+type conn struct {
+	s        *Server
+	rd       *bufio.Reader
+	wr       *bufio.Writer
+	protocol int
+	inMulti  bool
+	aborted  bool
+	queued   [][]string
+}
+
+func (s *Server) serveConn(nc net.Conn) {
+	defer func() {
+		s.connMu.Lock()
+		delete(s.conns, nc)
+		s.connMu.Unlock()
+		nc.Close()
+		s.wg.Done()
+	}()
+	c := &conn{s: s, rd: bufio.NewReader(nc), wr: bufio.NewWriter(nc), protocol: 2}
+	for {
+		args, err := c.readCommand()
+		if err != nil {
+			return
+		} else if len(args) == 0 {
+			continue
+		}
+		c.writeReply(c.handle(args))
+		if c.rd.Buffered() == 0 {
+			if err := c.wr.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *conn) readCommand() ([]string, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		} else if len(line) == 0 || line[0] != '$' {
+			return nil, errors.New("protocol error")
+		}
+		l, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, l+2)
+		if _, err := io.ReadFull(c.rd, b); err != nil {
+			return nil, err
+		}
+		args = append(args, string(b[:l]))
+	}
+	return args, nil
+}
+
+func (c *conn) readLine() (string, error) {
+	line, err := c.rd.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+type status string
+
+type replyMap [][2]any
+
+type replySet []any
+
+type commandFunc func(c *conn, args []string) any
+
+var commands = map[string]commandFunc{}
+
+func registerCommands(m map[string]commandFunc) {
+	for name, f := range m {
+		commands[name] = f
+	}
+}
+
+func init() {
+	registerCommands(map[string]commandFunc{
+		"PING":     cmdPing,
+		"ECHO":     cmdEcho,
+		"SELECT":   cmdOK,
+		"CLIENT":   cmdOK,
+		"FLUSHALL": cmdFlushAll,
+		"FLUSHDB":  cmdFlushAll,
+		"DEL":      cmdDel,
+		"EXISTS":   cmdExists,
+	})
+}
+
+var errSyntax = errors.New("ERR syntax error")
+
+func errWrongArgs(name string) error {
+	return fmt.Errorf("ERR wrong number of arguments for '%s' command", strings.ToLower(name))
+}
+
+var errWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+func (c *conn) handle(args []string) any {
+	name := strings.ToUpper(args[0])
+	switch name {
+	case "HELLO":
+		return c.hello(args)
+	case "MULTI":
+		if c.inMulti {
+			return errors.New("ERR MULTI calls can not be nested")
+		}
+		c.inMulti, c.aborted, c.queued = true, false, nil
+		return status("OK")
+	case "EXEC":
+		if !c.inMulti {
+			return errors.New("ERR EXEC without MULTI")
+		} else if c.aborted {
+			c.inMulti, c.aborted, c.queued = false, false, nil
+			return errors.New("EXECABORT Transaction discarded because of previous errors.")
+		}
+		res := make([]any, len(c.queued))
+		c.s.mu.Lock()
+		for i, args1 := range c.queued {
+			res[i] = c.call(args1)
+		}
+		c.s.mu.Unlock()
+		c.inMulti, c.queued = false, nil
+		return res
+	case "DISCARD":
+		if !c.inMulti {
+			return errors.New("ERR DISCARD without MULTI")
+		}
+		c.inMulti, c.aborted, c.queued = false, false, nil
+		return status("OK")
+	}
+	if _, ok := commands[name]; !ok {
+		c.aborted = c.inMulti
+		return fmt.Errorf("ERR unknown command '%s'", args[0])
+	}
+	if c.inMulti {
+		c.queued = append(c.queued, args)
+		return status("QUEUED")
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	return c.call(args)
+}
+
+func (c *conn) call(args []string) any {
+	return commands[strings.ToUpper(args[0])](c, args[1:])
+}
+
+func (c *conn) hello(args []string) any {
+	if c.s.protocol == 2 {
+		return fmt.Errorf("ERR unknown command '%s'", args[0])
+	}
+	protocol := c.protocol
+	if len(args) > 1 {
+		p, err := strconv.Atoi(args[1])
+		if err != nil || (p != 2 && p != 3) {
+			return errors.New("NOPROTO unsupported protocol version")
+		}
+		protocol = p
+	}
+	c.protocol = protocol
+	return replyMap{
+		{"server", "redis"},
+		{"version", "7.2.0"},
+		{"proto", int64(protocol)},
+		{"id", int64(1)},
+		{"mode", "standalone"},
+		{"role", "master"},
+		{"modules", []any{}},
+	}
+}
+
+func cmdPing(c *conn, args []string) any {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return status("PONG")
+}
+
+func cmdEcho(c *conn, args []string) any {
+	if len(args) != 1 {
+		return errWrongArgs("echo")
+	}
+	return args[0]
+}
+
+func cmdOK(c *conn, args []string) any {
+	return status("OK")
+}
+
+func cmdFlushAll(c *conn, args []string) any {
+	c.s.keys = map[string]any{}
+	return status("OK")
+}
+
+func cmdDel(c *conn, args []string) any {
+	var n int64
+	for _, key := range args {
+		if _, ok := c.s.keys[key]; ok {
+			delete(c.s.keys, key)
+			n++
+		}
+	}
+	return n
+}
+
+func cmdExists(c *conn, args []string) any {
+	var n int64
+	for _, key := range args {
+		if _, ok := c.s.keys[key]; ok {
+			n++
+		}
+	}
+	return n
+}
+
+func lookup[T any](c *conn, key string) (T, bool, error) {
+	var t T
+	v, ok := c.s.keys[key]
+	if !ok {
+		return t, false, nil
+	}
+	t, ok = v.(T)
+	if !ok {
+		return t, false, errWrongType
+	}
+	return t, true, nil
+}
+
+func (c *conn) writeReply(v any) {
+	wr := c.wr
+	switch r := v.(type) {
+	case nil:
+		if c.protocol == 3 {
+			wr.WriteString("_\r\n")
+		} else {
+			wr.WriteString("$-1\r\n")
+		}
+	case error:
+		wr.WriteByte('-')
+		wr.WriteString(strings.ReplaceAll(r.Error(), "\r\n", " "))
+		wr.WriteString("\r\n")
+	case status:
+		wr.WriteByte('+')
+		wr.WriteString(string(r))
+		wr.WriteString("\r\n")
+	case string:
+		fmt.Fprintf(wr, "$%d\r\n%s\r\n", len(r), r)
+	case int64:
+		fmt.Fprintf(wr, ":%d\r\n", r)
+	case int:
+		fmt.Fprintf(wr, ":%d\r\n", r)
+	case bool:
+		if c.protocol == 3 {
+			if r {
+				wr.WriteString("#t\r\n")
+			} else {
+				wr.WriteString("#f\r\n")
+			}
+		} else if r {
+			wr.WriteString(":1\r\n")
+		} else {
+			wr.WriteString(":0\r\n")
+		}
+	case float64:
+		s := formatFloat(r)
+		if c.protocol == 3 {
+			wr.WriteByte(',')
+			wr.WriteString(s)
+			wr.WriteString("\r\n")
+		} else {
+			c.writeReply(s)
+		}
+	case []any:
+		fmt.Fprintf(wr, "*%d\r\n", len(r))
+		for _, e := range r {
+			c.writeReply(e)
+		}
+	case replySet:
+		if c.protocol == 3 {
+			fmt.Fprintf(wr, "~%d\r\n", len(r))
+			for _, e := range r {
+				c.writeReply(e)
+			}
+		} else {
+			c.writeReply([]any(r))
+		}
+	case replyMap:
+		if c.protocol == 3 {
+			fmt.Fprintf(wr, "%%%d\r\n", len(r))
+		} else {
+			fmt.Fprintf(wr, "*%d\r\n", len(r)*2)
+		}
+		for _, e := range r {
+			c.writeReply(e[0])
+			c.writeReply(e[1])
+		}
+	default:
+		c.writeReply(fmt.Errorf("ERR unsupported reply type %T", v))
+	}
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func parseInt(s string) (int64, error) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, errors.New("ERR value is not an integer or out of range")
+	}
+	return i, nil
+}
+
+func parseFloat(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, errors.New("ERR value is not a valid float")
+	}
+	return f, nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
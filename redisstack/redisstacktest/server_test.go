@@ -0,0 +1,117 @@
+package redisstacktest
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-redis/redis/v9"
+)
+
+func TestPing(t *testing.T) {
+	ctx := context.Background()
+	ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		if v, err := red.Ping(ctx).Result(); err != nil || v != "PONG" {
+			t.Errorf("ping: %v %v", v, err)
+		}
+		if v, err := red.Echo(ctx, "hello").Result(); err != nil || v != "hello" {
+			t.Errorf("echo: %v %v", v, err)
+		}
+	})
+}
+
+func TestKeys(t *testing.T) {
+	ctx := context.Background()
+	ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		if err := red.Do(ctx, "BF.ADD", "bf", "a").Err(); err != nil {
+			t.Fatal(err)
+		}
+		if err := red.Do(ctx, "TS.CREATE", "ts").Err(); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := red.Exists(ctx, "bf", "ts", "none").Result(); err != nil || n != 2 {
+			t.Errorf("exists: %v %v", n, err)
+		}
+		if n, err := red.Del(ctx, "bf", "none").Result(); err != nil || n != 1 {
+			t.Errorf("del: %v %v", n, err)
+		}
+		if err := red.FlushAll(ctx).Err(); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := red.Exists(ctx, "ts").Result(); err != nil || n != 0 {
+			t.Errorf("exists after flush: %v %v", n, err)
+		}
+	})
+}
+
+func TestUnknownCommand(t *testing.T) {
+	ctx := context.Background()
+	ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		err := red.Do(ctx, "NOSUCH").Err()
+		if err == nil || !strings.HasPrefix(err.Error(), "ERR unknown command") {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+}
+
+func TestMulti(t *testing.T) {
+	ctx := context.Background()
+	ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		cmds, err := red.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.Do(ctx, "TS.CREATE", "ts")
+			p.Do(ctx, "TS.ADD", "ts", 1, 1)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, err := cmds[1].(*redis.Cmd).Int64(); err != nil || v != 1 {
+			t.Errorf("add: %v %v", v, err)
+		}
+	})
+}
+
+func TestMultiUnknownCommand(t *testing.T) {
+	ctx := context.Background()
+	ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		_, err := red.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.Do(ctx, "TS.CREATE", "ts")
+			p.Do(ctx, "NOSUCH")
+			return nil
+		})
+		if err == nil || !strings.HasPrefix(err.Error(), "EXECABORT") {
+			t.Fatalf("expected EXECABORT, got %v", err)
+		}
+		if n, err := red.Exists(ctx, "ts").Result(); err != nil || n != 0 {
+			t.Errorf("aborted transaction was applied: %v %v", n, err)
+		}
+		if err := red.Do(ctx, "TS.CREATE", "ts").Err(); err != nil {
+			t.Errorf("connection not reset after EXECABORT: %v", err)
+		}
+	})
+}
+
+type testCase struct {
+	args  []any
+	resp2 any
+	resp3 any
+}
+
+func runCases(t *testing.T, cases []testCase) {
+	ctx := context.Background()
+	for _, protocol := range []int{2, 3} {
+		red := NewTestClient(t, protocol)
+		for _, c := range cases {
+			exp := c.resp3
+			if protocol == 2 {
+				exp = c.resp2
+			}
+			if v, err := red.Do(ctx, c.args...).Result(); err != nil {
+				t.Errorf("RESP%d %v: %v", protocol, c.args, err)
+			} else if !reflect.DeepEqual(v, exp) {
+				t.Errorf("RESP%d %v: got %#v, expected %#v", protocol, c.args, v, exp)
+			}
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package redisstacktest
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+)
+
+type tDigest struct {
+	compression int64
+	values      []float64
+}
+
+var (
+	errTDigestNotFound = errors.New("T-Digest: key does not exist")
+	errTDigestExists   = errors.New("T-Digest: key already exists")
+)
+
+func init() {
+	registerCommands(map[string]commandFunc{
+		"TDIGEST.ADD":          cmdTDigestAdd,
+		"TDIGEST.BYRANK":       cmdTDigestByRank,
+		"TDIGEST.BYREVRANK":    cmdTDigestByRevRank,
+		"TDIGEST.CDF":          cmdTDigestCDF,
+		"TDIGEST.CREATE":       cmdTDigestCreate,
+		"TDIGEST.INFO":         cmdTDigestInfo,
+		"TDIGEST.MAX":          cmdTDigestMax,
+		"TDIGEST.MERGE":        cmdTDigestMerge,
+		"TDIGEST.MIN":          cmdTDigestMin,
+		"TDIGEST.QUANTILE":     cmdTDigestQuantile,
+		"TDIGEST.RANK":         cmdTDigestRank,
+		"TDIGEST.RESET":        cmdTDigestReset,
+		"TDIGEST.REVRANK":      cmdTDigestRevRank,
+		"TDIGEST.TRIMMED_MEAN": cmdTDigestTrimmedMean,
+	})
+}
+
+func (c *conn) tDigest(key string) (*tDigest, error) {
+	td, ok, err := lookup[*tDigest](c, key)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errTDigestNotFound
+	}
+	return td, nil
+}
+
+func parseCompression(args []string) (int64, error) {
+	if len(args) == 0 {
+		return 100, nil
+	} else if len(args) != 2 || strings.ToUpper(args[0]) != "COMPRESSION" {
+		return 0, errSyntax
+	}
+	compression, err := parseInt(args[1])
+	if err != nil || compression <= 0 {
+		return 0, errors.New("T-Digest: error parsing compression parameter")
+	}
+	return compression, nil
+}
+
+func cmdTDigestCreate(c *conn, args []string) any {
+	if len(args) != 1 && len(args) != 3 {
+		return errWrongArgs("tdigest.create")
+	}
+	if _, ok := c.s.keys[args[0]]; ok {
+		return errTDigestExists
+	}
+	compression, err := parseCompression(args[1:])
+	if err != nil {
+		return err
+	}
+	c.s.keys[args[0]] = &tDigest{compression: compression}
+	return status("OK")
+}
+
+func cmdTDigestAdd(c *conn, args []string) any {
+	if len(args) < 2 {
+		return errWrongArgs("tdigest.add")
+	}
+	td, err := c.tDigest(args[0])
+	if err != nil {
+		return err
+	}
+	values := make([]float64, len(args)-1)
+	for i, arg := range args[1:] {
+		if values[i], err = parseFloat(arg); err != nil || math.IsNaN(values[i]) {
+			return errors.New("T-Digest: error parsing val parameter")
+		}
+	}
+	td.values = append(td.values, values...)
+	sort.Float64s(td.values)
+	return status("OK")
+}
+
+func cmdTDigestReset(c *conn, args []string) any {
+	if len(args) != 1 {
+		return errWrongArgs("tdigest.reset")
+	}
+	td, err := c.tDigest(args[0])
+	if err != nil {
+		return err
+	}
+	td.values = nil
+	return status("OK")
+}
+
+func cmdTDigestMerge(c *conn, args []string) any {
+	if len(args) < 3 {
+		return errWrongArgs("tdigest.merge")
+	}
+	n, err := parseInt(args[1])
+	if err != nil || n <= 0 || int64(len(args)) < 2+n {
+		return errors.New("T-Digest: error parsing numkeys")
+	}
+	srcKeys, rest := args[2:2+n], args[2+n:]
+	override := false
+	if len(rest) > 0 && strings.ToUpper(rest[len(rest)-1]) == "OVERRIDE" {
+		override, rest = true, rest[:len(rest)-1]
+	}
+	values, compression := []float64{}, int64(0)
+	for _, key := range srcKeys {
+		src, err := c.tDigest(key)
+		if err != nil {
+			return err
+		}
+		values = append(values, src.values...)
+		if src.compression > compression {
+			compression = src.compression
+		}
+	}
+	if len(rest) > 0 {
+		if compression, err = parseCompression(rest); err != nil {
+			return err
+		}
+	}
+	dest, ok, err := lookup[*tDigest](c, args[0])
+	if err != nil {
+		return err
+	} else if ok && !override {
+		values = append(values, dest.values...)
+		if len(rest) == 0 {
+			compression = dest.compression
+		}
+	}
+	sort.Float64s(values)
+	c.s.keys[args[0]] = &tDigest{compression, values}
+	return status("OK")
+}
+
+func tDigestValues(c *conn, name string, args []string, minArgs int) (*tDigest, []float64, error) {
+	if len(args) < minArgs {
+		return nil, nil, errWrongArgs(name)
+	}
+	td, err := c.tDigest(args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make([]float64, len(args)-1)
+	for i, arg := range args[1:] {
+		if values[i], err = parseFloat(arg); err != nil {
+			return nil, nil, errors.New("T-Digest: error parsing value")
+		}
+	}
+	return td, values, nil
+}
+
+func cmdTDigestQuantile(c *conn, args []string) any {
+	td, quantiles, err := tDigestValues(c, "tdigest.quantile", args, 2)
+	if err != nil {
+		return err
+	}
+	n := len(td.values)
+	res := make([]any, len(quantiles))
+	for i, q := range quantiles {
+		if q < 0 || q > 1 {
+			return errors.New("T-Digest: quantile should be in [0,1]")
+		} else if n == 0 {
+			res[i] = math.NaN()
+		} else {
+			res[i] = td.values[int(math.Min(q*float64(n), float64(n-1)))]
+		}
+	}
+	return res
+}
+
+func (td *tDigest) countBelow(v float64) (int, int) {
+	lt := sort.SearchFloat64s(td.values, v)
+	le := sort.Search(len(td.values), func(i int) bool { return td.values[i] > v })
+	return lt, le - lt
+}
+
+func cmdTDigestCDF(c *conn, args []string) any {
+	td, values, err := tDigestValues(c, "tdigest.cdf", args, 2)
+	if err != nil {
+		return err
+	}
+	n := float64(len(td.values))
+	res := make([]any, len(values))
+	for i, v := range values {
+		if n == 0 {
+			res[i] = math.NaN()
+			continue
+		}
+		lt, eq := td.countBelow(v)
+		res[i] = (float64(lt) + float64(eq)/2) / n
+	}
+	return res
+}
+
+func (td *tDigest) rank(v float64, rev bool) int64 {
+	n := len(td.values)
+	if n == 0 {
+		return -2
+	}
+	lt, eq := td.countBelow(v)
+	if rev {
+		lt = n - lt - eq
+	}
+	if lt == n {
+		return int64(n)
+	} else if lt+eq == 0 {
+		return -1
+	}
+	return int64(lt + eq/2)
+}
+
+func cmdTDigestRank(c *conn, args []string) any {
+	td, values, err := tDigestValues(c, "tdigest.rank", args, 2)
+	if err != nil {
+		return err
+	}
+	res := make([]any, len(values))
+	for i, v := range values {
+		res[i] = td.rank(v, false)
+	}
+	return res
+}
+
+func cmdTDigestRevRank(c *conn, args []string) any {
+	td, values, err := tDigestValues(c, "tdigest.revrank", args, 2)
+	if err != nil {
+		return err
+	}
+	res := make([]any, len(values))
+	for i, v := range values {
+		res[i] = td.rank(v, true)
+	}
+	return res
+}
+
+func (td *tDigest) byRank(args []string, rev bool) any {
+	n := int64(len(td.values))
+	res := make([]any, len(args))
+	for i, arg := range args {
+		rank, err := parseInt(arg)
+		if err != nil || rank < 0 {
+			return errors.New("T-Digest: rank needs to be non negative")
+		}
+		switch {
+		case n == 0:
+			res[i] = math.NaN()
+		case rank >= n && rev:
+			res[i] = math.Inf(-1)
+		case rank >= n:
+			res[i] = math.Inf(1)
+		case rev:
+			res[i] = td.values[n-1-rank]
+		default:
+			res[i] = td.values[rank]
+		}
+	}
+	return res
+}
+
+func cmdTDigestByRank(c *conn, args []string) any {
+	if len(args) < 2 {
+		return errWrongArgs("tdigest.byrank")
+	}
+	td, err := c.tDigest(args[0])
+	if err != nil {
+		return err
+	}
+	return td.byRank(args[1:], false)
+}
+
+func cmdTDigestByRevRank(c *conn, args []string) any {
+	if len(args) < 2 {
+		return errWrongArgs("tdigest.byrevrank")
+	}
+	td, err := c.tDigest(args[0])
+	if err != nil {
+		return err
+	}
+	return td.byRank(args[1:], true)
+}
+
+func cmdTDigestMin(c *conn, args []string) any {
+	if len(args) != 1 {
+		return errWrongArgs("tdigest.min")
+	}
+	td, err := c.tDigest(args[0])
+	if err != nil {
+		return err
+	} else if len(td.values) == 0 {
+		return math.NaN()
+	}
+	return td.values[0]
+}
+
+func cmdTDigestMax(c *conn, args []string) any {
+	if len(args) != 1 {
+		return errWrongArgs("tdigest.max")
+	}
+	td, err := c.tDigest(args[0])
+	if err != nil {
+		return err
+	} else if len(td.values) == 0 {
+		return math.NaN()
+	}
+	return td.values[len(td.values)-1]
+}
+
+func cmdTDigestTrimmedMean(c *conn, args []string) any {
+	td, cuts, err := tDigestValues(c, "tdigest.trimmed_mean", args, 3)
+	if err != nil {
+		return err
+	} else if len(cuts) != 2 {
+		return errWrongArgs("tdigest.trimmed_mean")
+	} else if cuts[0] < 0 || cuts[1] > 1 || cuts[0] >= cuts[1] {
+		return errors.New("T-Digest: low_cut_percentile and high_cut_percentile should be in [0,1]")
+	}
+	n := float64(len(td.values))
+	lo, hi := int(math.Floor(cuts[0]*n)), int(math.Ceil(cuts[1]*n))
+	if lo >= hi {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, v := range td.values[lo:hi] {
+		sum += v
+	}
+	return sum / float64(hi-lo)
+}
+
+func cmdTDigestInfo(c *conn, args []string) any {
+	if len(args) != 1 {
+		return errWrongArgs("tdigest.info")
+	}
+	td, err := c.tDigest(args[0])
+	if err != nil {
+		return err
+	}
+	n := int64(len(td.values))
+	return replyMap{
+		{"Compression", td.compression},
+		{"Capacity", td.compression*6 + 10},
+		{"Merged nodes", n},
+		{"Unmerged nodes", int64(0)},
+		{"Merged weight", n},
+		{"Unmerged weight", int64(0)},
+		{"Observations", n},
+		{"Total compressions", int64(0)},
+		{"Memory usage", n*16 + 64},
+	}
+}
@@ -0,0 +1,14 @@
+package redisstacktest
+
+import "testing"
+
+func TestTDigest(t *testing.T) {
+	runCases(t, []testCase{
+		{[]any{"TDIGEST.CREATE", "td"}, "OK", "OK"},
+		{[]any{"TDIGEST.ADD", "td", 1, 2, 3, 4}, "OK", "OK"},
+		{[]any{"TDIGEST.MIN", "td"}, "1", float64(1)},
+		{[]any{"TDIGEST.MAX", "td"}, "4", float64(4)},
+		{[]any{"TDIGEST.QUANTILE", "td", 0.5}, []any{"3"}, []any{float64(3)}},
+		{[]any{"TDIGEST.RANK", "td", 2}, []any{int64(1)}, []any{int64(1)}},
+	})
+}
@@ -0,0 +1,30 @@
+package redisstacktest
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/go-redis/redis/v9"
+)
+
+func NewTestClient(t testing.TB, protocol int) *redis.Client {
+	t.Helper()
+	s, err := NewServer(&Option{Protocol: protocol})
+	if err != nil {
+		t.Fatal(err)
+	}
+	red := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	t.Cleanup(func() {
+		red.Close()
+		s.Close()
+	})
+	return red
+}
+
+func ForEachProtocol(t *testing.T, f func(t *testing.T, red *redis.Client)) {
+	for _, protocol := range []int{2, 3} {
+		t.Run("RESP"+strconv.Itoa(protocol), func(t *testing.T) {
+			f(t, NewTestClient(t, protocol))
+		})
+	}
+}
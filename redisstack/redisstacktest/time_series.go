@@ -0,0 +1,1233 @@
+package redisstacktest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type tsSample struct {
+	t int64
+	v float64
+}
+
+type tsRule struct {
+	destKey    string
+	aggregator string
+	bucket     int64
+	align      int64
+	next       int64
+}
+
+type timeSeries struct {
+	retention    int64
+	chunkSize    int64
+	uncompressed bool
+	dupPolicy    string
+	labels       [][2]string
+	samples      []tsSample
+	rules        []*tsRule
+	srcKey       string
+}
+
+var (
+	errTSNotLatest    = errors.New("ERR TSDB: timestamp must be equal to or higher than the maximum existing timestamp")
+	errTSNotFound     = errors.New("ERR TSDB: the key does not exist")
+	errTSExists       = errors.New("ERR TSDB: key already exists")
+	errTSWrongType    = errors.New("ERR TSDB: the key is not a TSDB key")
+	errTSBlocked      = errors.New("ERR TSDB: Error at upsert, update is not supported when DUPLICATE_POLICY is set to BLOCK mode")
+	errTSOld          = errors.New("ERR TSDB: Timestamp is older than retention")
+	errTSBadTimestamp = errors.New("ERR TSDB: invalid timestamp")
+	errTSBadValue     = errors.New("ERR TSDB: invalid value")
+	errTSBadFilter    = errors.New("ERR TSDB: failed parsing labels")
+	errTSNoMatcher    = errors.New("ERR TSDB: please provide at least one matcher")
+)
+
+func init() {
+	registerCommands(map[string]commandFunc{
+		"TS.ADD":        cmdTSAdd,
+		"TS.ALTER":      cmdTSAlter,
+		"TS.CREATE":     cmdTSCreate,
+		"TS.CREATERULE": cmdTSCreateRule,
+		"TS.DEL":        cmdTSDel,
+		"TS.DELETERULE": cmdTSDeleteRule,
+		"TS.DECRBY":     cmdTSDecrBy,
+		"TS.GET":        cmdTSGet,
+		"TS.INCRBY":     cmdTSIncrBy,
+		"TS.INFO":       cmdTSInfo,
+		"TS.MADD":       cmdTSMAdd,
+		"TS.MGET":       cmdTSMGet,
+		"TS.MRANGE":     cmdTSMRange,
+		"TS.MREVRANGE":  cmdTSMRevRange,
+		"TS.QUERYINDEX": cmdTSQueryIndex,
+		"TS.RANGE":      cmdTSRange,
+		"TS.REVRANGE":   cmdTSRevRange,
+	})
+}
+
+var tsDupPolicies = map[string]struct{}{
+	"BLOCK": {}, "FIRST": {}, "LAST": {}, "MIN": {}, "MAX": {}, "SUM": {},
+}
+
+var tsAggregators = map[string]struct{}{
+	"AVG": {}, "SUM": {}, "MIN": {}, "MAX": {}, "RANGE": {}, "COUNT": {}, "FIRST": {}, "LAST": {},
+	"STD.P": {}, "STD.S": {}, "VAR.P": {}, "VAR.S": {}, "TWA": {},
+}
+
+var tsReducers = map[string]struct{}{
+	"AVG": {}, "SUM": {}, "MIN": {}, "MAX": {}, "RANGE": {}, "COUNT": {},
+	"STD.P": {}, "STD.S": {}, "VAR.P": {}, "VAR.S": {},
+}
+
+func (c *conn) timeSeries(key string) (*timeSeries, error) {
+	ts, ok, err := lookup[*timeSeries](c, key)
+	if err == errWrongType {
+		return nil, errTSWrongType
+	} else if !ok {
+		return nil, errTSNotFound
+	}
+	return ts, nil
+}
+
+type tsOption struct {
+	retention    *int64
+	chunkSize    *int64
+	uncompressed *bool
+	dupPolicy    string
+	labels       [][2]string
+	hasLabels    bool
+}
+
+func parseTSOption(args []string, dupPolicyTag string) (*tsOption, error) {
+	opt := &tsOption{}
+	for i := 0; i < len(args); i++ {
+		tag := strings.ToUpper(args[i])
+		if tag == "LABELS" {
+			rest := args[i+1:]
+			if len(rest)%2 != 0 {
+				return nil, errors.New("ERR TSDB: wrong number of label arguments")
+			}
+			opt.labels, opt.hasLabels = make([][2]string, len(rest)/2), true
+			for j := range opt.labels {
+				opt.labels[j] = [2]string{rest[j*2], rest[j*2+1]}
+			}
+			break
+		} else if tag == "UNCOMPRESSED" {
+			b := true
+			opt.uncompressed = &b
+			continue
+		}
+		if i++; i >= len(args) {
+			return nil, errSyntax
+		}
+		switch tag {
+		case "RETENTION":
+			v, err := parseInt(args[i])
+			if err != nil || v < 0 {
+				return nil, errors.New("ERR TSDB: Couldn't parse RETENTION")
+			}
+			opt.retention = &v
+		case "CHUNK_SIZE":
+			v, err := parseInt(args[i])
+			if err != nil || v < 48 || v > 1048576 || v%8 != 0 {
+				return nil, errors.New("ERR TSDB: CHUNK_SIZE value must be a multiple of 8 in the range [48 .. 1048576]")
+			}
+			opt.chunkSize = &v
+		case "ENCODING":
+			var b bool
+			switch strings.ToUpper(args[i]) {
+			case "COMPRESSED":
+			case "UNCOMPRESSED":
+				b = true
+			default:
+				return nil, errors.New("ERR TSDB: unknown ENCODING parameter")
+			}
+			opt.uncompressed = &b
+		default:
+			if tag != dupPolicyTag {
+				return nil, errSyntax
+			}
+			p := strings.ToUpper(args[i])
+			if _, ok := tsDupPolicies[p]; !ok {
+				return nil, errors.New("ERR TSDB: Unknown DUPLICATE_POLICY")
+			}
+			opt.dupPolicy = p
+		}
+	}
+	return opt, nil
+}
+
+func (opt *tsOption) apply(ts *timeSeries) {
+	if opt.retention != nil {
+		ts.retention = *opt.retention
+	}
+	if opt.chunkSize != nil {
+		ts.chunkSize = *opt.chunkSize
+	}
+	if opt.uncompressed != nil {
+		ts.uncompressed = *opt.uncompressed
+	}
+	if len(opt.dupPolicy) > 0 {
+		ts.dupPolicy = opt.dupPolicy
+	}
+	if opt.hasLabels {
+		ts.labels = opt.labels
+	}
+}
+
+func newTimeSeries(opt *tsOption) *timeSeries {
+	ts := &timeSeries{chunkSize: 4096, labels: [][2]string{}}
+	opt.apply(ts)
+	return ts
+}
+
+func (ts *timeSeries) label(name string) (string, bool) {
+	for _, label := range ts.labels {
+		if label[0] == name {
+			return label[1], true
+		}
+	}
+	return "", false
+}
+
+func (ts *timeSeries) upsert(t int64, v float64, policy string) (int64, error) {
+	if len(policy) == 0 {
+		policy = ts.dupPolicy
+	}
+	n := len(ts.samples)
+	if ts.retention > 0 && n > 0 && t < ts.samples[n-1].t-ts.retention {
+		return 0, errTSOld
+	}
+	i := sort.Search(n, func(i int) bool { return ts.samples[i].t >= t })
+	if i < n && ts.samples[i].t == t {
+		s := &ts.samples[i]
+		switch policy {
+		case "", "BLOCK":
+			return 0, errTSBlocked
+		case "LAST":
+			s.v = v
+		case "MIN":
+			s.v = math.Min(s.v, v)
+		case "MAX":
+			s.v = math.Max(s.v, v)
+		case "SUM":
+			s.v += v
+		}
+		return t, nil
+	}
+	ts.samples = append(ts.samples, tsSample{})
+	copy(ts.samples[i+1:], ts.samples[i:])
+	ts.samples[i] = tsSample{t, v}
+	return t, nil
+}
+
+func (c *conn) compact(ts *timeSeries) {
+	n := len(ts.samples)
+	if n == 0 {
+		return
+	}
+	for _, rule := range ts.rules {
+		dest, err := c.timeSeries(rule.destKey)
+		if err != nil {
+			continue
+		}
+		lastBucket := tsBucketStart(ts.samples[n-1].t, rule.bucket, rule.align)
+		if lastBucket <= rule.next {
+			continue
+		}
+		lo := sort.Search(n, func(i int) bool { return ts.samples[i].t >= rule.next })
+		hi := sort.Search(n, func(i int) bool { return ts.samples[i].t >= lastBucket })
+		var prev *tsSample
+		if lo > 0 {
+			prev = &ts.samples[lo-1]
+		}
+		agg := &tsAggregation{aggregator: rule.aggregator, bucket: rule.bucket, align: rule.align}
+		for _, s := range agg.run(ts.samples[lo:hi], prev, &ts.samples[hi]) {
+			dest.upsert(s.t, s.v, "LAST")
+		}
+		rule.next = lastBucket
+		c.compact(dest)
+	}
+}
+
+func parseTimestamp(s string, now bool) (int64, error) {
+	if now && s == "*" {
+		return time.Now().UnixMilli(), nil
+	}
+	t, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || t < 0 {
+		return 0, errTSBadTimestamp
+	}
+	return t, nil
+}
+
+func parseTSValue(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) {
+		return 0, errTSBadValue
+	}
+	return v, nil
+}
+
+func parseRangeTimestamp(s string) (int64, error) {
+	switch s {
+	case "-":
+		return 0, nil
+	case "+":
+		return math.MaxInt64, nil
+	}
+	return parseTimestamp(s, false)
+}
+
+func cmdTSCreate(c *conn, args []string) any {
+	if len(args) < 1 {
+		return errWrongArgs("ts.create")
+	}
+	opt, err := parseTSOption(args[1:], "DUPLICATE_POLICY")
+	if err != nil {
+		return err
+	} else if _, ok := c.s.keys[args[0]]; ok {
+		return errTSExists
+	}
+	c.s.keys[args[0]] = newTimeSeries(opt)
+	return status("OK")
+}
+
+func cmdTSAlter(c *conn, args []string) any {
+	if len(args) < 1 {
+		return errWrongArgs("ts.alter")
+	}
+	opt, err := parseTSOption(args[1:], "DUPLICATE_POLICY")
+	if err != nil {
+		return err
+	}
+	ts, err := c.timeSeries(args[0])
+	if err != nil {
+		return err
+	}
+	opt.apply(ts)
+	return status("OK")
+}
+
+func cmdTSAdd(c *conn, args []string) any {
+	if len(args) < 3 {
+		return errWrongArgs("ts.add")
+	}
+	t, err := parseTimestamp(args[1], true)
+	if err != nil {
+		return err
+	}
+	v, err := parseTSValue(args[2])
+	if err != nil {
+		return err
+	}
+	opt, err := parseTSOption(args[3:], "ON_DUPLICATE")
+	if err != nil {
+		return err
+	}
+	ts, err := c.timeSeries(args[0])
+	if err == errTSNotFound {
+		ts = newTimeSeries(opt)
+		c.s.keys[args[0]] = ts
+	} else if err != nil {
+		return err
+	}
+	if t, err = ts.upsert(t, v, opt.dupPolicy); err != nil {
+		return err
+	}
+	c.compact(ts)
+	return t
+}
+
+func (c *conn) incrBy(name string, args []string, sign float64) any {
+	if len(args) < 2 {
+		return errWrongArgs(name)
+	}
+	v, err := parseTSValue(args[1])
+	if err != nil {
+		return err
+	}
+	t, rest := time.Now().UnixMilli(), args[2:]
+	if len(rest) >= 2 && strings.ToUpper(rest[0]) == "TIMESTAMP" {
+		if t, err = parseTimestamp(rest[1], true); err != nil {
+			return err
+		}
+		rest = rest[2:]
+	}
+	opt, err := parseTSOption(rest, "DUPLICATE_POLICY")
+	if err != nil {
+		return err
+	}
+	ts, err := c.timeSeries(args[0])
+	if err == errTSNotFound {
+		ts = newTimeSeries(opt)
+		c.s.keys[args[0]] = ts
+	} else if err != nil {
+		return err
+	}
+	if n := len(ts.samples); n > 0 {
+		last := ts.samples[n-1]
+		if t < last.t {
+			return errTSNotLatest
+		}
+		v = last.v + sign*v
+		if t == last.t {
+			ts.samples[n-1].v = v
+			c.compact(ts)
+			return t
+		}
+	} else {
+		v *= sign
+	}
+	if t, err = ts.upsert(t, v, "LAST"); err != nil {
+		return err
+	}
+	c.compact(ts)
+	return t
+}
+
+func cmdTSIncrBy(c *conn, args []string) any {
+	return c.incrBy("ts.incrby", args, 1)
+}
+
+func cmdTSDecrBy(c *conn, args []string) any {
+	return c.incrBy("ts.decrby", args, -1)
+}
+
+func cmdTSInfo(c *conn, args []string) any {
+	if len(args) != 1 && (len(args) != 2 || strings.ToUpper(args[1]) != "DEBUG") {
+		return errWrongArgs("ts.info")
+	}
+	ts, err := c.timeSeries(args[0])
+	if err != nil {
+		return err
+	}
+	var first, last int64
+	if n := len(ts.samples); n > 0 {
+		first, last = ts.samples[0].t, ts.samples[n-1].t
+	}
+	chunkType := "compressed"
+	if ts.uncompressed {
+		chunkType = "uncompressed"
+	}
+	var dupPolicy, srcKey any
+	if len(ts.dupPolicy) > 0 {
+		dupPolicy = strings.ToLower(ts.dupPolicy)
+	}
+	if len(ts.srcKey) > 0 {
+		srcKey = ts.srcKey
+	}
+	var labels, rules any
+	if c.protocol == 3 {
+		labels1, rules1 := make(replyMap, len(ts.labels)), make(replyMap, len(ts.rules))
+		for i, label := range ts.labels {
+			labels1[i] = [2]any{label[0], label[1]}
+		}
+		for i, rule := range ts.rules {
+			rules1[i] = [2]any{rule.destKey, []any{rule.bucket, rule.aggregator, rule.align}}
+		}
+		labels, rules = labels1, rules1
+	} else {
+		labels1, rules1 := make([]any, len(ts.labels)), make([]any, len(ts.rules))
+		for i, label := range ts.labels {
+			labels1[i] = []any{label[0], label[1]}
+		}
+		for i, rule := range ts.rules {
+			rules1[i] = []any{rule.destKey, rule.bucket, rule.aggregator, rule.align}
+		}
+		labels, rules = labels1, rules1
+	}
+	size := int64(len(ts.samples)) * 16
+	res := replyMap{
+		{"totalSamples", int64(len(ts.samples))},
+		{"memoryUsage", size + 256},
+		{"firstTimestamp", first},
+		{"lastTimestamp", last},
+		{"retentionTime", ts.retention},
+		{"chunkCount", int64(1)},
+		{"chunkSize", ts.chunkSize},
+		{"chunkType", chunkType},
+		{"duplicatePolicy", dupPolicy},
+		{"labels", labels},
+		{"sourceKey", srcKey},
+		{"rules", rules},
+	}
+	if len(args) == 2 {
+		bytesPerSample := 0.0
+		if len(ts.samples) > 0 {
+			bytesPerSample = float64(ts.chunkSize) / float64(len(ts.samples))
+		}
+		res = append(res, [2]any{"Chunks", []any{replyMap{
+			{"startTimestamp", first},
+			{"endTimestamp", last},
+			{"samples", int64(len(ts.samples))},
+			{"size", ts.chunkSize},
+			{"bytesPerSample", strconv.FormatFloat(bytesPerSample, 'f', -1, 64)},
+		}}})
+	}
+	return res
+}
+
+func cmdTSMAdd(c *conn, args []string) any {
+	if len(args) < 3 || len(args)%3 != 0 {
+		return errWrongArgs("ts.madd")
+	}
+	res := make([]any, len(args)/3)
+	for i := range res {
+		key := args[i*3]
+		t, err := parseTimestamp(args[i*3+1], true)
+		if err != nil {
+			res[i] = err
+			continue
+		}
+		v, err := parseTSValue(args[i*3+2])
+		if err != nil {
+			res[i] = err
+			continue
+		}
+		ts, err := c.timeSeries(key)
+		if err != nil {
+			res[i] = err
+			continue
+		}
+		if t, err = ts.upsert(t, v, ""); err != nil {
+			res[i] = err
+			continue
+		}
+		c.compact(ts)
+		res[i] = t
+	}
+	return res
+}
+
+func cmdTSDel(c *conn, args []string) any {
+	if len(args) != 3 {
+		return errWrongArgs("ts.del")
+	}
+	ts, err := c.timeSeries(args[0])
+	if err != nil {
+		return err
+	}
+	from, err := parseRangeTimestamp(args[1])
+	if err != nil {
+		return err
+	}
+	to, err := parseRangeTimestamp(args[2])
+	if err != nil {
+		return err
+	}
+	samples := ts.samples[:0]
+	var n int64
+	for _, s := range ts.samples {
+		if s.t >= from && s.t <= to {
+			n++
+		} else {
+			samples = append(samples, s)
+		}
+	}
+	ts.samples = samples
+	return n
+}
+
+func cmdTSCreateRule(c *conn, args []string) any {
+	if len(args) != 5 && len(args) != 6 {
+		return errWrongArgs("ts.createrule")
+	}
+	if strings.ToUpper(args[2]) != "AGGREGATION" {
+		return errSyntax
+	}
+	aggregator := strings.ToUpper(args[3])
+	if _, ok := tsAggregators[aggregator]; !ok {
+		return errors.New("ERR TSDB: Unknown aggregation type")
+	}
+	bucket, err := parseInt(args[4])
+	if err != nil || bucket <= 0 {
+		return errors.New("ERR TSDB: bucketDuration must be greater than zero")
+	}
+	var align int64
+	if len(args) == 6 {
+		if align, err = parseInt(args[5]); err != nil {
+			return errors.New("ERR TSDB: invalid alignTimestamp")
+		}
+	}
+	if args[0] == args[1] {
+		return errors.New("ERR TSDB: the source key and destination key should be different")
+	}
+	src, err := c.timeSeries(args[0])
+	if err != nil {
+		return err
+	}
+	dest, err := c.timeSeries(args[1])
+	if err != nil {
+		return err
+	} else if len(dest.srcKey) > 0 {
+		return errors.New("ERR TSDB: the destination key already has a src rule")
+	}
+	rule := &tsRule{args[1], aggregator, bucket, align, math.MinInt64}
+	if n := len(src.samples); n > 0 {
+		rule.next = tsBucketStart(src.samples[n-1].t, bucket, align)
+	}
+	src.rules = append(src.rules, rule)
+	dest.srcKey = args[0]
+	return status("OK")
+}
+
+func cmdTSDeleteRule(c *conn, args []string) any {
+	if len(args) != 2 {
+		return errWrongArgs("ts.deleterule")
+	}
+	src, err := c.timeSeries(args[0])
+	if err != nil {
+		return err
+	}
+	for i, rule := range src.rules {
+		if rule.destKey == args[1] {
+			src.rules = append(src.rules[:i], src.rules[i+1:]...)
+			if dest, err := c.timeSeries(args[1]); err == nil {
+				dest.srcKey = ""
+			}
+			return status("OK")
+		}
+	}
+	return errors.New("ERR TSDB: compaction rule does not exist")
+}
+
+func sampleReply(s tsSample) any {
+	return []any{s.t, s.v}
+}
+
+func samplesReply(samples []tsSample) []any {
+	res := make([]any, len(samples))
+	for i, s := range samples {
+		res[i] = sampleReply(s)
+	}
+	return res
+}
+
+func cmdTSGet(c *conn, args []string) any {
+	if len(args) != 1 && len(args) != 2 {
+		return errWrongArgs("ts.get")
+	}
+	ts, err := c.timeSeries(args[0])
+	if err != nil {
+		return err
+	} else if len(ts.samples) == 0 {
+		return []any{}
+	}
+	return sampleReply(ts.samples[len(ts.samples)-1])
+}
+
+type tsAggregation struct {
+	aggregator string
+	bucket     int64
+	align      int64
+	offset     int64
+	empty      bool
+}
+
+type tsQuery struct {
+	from           int64
+	to             int64
+	filterByTS     map[int64]struct{}
+	filterByValue  bool
+	minValue       float64
+	maxValue       float64
+	count          int64
+	alignSpec      string
+	aggregation    *tsAggregation
+	withLabels     bool
+	selectedLabels []string
+	filters        []string
+	groupBy        string
+	reducer        string
+}
+
+var tsQueryKeywords = map[string]struct{}{
+	"LATEST": {}, "FILTER_BY_TS": {}, "FILTER_BY_VALUE": {}, "WITHLABELS": {}, "SELECTED_LABELS": {},
+	"COUNT": {}, "ALIGN": {}, "AGGREGATION": {}, "BUCKETTIMESTAMP": {}, "EMPTY": {}, "FILTER": {}, "GROUPBY": {},
+}
+
+func parseTSQuery(args []string, multi bool, ranged bool) (*tsQuery, error) {
+	q := &tsQuery{to: math.MaxInt64, count: -1}
+	var err error
+	if ranged {
+		if len(args) < 2 {
+			return nil, errWrongArgs("ts.range")
+		} else if q.from, err = parseRangeTimestamp(args[0]); err != nil {
+			return nil, err
+		} else if q.to, err = parseRangeTimestamp(args[1]); err != nil {
+			return nil, err
+		}
+		args = args[2:]
+	}
+	var agg *tsAggregation
+	for i := 0; i < len(args); i++ {
+		switch tag := strings.ToUpper(args[i]); tag {
+		case "LATEST":
+		case "FILTER_BY_TS":
+			q.filterByTS = map[int64]struct{}{}
+			for ; i+1 < len(args); i++ {
+				t, err := strconv.ParseInt(args[i+1], 10, 64)
+				if err != nil {
+					break
+				}
+				q.filterByTS[t] = struct{}{}
+			}
+		case "FILTER_BY_VALUE":
+			if i+2 >= len(args) {
+				return nil, errSyntax
+			}
+			q.filterByValue = true
+			if q.minValue, err = parseFloat(args[i+1]); err != nil {
+				return nil, err
+			} else if q.maxValue, err = parseFloat(args[i+2]); err != nil {
+				return nil, err
+			}
+			i += 2
+		case "WITHLABELS":
+			q.withLabels = true
+		case "SELECTED_LABELS":
+			q.selectedLabels = []string{}
+			for ; i+1 < len(args); i++ {
+				if _, ok := tsQueryKeywords[strings.ToUpper(args[i+1])]; ok {
+					break
+				}
+				q.selectedLabels = append(q.selectedLabels, args[i+1])
+			}
+		case "COUNT":
+			if i++; i >= len(args) {
+				return nil, errSyntax
+			} else if q.count, err = parseInt(args[i]); err != nil {
+				return nil, errors.New("ERR TSDB: Couldn't parse COUNT")
+			}
+		case "ALIGN":
+			if i++; i >= len(args) {
+				return nil, errSyntax
+			}
+			q.alignSpec = args[i]
+		case "AGGREGATION":
+			if i+2 >= len(args) {
+				return nil, errSyntax
+			}
+			agg = &tsAggregation{aggregator: strings.ToUpper(args[i+1])}
+			if _, ok := tsAggregators[agg.aggregator]; !ok {
+				return nil, errors.New("ERR TSDB: Unknown aggregation type")
+			} else if agg.bucket, err = parseInt(args[i+2]); err != nil || agg.bucket <= 0 {
+				return nil, errors.New("ERR TSDB: bucketDuration must be greater than zero")
+			}
+			i += 2
+		case "BUCKETTIMESTAMP":
+			if i++; i >= len(args) || agg == nil {
+				return nil, errSyntax
+			}
+			switch args[i] {
+			case "-", "low", "start":
+				agg.offset = 0
+			case "+", "high", "end":
+				agg.offset = agg.bucket
+			case "~", "mid":
+				agg.offset = agg.bucket / 2
+			default:
+				return nil, errors.New("ERR TSDB: unknown BUCKETTIMESTAMP parameter")
+			}
+		case "EMPTY":
+			if agg == nil {
+				return nil, errSyntax
+			}
+			agg.empty = true
+		case "FILTER":
+			if !multi {
+				return nil, errSyntax
+			}
+			for ; i+1 < len(args) && strings.ToUpper(args[i+1]) != "GROUPBY"; i++ {
+				q.filters = append(q.filters, args[i+1])
+			}
+		case "GROUPBY":
+			if !multi || i+3 >= len(args) || strings.ToUpper(args[i+2]) != "REDUCE" {
+				return nil, errSyntax
+			}
+			q.groupBy, q.reducer = args[i+1], strings.ToUpper(args[i+3])
+			if _, ok := tsReducers[q.reducer]; !ok {
+				return nil, errors.New("ERR TSDB: failed parsing reducer")
+			}
+			i += 3
+		default:
+			return nil, errSyntax
+		}
+	}
+	if multi && len(q.filters) == 0 {
+		return nil, errors.New("ERR TSDB: missing FILTER argument")
+	}
+	if agg != nil {
+		switch q.alignSpec {
+		case "", "-", "start":
+			if len(q.alignSpec) > 0 {
+				agg.align = q.from
+			}
+		case "+", "end":
+			agg.align = q.to
+		default:
+			if agg.align, err = parseInt(q.alignSpec); err != nil {
+				return nil, errors.New("ERR TSDB: unknown ALIGN parameter")
+			}
+		}
+		q.aggregation = agg
+	}
+	return q, nil
+}
+
+func tsBucketStart(t int64, bucket int64, align int64) int64 {
+	d := (t - align) % bucket
+	if d < 0 {
+		d += bucket
+	}
+	return t - d
+}
+
+func tsReduce(aggregator string, samples []tsSample) float64 {
+	if len(samples) == 0 {
+		if aggregator == "SUM" || aggregator == "COUNT" {
+			return 0
+		}
+		return math.NaN()
+	}
+	n := float64(len(samples))
+	sum, min, max := 0.0, samples[0].v, samples[0].v
+	for _, s := range samples {
+		sum += s.v
+		if s.v < min {
+			min = s.v
+		}
+		if s.v > max {
+			max = s.v
+		}
+	}
+	sq := 0.0
+	for _, s := range samples {
+		sq += (s.v - sum/n) * (s.v - sum/n)
+	}
+	varS := 0.0
+	if len(samples) > 1 {
+		varS = sq / (n - 1)
+	}
+	switch aggregator {
+	case "AVG":
+		return sum / n
+	case "SUM":
+		return sum
+	case "MIN":
+		return min
+	case "MAX":
+		return max
+	case "RANGE":
+		return max - min
+	case "COUNT":
+		return n
+	case "FIRST":
+		return samples[0].v
+	case "LAST":
+		return samples[len(samples)-1].v
+	case "STD.P":
+		return math.Sqrt(sq / n)
+	case "STD.S":
+		return math.Sqrt(varS)
+	case "VAR.P":
+		return sq / n
+	case "VAR.S":
+		return varS
+	}
+	return math.NaN()
+}
+
+func tsInterpolate(a tsSample, b tsSample, t int64) tsSample {
+	if a.t == b.t {
+		return tsSample{t, b.v}
+	}
+	return tsSample{t, a.v + (b.v-a.v)*float64(t-a.t)/float64(b.t-a.t)}
+}
+
+func tsTWA(samples []tsSample, prev *tsSample, next *tsSample, start int64, end int64) float64 {
+	points := make([]tsSample, 0, len(samples)+2)
+	if prev != nil {
+		if len(samples) > 0 && samples[0].t > start {
+			points = append(points, tsInterpolate(*prev, samples[0], start))
+		} else if len(samples) == 0 && next != nil {
+			points = append(points, tsInterpolate(*prev, *next, start))
+		}
+	}
+	points = append(points, samples...)
+	if next != nil {
+		if len(samples) > 0 && samples[len(samples)-1].t < end {
+			points = append(points, tsInterpolate(samples[len(samples)-1], *next, end))
+		} else if len(samples) == 0 && prev != nil {
+			points = append(points, tsInterpolate(*prev, *next, end))
+		}
+	}
+	if len(points) == 0 {
+		return math.NaN()
+	}
+	span := points[len(points)-1].t - points[0].t
+	if span == 0 {
+		return tsReduce("AVG", points)
+	}
+	area := 0.0
+	for i := 1; i < len(points); i++ {
+		area += (points[i-1].v + points[i].v) / 2 * float64(points[i].t-points[i-1].t)
+	}
+	return area / float64(span)
+}
+
+func (agg *tsAggregation) reduce(samples []tsSample, i int, j int, prev *tsSample, next *tsSample, start int64) float64 {
+	switch agg.aggregator {
+	case "TWA":
+		if i > 0 {
+			prev = &samples[i-1]
+		}
+		if j < len(samples) {
+			next = &samples[j]
+		}
+		return tsTWA(samples[i:j], prev, next, start, start+agg.bucket)
+	case "LAST":
+		if i == j {
+			return samples[i-1].v
+		}
+	}
+	return tsReduce(agg.aggregator, samples[i:j])
+}
+
+func (agg *tsAggregation) run(samples []tsSample, prev *tsSample, next *tsSample) []tsSample {
+	res := []tsSample{}
+	for i := 0; i < len(samples); {
+		start := tsBucketStart(samples[i].t, agg.bucket, agg.align)
+		j := i + 1
+		for j < len(samples) && samples[j].t < start+agg.bucket {
+			j++
+		}
+		res = append(res, tsSample{start + agg.offset, agg.reduce(samples, i, j, prev, next, start)})
+		if agg.empty && j < len(samples) {
+			end := tsBucketStart(samples[j].t, agg.bucket, agg.align)
+			for e := start + agg.bucket; e < end; e += agg.bucket {
+				res = append(res, tsSample{e + agg.offset, agg.reduce(samples, j, j, prev, next, e)})
+			}
+		}
+		i = j
+	}
+	return res
+}
+
+func (q *tsQuery) run(ts *timeSeries, reverse bool) []tsSample {
+	samples := make([]tsSample, 0, len(ts.samples))
+	for _, s := range ts.samples {
+		if s.t < q.from || s.t > q.to {
+			continue
+		} else if q.filterByTS != nil {
+			if _, ok := q.filterByTS[s.t]; !ok {
+				continue
+			}
+		}
+		if q.filterByValue && (s.v < q.minValue || s.v > q.maxValue) {
+			continue
+		}
+		samples = append(samples, s)
+	}
+	if q.aggregation != nil {
+		samples = q.aggregate(ts, samples)
+	}
+	if reverse {
+		for i, j := 0, len(samples)-1; i < j; i, j = i+1, j-1 {
+			samples[i], samples[j] = samples[j], samples[i]
+		}
+	}
+	if q.count >= 0 && int64(len(samples)) > q.count {
+		samples = samples[:q.count]
+	}
+	return samples
+}
+
+func (q *tsQuery) aggregate(ts *timeSeries, samples []tsSample) []tsSample {
+	var prev, next *tsSample
+	if q.aggregation.aggregator == "TWA" && q.filterByTS == nil && !q.filterByValue {
+		n := len(ts.samples)
+		lo := sort.Search(n, func(i int) bool { return ts.samples[i].t >= q.from })
+		hi := sort.Search(n, func(i int) bool { return ts.samples[i].t > q.to })
+		if lo > 0 {
+			prev = &ts.samples[lo-1]
+		}
+		if hi < n {
+			next = &ts.samples[hi]
+		}
+	}
+	return q.aggregation.run(samples, prev, next)
+}
+
+func tsRange(c *conn, args []string, reverse bool) any {
+	if len(args) < 3 {
+		return errWrongArgs("ts.range")
+	}
+	ts, err := c.timeSeries(args[0])
+	if err != nil {
+		return err
+	}
+	q, err := parseTSQuery(args[1:], false, true)
+	if err != nil {
+		return err
+	}
+	return samplesReply(q.run(ts, reverse))
+}
+
+func cmdTSRange(c *conn, args []string) any {
+	return tsRange(c, args, false)
+}
+
+func cmdTSRevRange(c *conn, args []string) any {
+	return tsRange(c, args, true)
+}
+
+type tsMatcher struct {
+	label  string
+	negate bool
+	values []string
+}
+
+func parseTSFilter(filter string) (*tsMatcher, error) {
+	i := strings.IndexByte(filter, '=')
+	if i <= 0 {
+		return nil, errTSBadFilter
+	}
+	m := &tsMatcher{label: filter[:i]}
+	if strings.HasSuffix(m.label, "!") {
+		m.label, m.negate = m.label[:len(m.label)-1], true
+	}
+	if len(m.label) == 0 {
+		return nil, errTSBadFilter
+	}
+	value := filter[i+1:]
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		m.values = strings.Split(value[1:len(value)-1], ",")
+	} else if len(value) > 0 {
+		m.values = []string{value}
+	}
+	return m, nil
+}
+
+func (m *tsMatcher) positive() bool {
+	return !m.negate && len(m.values) > 0
+}
+
+func (m *tsMatcher) match(ts *timeSeries) bool {
+	v, ok := ts.label(m.label)
+	if len(m.values) == 0 {
+		return ok == m.negate
+	}
+	found := false
+	for _, e := range m.values {
+		if ok && v == e {
+			found = true
+			break
+		}
+	}
+	return found != m.negate
+}
+
+func (c *conn) queryIndex(filters []string) ([]string, error) {
+	matchers := make([]*tsMatcher, len(filters))
+	positive := false
+	for i, filter := range filters {
+		m, err := parseTSFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		matchers[i], positive = m, positive || m.positive()
+	}
+	if !positive {
+		return nil, errTSNoMatcher
+	}
+	keys := []string{}
+	for _, key := range sortedKeys(c.s.keys) {
+		ts, ok := c.s.keys[key].(*timeSeries)
+		if !ok {
+			continue
+		}
+		matched := true
+		for _, m := range matchers {
+			if !m.match(ts) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func cmdTSQueryIndex(c *conn, args []string) any {
+	if len(args) < 1 {
+		return errWrongArgs("ts.queryindex")
+	}
+	keys, err := c.queryIndex(args)
+	if err != nil {
+		return err
+	}
+	res := make(replySet, len(keys))
+	for i, key := range keys {
+		res[i] = key
+	}
+	return res
+}
+
+func (c *conn) labelsReply(labels [][2]any) any {
+	if c.protocol == 3 {
+		return replyMap(labels)
+	}
+	res := make([]any, len(labels))
+	for i, label := range labels {
+		res[i] = []any{label[0], label[1]}
+	}
+	return res
+}
+
+func (q *tsQuery) labels(ts *timeSeries) [][2]any {
+	res := [][2]any{}
+	if q.withLabels {
+		for _, label := range ts.labels {
+			res = append(res, [2]any{label[0], label[1]})
+		}
+	} else {
+		for _, name := range q.selectedLabels {
+			var v any
+			if v1, ok := ts.label(name); ok {
+				v = v1
+			}
+			res = append(res, [2]any{name, v})
+		}
+	}
+	return res
+}
+
+func (c *conn) multiReply(keys []string, labels [][][2]any, metas []replyMap, values []any) any {
+	if c.protocol == 3 {
+		res := make(replyMap, len(keys))
+		for i, key := range keys {
+			if metas == nil {
+				res[i] = [2]any{key, []any{c.labelsReply(labels[i]), values[i]}}
+			} else {
+				res[i] = [2]any{key, []any{c.labelsReply(labels[i]), metas[i], values[i]}}
+			}
+		}
+		return res
+	}
+	res := make([]any, len(keys))
+	for i, key := range keys {
+		res[i] = []any{key, c.labelsReply(labels[i]), values[i]}
+	}
+	return res
+}
+
+func cmdTSMGet(c *conn, args []string) any {
+	q, err := parseTSQuery(args, true, false)
+	if err != nil {
+		return err
+	}
+	keys, err := c.queryIndex(q.filters)
+	if err != nil {
+		return err
+	}
+	labels, values := make([][][2]any, len(keys)), make([]any, len(keys))
+	for i, key := range keys {
+		ts := c.s.keys[key].(*timeSeries)
+		labels[i] = q.labels(ts)
+		if n := len(ts.samples); n > 0 {
+			values[i] = sampleReply(ts.samples[n-1])
+		} else {
+			values[i] = []any{}
+		}
+	}
+	return c.multiReply(keys, labels, nil, values)
+}
+
+func tsMRange(c *conn, args []string, reverse bool) any {
+	q, err := parseTSQuery(args, true, true)
+	if err != nil {
+		return err
+	}
+	keys, err := c.queryIndex(q.filters)
+	if err != nil {
+		return err
+	}
+	if len(q.groupBy) > 0 {
+		return c.groupReply(q, keys, reverse)
+	}
+	labels, metas, values := make([][][2]any, len(keys)), make([]replyMap, len(keys)), make([]any, len(keys))
+	for i, key := range keys {
+		ts := c.s.keys[key].(*timeSeries)
+		labels[i] = q.labels(ts)
+		aggregators := []any{}
+		if q.aggregation != nil {
+			aggregators = append(aggregators, strings.ToLower(q.aggregation.aggregator))
+		}
+		metas[i] = replyMap{{"aggregators", aggregators}}
+		values[i] = samplesReply(q.run(ts, reverse))
+	}
+	return c.multiReply(keys, labels, metas, values)
+}
+
+func (c *conn) groupReply(q *tsQuery, keys []string, reverse bool) any {
+	groups := map[string][]string{}
+	for _, key := range keys {
+		if v, ok := c.s.keys[key].(*timeSeries).label(q.groupBy); ok {
+			groups[v] = append(groups[v], key)
+		}
+	}
+	values := sortedKeys(groups)
+	groupKeys, labels, metas, samples := make([]string, len(values)), make([][][2]any, len(values)), make([]replyMap, len(values)), make([]any, len(values))
+	reducer := strings.ToLower(q.reducer)
+	for i, value := range values {
+		srcKeys := groups[value]
+		byTime := map[int64][]tsSample{}
+		for _, key := range srcKeys {
+			for _, s := range q.run(c.s.keys[key].(*timeSeries), false) {
+				byTime[s.t] = append(byTime[s.t], s)
+			}
+		}
+		times := make([]int64, 0, len(byTime))
+		for t := range byTime {
+			times = append(times, t)
+		}
+		sort.Slice(times, func(i, j int) bool { return (times[i] < times[j]) != reverse })
+		reduced := make([]tsSample, len(times))
+		for j, t := range times {
+			reduced[j] = tsSample{t, tsReduce(q.reducer, byTime[t])}
+		}
+		if q.count >= 0 && int64(len(reduced)) > q.count {
+			reduced = reduced[:q.count]
+		}
+
+		groupKeys[i] = fmt.Sprintf("%s=%s", q.groupBy, value)
+		sources := make([]any, len(srcKeys))
+		for j, key := range srcKeys {
+			sources[j] = key
+		}
+		if c.protocol == 3 {
+			labels[i] = [][2]any{{q.groupBy, value}}
+		} else {
+			labels[i] = [][2]any{{q.groupBy, value}, {"__reducer__", reducer}, {"__source__", strings.Join(srcKeys, ",")}}
+		}
+		metas[i] = replyMap{{"reducers", []any{reducer}}, {"sources", sources}}
+		samples[i] = samplesReply(reduced)
+	}
+	return c.multiReply(groupKeys, labels, metas, samples)
+}
+
+func cmdTSMRange(c *conn, args []string) any {
+	return tsMRange(c, args, false)
+}
+
+func cmdTSMRevRange(c *conn, args []string) any {
+	return tsMRange(c, args, true)
+}
@@ -0,0 +1,233 @@
+package redisstacktest
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/go-redis/redis/v9"
+)
+
+func tsRangeSamples(t *testing.T, red *redis.Client, args ...any) []tsSample {
+	t.Helper()
+	val, err := red.Do(context.Background(), args...).Slice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make([]tsSample, 0, len(val))
+	for _, e := range val {
+		pair := e.([]any)
+		s := tsSample{t: pair[0].(int64)}
+		switch v := pair[1].(type) {
+		case string:
+			s.v, err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+		case float64:
+			s.v = v
+		default:
+			t.Fatalf("unexpected value %#v", v)
+		}
+		samples = append(samples, s)
+	}
+	return samples
+}
+
+func TestTSAddRange(t *testing.T) {
+	ctx := context.Background()
+	ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		for i := int64(1); i <= 3; i++ {
+			if err := red.Do(ctx, "TS.ADD", "ts", i*10, i).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		exp := []tsSample{{10, 1}, {20, 2}, {30, 3}}
+		if got := tsRangeSamples(t, red, "TS.RANGE", "ts", "-", "+"); !reflect.DeepEqual(got, exp) {
+			t.Errorf("range: %v", got)
+		}
+		exp = []tsSample{{30, 3}, {20, 2}}
+		if got := tsRangeSamples(t, red, "TS.REVRANGE", "ts", 15, "+"); !reflect.DeepEqual(got, exp) {
+			t.Errorf("revrange: %v", got)
+		}
+	})
+}
+
+func TestTSRangeTWA(t *testing.T) {
+	ctx := context.Background()
+	ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		for i := int64(0); i <= 4; i++ {
+			if err := red.Do(ctx, "TS.ADD", "ts", i*10, i*10).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		exp := []tsSample{{10, 15}, {20, 25}}
+		if got := tsRangeSamples(t, red, "TS.RANGE", "ts", 10, 29, "AGGREGATION", "twa", 10); !reflect.DeepEqual(got, exp) {
+			t.Errorf("twa with neighbors: %v", got)
+		}
+		exp = []tsSample{{40, 40}}
+		if got := tsRangeSamples(t, red, "TS.RANGE", "ts", 40, "+", "AGGREGATION", "twa", 10); !reflect.DeepEqual(got, exp) {
+			t.Errorf("twa at the last sample: %v", got)
+		}
+	})
+}
+
+func TestTSRangeBucketTimestamp(t *testing.T) {
+	ctx := context.Background()
+	ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		for _, ts := range []int64{0, 5, 12} {
+			if err := red.Do(ctx, "TS.ADD", "ts", ts, 1).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		exp := []tsSample{{5, 2}, {15, 1}}
+		if got := tsRangeSamples(t, red, "TS.RANGE", "ts", "-", "+", "AGGREGATION", "count", 10, "BUCKETTIMESTAMP", "mid"); !reflect.DeepEqual(got, exp) {
+			t.Errorf("mid: %v", got)
+		}
+		if err := red.Do(ctx, "TS.RANGE", "ts", "-", "+", "AGGREGATION", "count", 10, "BUCKETTIMESTAMP", "bad").Err(); err == nil {
+			t.Error("expected error for unknown BUCKETTIMESTAMP")
+		}
+	})
+}
+
+func TestTSCompaction(t *testing.T) {
+	ctx := context.Background()
+	ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		for _, args := range [][]any{
+			{"TS.CREATE", "src"},
+			{"TS.CREATE", "dst"},
+			{"TS.ADD", "dst", 0, 100},
+			{"TS.CREATERULE", "src", "dst", "AGGREGATION", "sum", 10},
+			{"TS.ADD", "src", 20, 1},
+			{"TS.ADD", "src", 21, 2},
+		} {
+			if err := red.Do(ctx, args...).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		exp := []tsSample{{0, 100}}
+		if got := tsRangeSamples(t, red, "TS.RANGE", "dst", "-", "+"); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("open bucket was compacted: %v", got)
+		}
+
+		if err := red.Do(ctx, "TS.ADD", "src", 35, 4).Err(); err != nil {
+			t.Fatal(err)
+		}
+		exp = []tsSample{{0, 100}, {20, 3}}
+		if got := tsRangeSamples(t, red, "TS.RANGE", "dst", "-", "+"); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("after first bucket: %v", got)
+		}
+
+		if err := red.Do(ctx, "TS.ADD", "dst", 25, 7).Err(); err != nil {
+			t.Fatal(err)
+		}
+		if err := red.Do(ctx, "TS.ADD", "src", 40, 8).Err(); err != nil {
+			t.Fatal(err)
+		}
+		exp = []tsSample{{0, 100}, {20, 3}, {25, 7}, {30, 4}}
+		if got := tsRangeSamples(t, red, "TS.RANGE", "dst", "-", "+"); !reflect.DeepEqual(got, exp) {
+			t.Errorf("destination data was not kept: %v", got)
+		}
+	})
+}
+
+func TestTSCompactionChain(t *testing.T) {
+	ctx := context.Background()
+	ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		for _, args := range [][]any{
+			{"TS.CREATE", "a"},
+			{"TS.CREATE", "b"},
+			{"TS.CREATE", "c"},
+			{"TS.CREATERULE", "a", "b", "AGGREGATION", "max", 10},
+			{"TS.CREATERULE", "b", "c", "AGGREGATION", "sum", 20},
+		} {
+			if err := red.Do(ctx, args...).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := int64(0); i <= 40; i += 5 {
+			if err := red.Do(ctx, "TS.ADD", "a", i, i).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		exp := []tsSample{{0, 5}, {10, 15}, {20, 25}, {30, 35}}
+		if got := tsRangeSamples(t, red, "TS.RANGE", "b", "-", "+"); !reflect.DeepEqual(got, exp) {
+			t.Errorf("b: %v", got)
+		}
+		exp = []tsSample{{0, 20}}
+		if got := tsRangeSamples(t, red, "TS.RANGE", "c", "-", "+"); !reflect.DeepEqual(got, exp) {
+			t.Errorf("c: %v", got)
+		}
+	})
+}
+
+func TestTSRangeAggregation(t *testing.T) {
+	ctx := context.Background()
+	ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		for _, s := range []tsSample{{0, 1}, {2, 3}, {4, 8}, {25, 4}} {
+			if err := red.Do(ctx, "TS.ADD", "ts", s.t, s.v).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, c := range []struct {
+			args []any
+			exp  []tsSample
+		}{
+			{[]any{"avg", 10}, []tsSample{{0, 4}, {20, 4}}},
+			{[]any{"range", 10}, []tsSample{{0, 7}, {20, 0}}},
+			{[]any{"var.p", 10}, []tsSample{{0, 26.0 / 3}, {20, 0}}},
+			{[]any{"var.s", 10}, []tsSample{{0, 13}, {20, 0}}},
+			{[]any{"first", 10}, []tsSample{{0, 1}, {20, 4}}},
+			{[]any{"sum", 10, "EMPTY"}, []tsSample{{0, 12}, {10, 0}, {20, 4}}},
+			{[]any{"count", 10, "EMPTY"}, []tsSample{{0, 3}, {10, 0}, {20, 1}}},
+			{[]any{"last", 10, "EMPTY"}, []tsSample{{0, 8}, {10, 8}, {20, 4}}},
+		} {
+			args := append([]any{"TS.RANGE", "ts", "-", "+", "AGGREGATION"}, c.args...)
+			if got := tsRangeSamples(t, red, args...); !reflect.DeepEqual(got, c.exp) {
+				t.Errorf("%v: %v", c.args, got)
+			}
+		}
+		exp := []tsSample{{1, 11}, {21, 4}}
+		if got := tsRangeSamples(t, red, "TS.RANGE", "ts", 1, 30, "ALIGN", "start", "AGGREGATION", "sum", 10); !reflect.DeepEqual(got, exp) {
+			t.Errorf("align start: %v", got)
+		}
+		exp = []tsSample{{-4, 1}, {16, 4}}
+		if got := tsRangeSamples(t, red, "TS.RANGE", "ts", 0, 26, "ALIGN", "end", "AGGREGATION", "min", 10); !reflect.DeepEqual(got, exp) {
+			t.Errorf("align end: %v", got)
+		}
+	})
+}
+
+func TestTSMRangeGroupBy(t *testing.T) {
+	ctx := context.Background()
+	red := NewTestClient(t, 2)
+	for _, args := range [][]any{
+		{"TS.CREATE", "a", "LABELS", "g", "x"},
+		{"TS.CREATE", "b", "LABELS", "g", "x"},
+		{"TS.ADD", "a", 10, 1},
+		{"TS.ADD", "b", 10, 3},
+		{"TS.ADD", "b", 20, 5},
+	} {
+		if err := red.Do(ctx, args...).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for reducer, exp := range map[string][]any{
+		"max":   {[]any{int64(10), "3"}, []any{int64(20), "5"}},
+		"sum":   {[]any{int64(10), "4"}, []any{int64(20), "5"}},
+		"range": {[]any{int64(10), "2"}, []any{int64(20), "0"}},
+		"std.s": {[]any{int64(10), strconv.FormatFloat(math.Sqrt2, 'f', -1, 64)}, []any{int64(20), "0"}},
+	} {
+		val, err := red.Do(ctx, "TS.MRANGE", "-", "+", "FILTER", "g=x", "GROUPBY", "g", "REDUCE", reducer).Slice()
+		if err != nil {
+			t.Fatal(err)
+		} else if len(val) != 1 {
+			t.Fatalf("%s: %v", reducer, val)
+		}
+		if got := val[0].([]any)[2]; !reflect.DeepEqual(got, exp) {
+			t.Errorf("%s: %v", reducer, got)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package redisstacktest
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+type topK struct {
+	k      int64
+	width  int64
+	depth  int64
+	decay  float64
+	counts map[string]int64
+}
+
+var (
+	errTopKNotFound = errors.New("TopK: key does not exist")
+	errTopKExists   = errors.New("TopK: key already exists")
+)
+
+func init() {
+	registerCommands(map[string]commandFunc{
+		"TOPK.ADD":     cmdTopKAdd,
+		"TOPK.COUNT":   cmdTopKCount,
+		"TOPK.INCRBY":  cmdTopKIncrBy,
+		"TOPK.INFO":    cmdTopKInfo,
+		"TOPK.LIST":    cmdTopKList,
+		"TOPK.QUERY":   cmdTopKQuery,
+		"TOPK.RESERVE": cmdTopKReserve,
+	})
+}
+
+func (c *conn) topK(key string) (*topK, error) {
+	tk, ok, err := lookup[*topK](c, key)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errTopKNotFound
+	}
+	return tk, nil
+}
+
+func (tk *topK) list() []string {
+	items := make([]string, 0, len(tk.counts))
+	for item := range tk.counts {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		ci, cj := tk.counts[items[i]], tk.counts[items[j]]
+		return ci > cj || (ci == cj && items[i] < items[j])
+	})
+	if int64(len(items)) > tk.k {
+		items = items[:tk.k]
+	}
+	return items
+}
+
+func (tk *topK) incrBy(item string, incr int64) any {
+	before := tk.list()
+	tk.counts[item] += incr
+	after := map[string]struct{}{}
+	for _, e := range tk.list() {
+		after[e] = struct{}{}
+	}
+	for _, e := range before {
+		if _, ok := after[e]; !ok {
+			return e
+		}
+	}
+	return nil
+}
+
+func cmdTopKReserve(c *conn, args []string) any {
+	if len(args) != 2 && len(args) != 5 {
+		return errWrongArgs("topk.reserve")
+	}
+	k, err := parseInt(args[1])
+	if err != nil || k <= 0 {
+		return errors.New("TopK: invalid k")
+	}
+	tk := &topK{k, 8, 7, 0.9, map[string]int64{}}
+	if len(args) == 5 {
+		if tk.width, err = parseInt(args[2]); err != nil {
+			return errors.New("TopK: invalid width")
+		} else if tk.depth, err = parseInt(args[3]); err != nil {
+			return errors.New("TopK: invalid depth")
+		} else if tk.decay, err = parseFloat(args[4]); err != nil || tk.decay <= 0 || tk.decay > 1 {
+			return errors.New("TopK: invalid decay value. must be '<= 1' & '> 0'")
+		}
+	}
+	if _, ok := c.s.keys[args[0]]; ok {
+		return errTopKExists
+	}
+	c.s.keys[args[0]] = tk
+	return status("OK")
+}
+
+func cmdTopKAdd(c *conn, args []string) any {
+	if len(args) < 2 {
+		return errWrongArgs("topk.add")
+	}
+	tk, err := c.topK(args[0])
+	if err != nil {
+		return err
+	}
+	res := make([]any, len(args)-1)
+	for i, item := range args[1:] {
+		res[i] = tk.incrBy(item, 1)
+	}
+	return res
+}
+
+func cmdTopKIncrBy(c *conn, args []string) any {
+	if len(args) < 3 || len(args)%2 != 1 {
+		return errWrongArgs("topk.incrby")
+	}
+	tk, err := c.topK(args[0])
+	if err != nil {
+		return err
+	}
+	res := make([]any, 0, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		incr, err := parseInt(args[i+1])
+		if err != nil || incr < 0 {
+			return errors.New("TopK: Cannot parse number")
+		}
+		res = append(res, tk.incrBy(args[i], incr))
+	}
+	return res
+}
+
+func cmdTopKQuery(c *conn, args []string) any {
+	if len(args) < 2 {
+		return errWrongArgs("topk.query")
+	}
+	tk, err := c.topK(args[0])
+	if err != nil {
+		return err
+	}
+	top := map[string]struct{}{}
+	for _, e := range tk.list() {
+		top[e] = struct{}{}
+	}
+	res := make([]any, len(args)-1)
+	for i, item := range args[1:] {
+		_, ok := top[item]
+		res[i] = ok
+	}
+	return res
+}
+
+func cmdTopKCount(c *conn, args []string) any {
+	if len(args) < 2 {
+		return errWrongArgs("topk.count")
+	}
+	tk, err := c.topK(args[0])
+	if err != nil {
+		return err
+	}
+	res := make([]any, len(args)-1)
+	for i, item := range args[1:] {
+		res[i] = tk.counts[item]
+	}
+	return res
+}
+
+func cmdTopKList(c *conn, args []string) any {
+	if len(args) != 1 && len(args) != 2 {
+		return errWrongArgs("topk.list")
+	}
+	tk, err := c.topK(args[0])
+	if err != nil {
+		return err
+	}
+	withCount := false
+	if len(args) == 2 {
+		if strings.ToUpper(args[1]) != "WITHCOUNT" {
+			return errSyntax
+		}
+		withCount = true
+	}
+	res := []any{}
+	for _, item := range tk.list() {
+		res = append(res, item)
+		if withCount {
+			res = append(res, tk.counts[item])
+		}
+	}
+	return res
+}
+
+func cmdTopKInfo(c *conn, args []string) any {
+	if len(args) != 1 {
+		return errWrongArgs("topk.info")
+	}
+	tk, err := c.topK(args[0])
+	if err != nil {
+		return err
+	}
+	return replyMap{
+		{"k", tk.k},
+		{"width", tk.width},
+		{"depth", tk.depth},
+		{"decay", tk.decay},
+	}
+}
@@ -0,0 +1,12 @@
+package redisstacktest
+
+import "testing"
+
+func TestTopK(t *testing.T) {
+	runCases(t, []testCase{
+		{[]any{"TOPK.RESERVE", "tk", 2}, "OK", "OK"},
+		{[]any{"TOPK.INCRBY", "tk", "a", 3, "b", 2}, []any{nil, nil}, []any{nil, nil}},
+		{[]any{"TOPK.LIST", "tk", "WITHCOUNT"}, []any{"a", int64(3), "b", int64(2)}, []any{"a", int64(3), "b", int64(2)}},
+		{[]any{"TOPK.QUERY", "tk", "a", "z"}, []any{int64(1), int64(0)}, []any{true, false}},
+	})
+}
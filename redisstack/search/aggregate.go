@@ -0,0 +1,355 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+)
+
+type ReducerFunc byte
+
+const (
+	ReducerFuncCount = ReducerFunc(iota)
+	ReducerFuncCountDistinct
+	ReducerFuncCountDistinctish
+	ReducerFuncSum
+	ReducerFuncMin
+	ReducerFuncMax
+	ReducerFuncAvg
+	ReducerFuncStdDev
+	ReducerFuncQuantile
+	ReducerFuncToList
+	ReducerFuncFirstValue
+	ReducerFuncRandomSample
+)
+
+var reducerFuncNames = map[ReducerFunc]string{
+	ReducerFuncCount:            "COUNT",
+	ReducerFuncCountDistinct:    "COUNT_DISTINCT",
+	ReducerFuncCountDistinctish: "COUNT_DISTINCTISH",
+	ReducerFuncSum:              "SUM",
+	ReducerFuncMin:              "MIN",
+	ReducerFuncMax:              "MAX",
+	ReducerFuncAvg:              "AVG",
+	ReducerFuncStdDev:           "STDDEV",
+	ReducerFuncQuantile:         "QUANTILE",
+	ReducerFuncToList:           "TOLIST",
+	ReducerFuncFirstValue:       "FIRST_VALUE",
+	ReducerFuncRandomSample:     "RANDOM_SAMPLE",
+}
+
+type Reducer struct {
+	Func ReducerFunc
+	Args []any
+	As   string
+}
+
+func CountReducer(as string) *Reducer {
+	return &Reducer{ReducerFuncCount, nil, as}
+}
+
+func SumReducer(property string, as string) *Reducer {
+	return &Reducer{ReducerFuncSum, []any{property}, as}
+}
+
+func AvgReducer(property string, as string) *Reducer {
+	return &Reducer{ReducerFuncAvg, []any{property}, as}
+}
+
+func ToListReducer(property string, as string) *Reducer {
+	return &Reducer{ReducerFuncToList, []any{property}, as}
+}
+
+func QuantileReducer(property string, quantile float64, as string) *Reducer {
+	return &Reducer{ReducerFuncQuantile, []any{property, quantile}, as}
+}
+
+func FirstValueReducer(property string, sortBy *SortBy, as string) *Reducer {
+	args := []any{property}
+	if sortBy != nil {
+		args = append(args, "BY", sortBy.Field)
+		if sortBy.Desc {
+			args = append(args, "DESC")
+		} else {
+			args = append(args, "ASC")
+		}
+	}
+	return &Reducer{ReducerFuncFirstValue, args, as}
+}
+
+func (r *Reducer) appendArgs(args []any) []any {
+	args = append(args, "REDUCE", reducerFuncNames[r.Func], len(r.Args))
+	args = append(args, r.Args...)
+	if len(r.As) > 0 {
+		args = append(args, "AS", r.As)
+	}
+	return args
+}
+
+type Aggregation struct {
+	query    string
+	verbatim bool
+	timeout  *time.Duration
+	steps    []any
+	cursor   bool
+	count    int64
+	maxIdle  time.Duration
+	params   []redisstack.StringAnyPair
+	dialect  int
+}
+
+func NewAggregation(query string) *Aggregation {
+	return &Aggregation{query: query}
+}
+
+func (a *Aggregation) Verbatim() *Aggregation {
+	a.verbatim = true
+	return a
+}
+
+func (a *Aggregation) Timeout(timeout time.Duration) *Aggregation {
+	a.timeout = &timeout
+	return a
+}
+
+func (a *Aggregation) Load(fields ...string) *Aggregation {
+	a.steps = append(a.steps, "LOAD", len(fields))
+	for _, field := range fields {
+		a.steps = append(a.steps, field)
+	}
+	return a
+}
+
+func (a *Aggregation) LoadAll() *Aggregation {
+	a.steps = append(a.steps, "LOAD", "*")
+	return a
+}
+
+func (a *Aggregation) GroupBy(properties []string, reducers ...*Reducer) *Aggregation {
+	a.steps = append(a.steps, "GROUPBY", len(properties))
+	for _, property := range properties {
+		a.steps = append(a.steps, property)
+	}
+	for _, reducer := range reducers {
+		a.steps = reducer.appendArgs(a.steps)
+	}
+	return a
+}
+
+func (a *Aggregation) Apply(expr string, as string) *Aggregation {
+	a.steps = append(a.steps, "APPLY", expr, "AS", as)
+	return a
+}
+
+func (a *Aggregation) SortBy(max int64, keys ...SortBy) *Aggregation {
+	a.steps = append(a.steps, "SORTBY", len(keys)*2)
+	for _, key := range keys {
+		if key.Desc {
+			a.steps = append(a.steps, key.Field, "DESC")
+		} else {
+			a.steps = append(a.steps, key.Field, "ASC")
+		}
+	}
+	if max > 0 {
+		a.steps = append(a.steps, "MAX", max)
+	}
+	return a
+}
+
+func (a *Aggregation) Filter(expr string) *Aggregation {
+	a.steps = append(a.steps, "FILTER", expr)
+	return a
+}
+
+func (a *Aggregation) Limit(offset int64, num int64) *Aggregation {
+	a.steps = append(a.steps, "LIMIT", offset, num)
+	return a
+}
+
+func (a *Aggregation) WithCursor(count int64, maxIdle time.Duration) *Aggregation {
+	a.cursor, a.count, a.maxIdle = true, count, maxIdle
+	return a
+}
+
+func (a *Aggregation) Params(params ...redisstack.StringAnyPair) *Aggregation {
+	a.params = append(a.params, params...)
+	return a
+}
+
+func (a *Aggregation) Dialect(dialect int) *Aggregation {
+	a.dialect = dialect
+	return a
+}
+
+func AggregateArgs(index string, agg *Aggregation) []any {
+	args := make([]any, 0, 16+len(agg.steps)+len(agg.params)*2)
+	args = append(args, "FT.AGGREGATE", index, agg.query)
+	if agg.verbatim {
+		args = append(args, "VERBATIM")
+	}
+	if agg.timeout != nil {
+		args = append(args, "TIMEOUT", agg.timeout.Milliseconds())
+	}
+	args = append(args, agg.steps...)
+	if agg.cursor {
+		args = append(args, "WITHCURSOR")
+		if agg.count > 0 {
+			args = append(args, "COUNT", agg.count)
+		}
+		if agg.maxIdle > 0 {
+			args = append(args, "MAXIDLE", agg.maxIdle.Milliseconds())
+		}
+	}
+	if len(agg.params) > 0 {
+		args = append(args, "PARAMS", len(agg.params)*2)
+		for _, param := range agg.params {
+			args = append(args, param.Key, param.Value)
+		}
+	}
+	if agg.dialect > 0 {
+		args = append(args, "DIALECT", agg.dialect)
+	}
+	return args
+}
+
+type AggregateResultSet struct {
+	Total int64
+	Rows  [][]redisstack.StringAnyPair
+}
+
+func AggregateResult(val any) (*AggregateResultSet, error) {
+	if m, ok := val.(map[any]any); ok {
+		m1, err := redisstack.ParseMap(m)
+		if err != nil {
+			return nil, err
+		}
+		res := &AggregateResultSet{}
+		if res.Total, err = parseResp3Total(m1); err != nil {
+			return nil, err
+		}
+		res.Rows, err = redisstack.ParseToMappedArray(m1["results"], 0, func(e any) ([]redisstack.StringAnyPair, error) {
+			m2, err := redisstack.ParseMap(e)
+			if err != nil {
+				return nil, err
+			}
+			row, err := parseFields(m2["extra_attributes"])
+			return row, redisstack.WithPathKey(err, "extra_attributes")
+		})
+		if err != nil {
+			return nil, redisstack.WithPathKey(err, "results")
+		}
+		return res, nil
+	}
+	arr, err := redisstack.ParseArray(val, 1)
+	if err != nil {
+		return nil, err
+	}
+	res := &AggregateResultSet{}
+	if res.Total, err = redisstack.ParseScalar[int64](arr[0]); err != nil {
+		return nil, redisstack.WithPathIndex(err, 0)
+	}
+	res.Rows = make([][]redisstack.StringAnyPair, len(arr)-1)
+	for i := 1; i < len(arr); i++ {
+		if res.Rows[i-1], err = parseFields(arr[i]); err != nil {
+			return nil, redisstack.WithPathIndex(err, i)
+		}
+	}
+	return res, nil
+}
+
+type AggregateCursorResultSet struct {
+	AggregateResultSet
+	Cursor int64
+}
+
+func AggregateCursorResult(val any) (*AggregateCursorResultSet, error) {
+	arr, err := redisstack.ParseArray(val, 2)
+	if err != nil {
+		return nil, err
+	}
+	res := &AggregateCursorResultSet{}
+	res1, err := AggregateResult(arr[0])
+	if err != nil {
+		return nil, redisstack.WithPathIndex(err, 0)
+	}
+	res.AggregateResultSet = *res1
+	if res.Cursor, err = redisstack.ParseScalar[int64](arr[1]); err != nil {
+		return nil, redisstack.WithPathIndex(err, 1)
+	}
+	return res, nil
+}
+
+func CursorReadArgs(index string, cursor int64, count int64) []any {
+	if count > 0 {
+		return []any{"FT.CURSOR", "READ", index, cursor, "COUNT", count}
+	}
+	return []any{"FT.CURSOR", "READ", index, cursor}
+}
+
+func CursorDelArgs(index string, cursor int64) []any {
+	return []any{"FT.CURSOR", "DEL", index, cursor}
+}
+
+type AggregateCursor struct {
+	red     redis.UniversalClient
+	index   string
+	agg     *Aggregation
+	started bool
+	cursor  int64
+	rows    [][]redisstack.StringAnyPair
+	row     []redisstack.StringAnyPair
+	err     error
+}
+
+func NewAggregateCursor(red redis.UniversalClient, index string, agg *Aggregation) *AggregateCursor {
+	agg1 := *agg
+	agg1.cursor = true
+	return &AggregateCursor{red: red, index: index, agg: &agg1}
+}
+
+func (c *AggregateCursor) Next(ctx context.Context) bool {
+	for len(c.rows) == 0 {
+		if c.err != nil || (c.started && c.cursor == 0) {
+			return false
+		}
+		var args []any
+		if !c.started {
+			args = AggregateArgs(c.index, c.agg)
+		} else {
+			args = CursorReadArgs(c.index, c.cursor, c.agg.count)
+		}
+		cmd := c.red.Do(ctx, args...)
+		if c.err = cmd.Err(); c.err != nil {
+			return false
+		}
+		res, err := AggregateCursorResult(cmd.Val())
+		if err != nil {
+			c.err = err
+			return false
+		}
+		c.started, c.cursor, c.rows = true, res.Cursor, res.Rows
+	}
+	c.row, c.rows = c.rows[0], c.rows[1:]
+	return true
+}
+
+func (c *AggregateCursor) Row() []redisstack.StringAnyPair {
+	return c.row
+}
+
+func (c *AggregateCursor) Err() error {
+	return c.err
+}
+
+// Close deletes the server-side cursor. Call it even after Next has failed,
+// since the cursor stays open until it is deleted or idles out.
+func (c *AggregateCursor) Close(ctx context.Context) error {
+	if !c.started || c.cursor == 0 {
+		return nil
+	}
+	err := c.red.Do(ctx, CursorDelArgs(c.index, c.cursor)...).Err()
+	c.cursor, c.rows = 0, nil
+	return err
+}
@@ -0,0 +1,304 @@
+package search
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+type QueryWritable interface {
+	WriteToQuery(sb *strings.Builder) error
+}
+
+func QueryWritableToString[T QueryWritable](qw T) (string, error) {
+	sb := &strings.Builder{}
+	if err := qw.WriteToQuery(sb); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+const specialChars = ",.<>{}[]\"':;!@#$%^&*()-+=~|/\\ \t\n"
+
+func Escape(s string) string {
+	sb := &strings.Builder{}
+	writeEscaped(s, sb)
+	return sb.String()
+}
+
+func writeEscaped(s string, sb *strings.Builder) {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(specialChars, s[i]) >= 0 {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+}
+
+func writeNumber(f float64, sb *strings.Builder) {
+	switch {
+	case math.IsInf(f, 1):
+		sb.WriteString("+inf")
+	case math.IsInf(f, -1):
+		sb.WriteString("-inf")
+	default:
+		sb.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+	}
+}
+
+func writeFieldPrefix(fields []string, sb *strings.Builder) error {
+	if len(fields) == 0 {
+		return ErrInvalidQuery
+	}
+	sb.WriteByte('@')
+	for i, field := range fields {
+		if len(field) == 0 {
+			return ErrInvalidQuery
+		}
+		if i > 0 {
+			sb.WriteByte('|')
+		}
+		writeEscaped(field, sb)
+	}
+	sb.WriteByte(':')
+	return nil
+}
+
+type AllQuery struct{}
+
+func (q AllQuery) WriteToQuery(sb *strings.Builder) error {
+	sb.WriteByte('*')
+	return nil
+}
+
+type RawQuery string
+
+func (q RawQuery) WriteToQuery(sb *strings.Builder) error {
+	sb.WriteString(string(q))
+	return nil
+}
+
+type TermQuery string
+
+func (q TermQuery) WriteToQuery(sb *strings.Builder) error {
+	if len(q) == 0 {
+		return ErrInvalidQuery
+	}
+	writeEscaped(string(q), sb)
+	return nil
+}
+
+type PrefixQuery string
+
+func (q PrefixQuery) WriteToQuery(sb *strings.Builder) error {
+	if len(q) == 0 {
+		return ErrInvalidQuery
+	}
+	writeEscaped(string(q), sb)
+	sb.WriteByte('*')
+	return nil
+}
+
+type PhraseQuery []string
+
+func (q PhraseQuery) WriteToQuery(sb *strings.Builder) error {
+	if len(q) == 0 {
+		return ErrInvalidQuery
+	}
+	sb.WriteByte('"')
+	for i, word := range q {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		writeEscaped(word, sb)
+	}
+	sb.WriteByte('"')
+	return nil
+}
+
+type FieldQuery struct {
+	Fields []string
+	Query  QueryWritable
+}
+
+func (q *FieldQuery) WriteToQuery(sb *strings.Builder) error {
+	if q.Query == nil {
+		return ErrInvalidQuery
+	}
+	if err := writeFieldPrefix(q.Fields, sb); err != nil {
+		return err
+	}
+	sb.WriteByte('(')
+	if err := q.Query.WriteToQuery(sb); err != nil {
+		return err
+	}
+	sb.WriteByte(')')
+	return nil
+}
+
+type TagQuery struct {
+	Field  string
+	Values []string
+}
+
+func (q *TagQuery) WriteToQuery(sb *strings.Builder) error {
+	if len(q.Values) == 0 {
+		return ErrInvalidQuery
+	}
+	if err := writeFieldPrefix([]string{q.Field}, sb); err != nil {
+		return err
+	}
+	sb.WriteByte('{')
+	for i, value := range q.Values {
+		if i > 0 {
+			sb.WriteString(" | ")
+		}
+		writeEscaped(value, sb)
+	}
+	sb.WriteByte('}')
+	return nil
+}
+
+type NumericRangeQuery struct {
+	Field        string
+	Min          float64
+	Max          float64
+	ExclusiveMin bool
+	ExclusiveMax bool
+}
+
+func (q *NumericRangeQuery) WriteToQuery(sb *strings.Builder) error {
+	if err := writeFieldPrefix([]string{q.Field}, sb); err != nil {
+		return err
+	}
+	sb.WriteByte('[')
+	if q.ExclusiveMin {
+		sb.WriteByte('(')
+	}
+	writeNumber(q.Min, sb)
+	sb.WriteByte(' ')
+	if q.ExclusiveMax {
+		sb.WriteByte('(')
+	}
+	writeNumber(q.Max, sb)
+	sb.WriteByte(']')
+	return nil
+}
+
+type GeoUnit byte
+
+const (
+	GeoUnitM = GeoUnit(iota)
+	GeoUnitKM
+	GeoUnitMI
+	GeoUnitFT
+)
+
+var geoUnitNames = map[GeoUnit]string{
+	GeoUnitM:  "m",
+	GeoUnitKM: "km",
+	GeoUnitMI: "mi",
+	GeoUnitFT: "ft",
+}
+
+type GeoQuery struct {
+	Field     string
+	Longitude float64
+	Latitude  float64
+	Radius    float64
+	Unit      GeoUnit
+}
+
+func (q *GeoQuery) WriteToQuery(sb *strings.Builder) error {
+	if err := writeFieldPrefix([]string{q.Field}, sb); err != nil {
+		return err
+	}
+	sb.WriteByte('[')
+	writeNumber(q.Longitude, sb)
+	sb.WriteByte(' ')
+	writeNumber(q.Latitude, sb)
+	sb.WriteByte(' ')
+	writeNumber(q.Radius, sb)
+	sb.WriteByte(' ')
+	sb.WriteString(geoUnitNames[q.Unit])
+	sb.WriteByte(']')
+	return nil
+}
+
+type NotQuery struct {
+	Query QueryWritable
+}
+
+func (q *NotQuery) WriteToQuery(sb *strings.Builder) error {
+	return writeGroup("-(", q.Query, sb)
+}
+
+type OptionalQuery struct {
+	Query QueryWritable
+}
+
+func (q *OptionalQuery) WriteToQuery(sb *strings.Builder) error {
+	return writeGroup("~(", q.Query, sb)
+}
+
+func writeGroup(open string, qw QueryWritable, sb *strings.Builder) error {
+	if qw == nil {
+		return ErrInvalidQuery
+	}
+	sb.WriteString(open)
+	if err := qw.WriteToQuery(sb); err != nil {
+		return err
+	}
+	sb.WriteByte(')')
+	return nil
+}
+
+type BoostQuery struct {
+	Query  QueryWritable
+	Weight float64
+}
+
+func (q *BoostQuery) WriteToQuery(sb *strings.Builder) error {
+	if err := writeGroup("(", q.Query, sb); err != nil {
+		return err
+	}
+	sb.WriteString(" => { $weight: ")
+	writeNumber(q.Weight, sb)
+	sb.WriteString("; }")
+	return nil
+}
+
+func writeCombined(qws []QueryWritable, sep string, sb *strings.Builder) error {
+	if len(qws) == 0 {
+		return ErrInvalidQuery
+	}
+	sb.WriteByte('(')
+	for i, qw := range qws {
+		if qw == nil {
+			return ErrInvalidQuery
+		}
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		if err := qw.WriteToQuery(sb); err != nil {
+			return err
+		}
+	}
+	sb.WriteByte(')')
+	return nil
+}
+
+type IntersectQuery []QueryWritable
+
+func (q IntersectQuery) WriteToQuery(sb *strings.Builder) error {
+	return writeCombined(q, " ", sb)
+}
+
+type UnionQuery []QueryWritable
+
+func (q UnionQuery) WriteToQuery(sb *strings.Builder) error {
+	return writeCombined(q, " | ", sb)
+}
@@ -0,0 +1,509 @@
+package search
+
+import (
+	"time"
+
+	"github.com/ldeng7/go-redis-stack/redisstack"
+)
+
+type IndexOn byte
+
+const (
+	IndexOnNone = IndexOn(iota)
+	IndexOnHash
+	IndexOnJSON
+)
+
+var indexOnNames = map[IndexOn]string{
+	IndexOnHash: "HASH",
+	IndexOnJSON: "JSON",
+}
+
+type IndexOption struct {
+	On              IndexOn
+	Prefixes        []string
+	Filter          string
+	Language        string
+	LanguageField   string
+	Score           *float64
+	ScoreField      string
+	PayloadField    string
+	MaxTextFields   bool
+	Temporary       *time.Duration
+	NoOffsets       bool
+	NoHL            bool
+	NoFields        bool
+	NoFreqs         bool
+	StopWords       []string
+	SkipInitialScan bool
+}
+
+func (opt *IndexOption) appendArgs(args []any) []any {
+	if opt == nil {
+		return args
+	}
+	if opt.On != IndexOnNone {
+		args = append(args, "ON", indexOnNames[opt.On])
+	}
+	if len(opt.Prefixes) > 0 {
+		args = append(args, "PREFIX", len(opt.Prefixes))
+		for _, prefix := range opt.Prefixes {
+			args = append(args, prefix)
+		}
+	}
+	if len(opt.Filter) > 0 {
+		args = append(args, "FILTER", opt.Filter)
+	}
+	if len(opt.Language) > 0 {
+		args = append(args, "LANGUAGE", opt.Language)
+	}
+	if len(opt.LanguageField) > 0 {
+		args = append(args, "LANGUAGE_FIELD", opt.LanguageField)
+	}
+	if opt.Score != nil {
+		args = append(args, "SCORE", *opt.Score)
+	}
+	if len(opt.ScoreField) > 0 {
+		args = append(args, "SCORE_FIELD", opt.ScoreField)
+	}
+	if len(opt.PayloadField) > 0 {
+		args = append(args, "PAYLOAD_FIELD", opt.PayloadField)
+	}
+	if opt.MaxTextFields {
+		args = append(args, "MAXTEXTFIELDS")
+	}
+	if opt.Temporary != nil {
+		args = append(args, "TEMPORARY", int64(opt.Temporary.Seconds()))
+	}
+	if opt.NoOffsets {
+		args = append(args, "NOOFFSETS")
+	}
+	if opt.NoHL {
+		args = append(args, "NOHL")
+	}
+	if opt.NoFields {
+		args = append(args, "NOFIELDS")
+	}
+	if opt.NoFreqs {
+		args = append(args, "NOFREQS")
+	}
+	if opt.StopWords != nil {
+		args = append(args, "STOPWORDS", len(opt.StopWords))
+		for _, word := range opt.StopWords {
+			args = append(args, word)
+		}
+	}
+	if opt.SkipInitialScan {
+		args = append(args, "SKIPINITIALSCAN")
+	}
+	return args
+}
+
+type FieldType byte
+
+const (
+	FieldTypeText = FieldType(iota)
+	FieldTypeTag
+	FieldTypeNumeric
+	FieldTypeGeo
+	FieldTypeVector
+)
+
+var fieldTypeNames = map[FieldType]string{
+	FieldTypeText:    "TEXT",
+	FieldTypeTag:     "TAG",
+	FieldTypeNumeric: "NUMERIC",
+	FieldTypeGeo:     "GEO",
+	FieldTypeVector:  "VECTOR",
+}
+
+type Field struct {
+	Name           string
+	As             string
+	Type           FieldType
+	NoStem         bool
+	Weight         *float64
+	Phonetic       string
+	Separator      string
+	CaseSensitive  bool
+	WithSuffixTrie bool
+	Vector         *VectorOption
+	Sortable       bool
+	UNF            bool
+	NoIndex        bool
+}
+
+func (f *Field) appendArgs(args []any) []any {
+	args = append(args, f.Name)
+	if len(f.As) > 0 {
+		args = append(args, "AS", f.As)
+	}
+	args = append(args, fieldTypeNames[f.Type])
+	switch f.Type {
+	case FieldTypeText:
+		if f.NoStem {
+			args = append(args, "NOSTEM")
+		}
+		if f.Weight != nil {
+			args = append(args, "WEIGHT", *f.Weight)
+		}
+		if len(f.Phonetic) > 0 {
+			args = append(args, "PHONETIC", f.Phonetic)
+		}
+		if f.WithSuffixTrie {
+			args = append(args, "WITHSUFFIXTRIE")
+		}
+	case FieldTypeTag:
+		if len(f.Separator) > 0 {
+			args = append(args, "SEPARATOR", f.Separator)
+		}
+		if f.CaseSensitive {
+			args = append(args, "CASESENSITIVE")
+		}
+		if f.WithSuffixTrie {
+			args = append(args, "WITHSUFFIXTRIE")
+		}
+	case FieldTypeVector:
+		if f.Vector != nil {
+			args = f.Vector.appendArgs(args)
+		}
+	}
+	if f.Sortable {
+		args = append(args, "SORTABLE")
+		if f.UNF {
+			args = append(args, "UNF")
+		}
+	}
+	if f.NoIndex {
+		args = append(args, "NOINDEX")
+	}
+	return args
+}
+
+func CreateArgs(index string, option *IndexOption, schema []*Field) []any {
+	args := make([]any, 0, 32+len(schema)*8)
+	args = append(args, "FT.CREATE", index)
+	args = option.appendArgs(args)
+	args = append(args, "SCHEMA")
+	for _, field := range schema {
+		args = field.appendArgs(args)
+	}
+	return args
+}
+
+func DropIndexArgs(index string, deleteDocs bool) []any {
+	if deleteDocs {
+		return []any{"FT.DROPINDEX", index, "DD"}
+	}
+	return []any{"FT.DROPINDEX", index}
+}
+
+func InfoArgs(index string) []any {
+	return []any{"FT.INFO", index}
+}
+
+func InfoResult(val any) (map[string]any, error) {
+	return redisstack.ParseMap(val)
+}
+
+func ListArgs() []any {
+	return []any{"FT._LIST"}
+}
+
+func ListResult(val any) ([]string, error) {
+	return redisstack.ParseScalarArray[string](val, 0)
+}
+
+type ReturnField struct {
+	Identifier string
+	As         string
+}
+
+type SortBy struct {
+	Field string
+	Desc  bool
+}
+
+type Limit struct {
+	Offset int64
+	Num    int64
+}
+
+type SearchOption struct {
+	NoContent    bool
+	Verbatim     bool
+	NoStopWords  bool
+	WithScores   bool
+	WithPayloads bool
+	WithSortKeys bool
+	InKeys       []string
+	InFields     []string
+	Return       []ReturnField
+	Slop         *int64
+	Timeout      *time.Duration
+	InOrder      bool
+	Language     string
+	Expander     string
+	Scorer       string
+	ExplainScore bool
+	Payload      string
+	SortBy       *SortBy
+	Limit        *Limit
+	Params       []redisstack.StringAnyPair
+	Dialect      int
+}
+
+func (opt *SearchOption) appendArgs(args []any) []any {
+	if opt == nil {
+		return args
+	}
+	if opt.NoContent {
+		args = append(args, "NOCONTENT")
+	}
+	if opt.Verbatim {
+		args = append(args, "VERBATIM")
+	}
+	if opt.NoStopWords {
+		args = append(args, "NOSTOPWORDS")
+	}
+	if opt.WithScores {
+		args = append(args, "WITHSCORES")
+	}
+	if opt.WithPayloads {
+		args = append(args, "WITHPAYLOADS")
+	}
+	if opt.WithSortKeys {
+		args = append(args, "WITHSORTKEYS")
+	}
+	if len(opt.InKeys) > 0 {
+		args = append(args, "INKEYS", len(opt.InKeys))
+		for _, key := range opt.InKeys {
+			args = append(args, key)
+		}
+	}
+	if len(opt.InFields) > 0 {
+		args = append(args, "INFIELDS", len(opt.InFields))
+		for _, field := range opt.InFields {
+			args = append(args, field)
+		}
+	}
+	if len(opt.Return) > 0 {
+		n := 0
+		for _, field := range opt.Return {
+			if n++; len(field.As) > 0 {
+				n += 2
+			}
+		}
+		args = append(args, "RETURN", n)
+		for _, field := range opt.Return {
+			args = append(args, field.Identifier)
+			if len(field.As) > 0 {
+				args = append(args, "AS", field.As)
+			}
+		}
+	}
+	if opt.Slop != nil {
+		args = append(args, "SLOP", *opt.Slop)
+	}
+	if opt.Timeout != nil {
+		args = append(args, "TIMEOUT", opt.Timeout.Milliseconds())
+	}
+	if opt.InOrder {
+		args = append(args, "INORDER")
+	}
+	if len(opt.Language) > 0 {
+		args = append(args, "LANGUAGE", opt.Language)
+	}
+	if len(opt.Expander) > 0 {
+		args = append(args, "EXPANDER", opt.Expander)
+	}
+	if len(opt.Scorer) > 0 {
+		args = append(args, "SCORER", opt.Scorer)
+	}
+	if opt.ExplainScore {
+		args = append(args, "EXPLAINSCORE")
+	}
+	if len(opt.Payload) > 0 {
+		args = append(args, "PAYLOAD", opt.Payload)
+	}
+	if opt.SortBy != nil {
+		args = append(args, "SORTBY", opt.SortBy.Field)
+		if opt.SortBy.Desc {
+			args = append(args, "DESC")
+		} else {
+			args = append(args, "ASC")
+		}
+	}
+	if opt.Limit != nil {
+		args = append(args, "LIMIT", opt.Limit.Offset, opt.Limit.Num)
+	}
+	if len(opt.Params) > 0 {
+		args = append(args, "PARAMS", len(opt.Params)*2)
+		for _, param := range opt.Params {
+			args = append(args, param.Key, param.Value)
+		}
+	}
+	if opt.Dialect > 0 {
+		args = append(args, "DIALECT", opt.Dialect)
+	}
+	return args
+}
+
+func SearchArgs(index string, query string, option *SearchOption) []any {
+	args := make([]any, 0, 48)
+	args = append(args, "FT.SEARCH", index, query)
+	return option.appendArgs(args)
+}
+
+type Document struct {
+	ID      string
+	Score   *float64
+	Payload *string
+	SortKey *string
+	Fields  []redisstack.StringAnyPair
+}
+
+type ResultSet struct {
+	Total     int64
+	Documents []*Document
+}
+
+func parseScore(val any) (*float64, error) {
+	if arr, ok := val.([]any); ok && len(arr) > 0 {
+		val = arr[0]
+	}
+	f, err := redisstack.ParseFloat(val)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func parseNullableString(val any) *string {
+	if s, ok := val.(string); ok {
+		return &s
+	}
+	return nil
+}
+
+func parseFields(val any) ([]redisstack.StringAnyPair, error) {
+	if val == nil {
+		return nil, nil
+	}
+	if _, ok := val.(map[any]any); ok {
+		return redisstack.ParseToInterlacedMappedArray(val, 0, func(e1, e2 any) (redisstack.StringAnyPair, error) {
+			k, err := redisstack.ParseScalar[string](e1)
+			return redisstack.StringAnyPair{Key: k, Value: e2}, err
+		})
+	}
+	arr, err := redisstack.ParseArray(val, 0)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]redisstack.StringAnyPair, len(arr)/2)
+	for i := range res {
+		k, err := redisstack.ParseScalar[string](arr[i*2])
+		if err != nil {
+			return nil, redisstack.WithPathIndex(err, i*2)
+		}
+		res[i] = redisstack.StringAnyPair{Key: k, Value: arr[i*2+1]}
+	}
+	return res, nil
+}
+
+func parseResp3Total(m map[string]any) (int64, error) {
+	total, err := redisstack.ParseScalar[int64](m["total_results"])
+	return total, redisstack.WithPathKey(err, "total_results")
+}
+
+func parseResp3SearchResult(m map[string]any) (*ResultSet, error) {
+	res := &ResultSet{}
+	var err error
+	if res.Total, err = parseResp3Total(m); err != nil {
+		return nil, err
+	}
+	res.Documents, err = redisstack.ParseToMappedArray(m["results"], 0, func(e any) (*Document, error) {
+		m1, err := redisstack.ParseMap(e)
+		if err != nil {
+			return nil, err
+		}
+		doc := &Document{}
+		if doc.ID, err = redisstack.ParseScalar[string](m1["id"]); err != nil {
+			return nil, redisstack.WithPathKey(err, "id")
+		}
+		if v, ok := m1["score"]; ok {
+			if doc.Score, err = parseScore(v); err != nil {
+				return nil, redisstack.WithPathKey(err, "score")
+			}
+		}
+		doc.Payload = parseNullableString(m1["payload"])
+		doc.SortKey = parseNullableString(m1["sortkey"])
+		if doc.Fields, err = parseFields(m1["extra_attributes"]); err != nil {
+			return nil, redisstack.WithPathKey(err, "extra_attributes")
+		}
+		return doc, nil
+	})
+	if err != nil {
+		return nil, redisstack.WithPathKey(err, "results")
+	}
+	return res, nil
+}
+
+func SearchResult(val any, option *SearchOption) (*ResultSet, error) {
+	if m, ok := val.(map[any]any); ok {
+		m1, err := redisstack.ParseMap(m)
+		if err != nil {
+			return nil, err
+		}
+		return parseResp3SearchResult(m1)
+	}
+	arr, err := redisstack.ParseArray(val, 1)
+	if err != nil {
+		return nil, err
+	}
+	res := &ResultSet{}
+	if res.Total, err = redisstack.ParseScalar[int64](arr[0]); err != nil {
+		return nil, redisstack.WithPathIndex(err, 0)
+	}
+	withScores, withPayloads, withSortKeys, withContent := false, false, false, true
+	if option != nil {
+		withScores, withPayloads, withSortKeys = option.WithScores, option.WithPayloads, option.WithSortKeys
+		withContent = !option.NoContent
+	}
+	stride := 1
+	for _, with := range []bool{withScores, withPayloads, withSortKeys, withContent} {
+		if with {
+			stride++
+		}
+	}
+	res.Documents = make([]*Document, 0, (len(arr)-1+stride-1)/stride)
+	for i := 1; i < len(arr); {
+		doc := &Document{}
+		if doc.ID, err = redisstack.ParseScalar[string](arr[i]); err != nil {
+			return nil, redisstack.WithPathIndex(err, i)
+		}
+		i++
+		if withScores && i < len(arr) {
+			if doc.Score, err = parseScore(arr[i]); err != nil {
+				return nil, redisstack.WithPathIndex(err, i)
+			}
+			i++
+		}
+		if withPayloads && i < len(arr) {
+			doc.Payload = parseNullableString(arr[i])
+			i++
+		}
+		if withSortKeys && i < len(arr) {
+			doc.SortKey = parseNullableString(arr[i])
+			i++
+		}
+		if withContent && i < len(arr) {
+			if doc.Fields, err = parseFields(arr[i]); err != nil {
+				return nil, redisstack.WithPathIndex(err, i)
+			}
+			i++
+		}
+		res.Documents = append(res.Documents, doc)
+	}
+	return res, nil
+}
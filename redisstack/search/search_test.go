@@ -0,0 +1,211 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+)
+
+func TestArgs(t *testing.T) {
+	score, weight, slop, m := 0.5, 2.0, int64(1), int64(16)
+	temporary, timeout := time.Minute, 500*time.Millisecond
+	blob := Float32Blob([]float32{1, 2})
+	knnArgs, err := KNNArgs("idx", &KNNQuery{K: 3, Field: "vec", Param: "BLOB"}, blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rangeArgs, err := VectorRangeArgs("idx", &VectorRangeQuery{Field: "vec", Radius: 0.5, Param: "BLOB", As: "d"}, blob, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name string
+		args []any
+		exp  []any
+	}{
+		{"CreateMinimal", CreateArgs("idx", nil, []*Field{{Name: "t"}}), []any{"FT.CREATE", "idx", "SCHEMA", "t", "TEXT"}},
+		{"Create", CreateArgs("idx", &IndexOption{
+			On: IndexOnJSON, Prefixes: []string{"a:", "b:"}, Filter: "@n>0", Language: "english", Score: &score,
+			Temporary: &temporary, NoOffsets: true, StopWords: []string{}, SkipInitialScan: true,
+		}, []*Field{
+			{Name: "$.t", As: "t", Type: FieldTypeText, NoStem: true, Weight: &weight, Sortable: true, UNF: true},
+			{Name: "tag", Type: FieldTypeTag, Separator: ";", CaseSensitive: true},
+			{Name: "n", Type: FieldTypeNumeric, NoIndex: true},
+			{Name: "vec", Type: FieldTypeVector, Vector: &VectorOption{
+				Algorithm: VectorAlgorithmHNSW, Type: VectorTypeFloat32, Dim: 2, DistanceMetric: DistanceMetricCosine, M: &m,
+			}},
+		}), []any{"FT.CREATE", "idx", "ON", "JSON", "PREFIX", 2, "a:", "b:", "FILTER", "@n>0", "LANGUAGE", "english",
+			"SCORE", 0.5, "TEMPORARY", int64(60), "NOOFFSETS", "STOPWORDS", 0, "SKIPINITIALSCAN", "SCHEMA",
+			"$.t", "AS", "t", "TEXT", "NOSTEM", "WEIGHT", 2.0, "SORTABLE", "UNF",
+			"tag", "TAG", "SEPARATOR", ";", "CASESENSITIVE",
+			"n", "NUMERIC", "NOINDEX",
+			"vec", "VECTOR", "HNSW", 8, "TYPE", "FLOAT32", "DIM", int64(2), "DISTANCE_METRIC", "COSINE", "M", int64(16)}},
+		{"DropIndex", DropIndexArgs("idx", false), []any{"FT.DROPINDEX", "idx"}},
+		{"DropIndexDD", DropIndexArgs("idx", true), []any{"FT.DROPINDEX", "idx", "DD"}},
+		{"Info", InfoArgs("idx"), []any{"FT.INFO", "idx"}},
+		{"List", ListArgs(), []any{"FT._LIST"}},
+		{"SearchMinimal", SearchArgs("idx", "*", nil), []any{"FT.SEARCH", "idx", "*"}},
+		{"Search", SearchArgs("idx", "hello", &SearchOption{
+			NoContent: true, WithScores: true, InKeys: []string{"a"}, InFields: []string{"t"},
+			Return: []ReturnField{{Identifier: "t"}, {Identifier: "$.n", As: "n"}}, Slop: &slop, Timeout: &timeout,
+			InOrder: true, SortBy: &SortBy{Field: "n", Desc: true}, Limit: &Limit{10, 20},
+			Params: []redisstack.StringAnyPair{{Key: "p", Value: "v"}}, Dialect: 2,
+		}), []any{"FT.SEARCH", "idx", "hello", "NOCONTENT", "WITHSCORES", "INKEYS", 1, "a", "INFIELDS", 1, "t",
+			"RETURN", 4, "t", "$.n", "AS", "n", "SLOP", int64(1), "TIMEOUT", int64(500), "INORDER",
+			"SORTBY", "n", "DESC", "LIMIT", int64(10), int64(20), "PARAMS", 2, "p", "v", "DIALECT", 2}},
+		{"Aggregate", AggregateArgs("idx", NewAggregation("*").Verbatim().Load("@a", "@b").
+			GroupBy([]string{"@a"}, CountReducer("n"), QuantileReducer("@b", 0.5, "q"), FirstValueReducer("@b", &SortBy{Field: "@b"}, "")).
+			Apply("@n*2", "n2").SortBy(10, SortBy{Field: "@n2", Desc: true}).Filter("@n>1").Limit(0, 5).
+			WithCursor(100, time.Second).Params(redisstack.StringAnyPair{Key: "p", Value: 1}).Dialect(3)),
+			[]any{"FT.AGGREGATE", "idx", "*", "VERBATIM", "LOAD", 2, "@a", "@b",
+				"GROUPBY", 1, "@a", "REDUCE", "COUNT", 0, "AS", "n", "REDUCE", "QUANTILE", 2, "@b", 0.5, "AS", "q",
+				"REDUCE", "FIRST_VALUE", 4, "@b", "BY", "@b", "ASC",
+				"APPLY", "@n*2", "AS", "n2", "SORTBY", 2, "@n2", "DESC", "MAX", int64(10), "FILTER", "@n>1", "LIMIT", int64(0), int64(5),
+				"WITHCURSOR", "COUNT", int64(100), "MAXIDLE", int64(1000), "PARAMS", 2, "p", 1, "DIALECT", 3}},
+		{"AggregateLoadAll", AggregateArgs("idx", NewAggregation("*").LoadAll()), []any{"FT.AGGREGATE", "idx", "*", "LOAD", "*"}},
+		{"CursorRead", CursorReadArgs("idx", 7, 0), []any{"FT.CURSOR", "READ", "idx", int64(7)}},
+		{"CursorReadCount", CursorReadArgs("idx", 7, 10), []any{"FT.CURSOR", "READ", "idx", int64(7), "COUNT", int64(10)}},
+		{"CursorDel", CursorDelArgs("idx", 7), []any{"FT.CURSOR", "DEL", "idx", int64(7)}},
+		{"KNN", knnArgs, []any{"FT.SEARCH", "idx", "*=>[KNN 3 @vec $BLOB]", "SORTBY", "__vec_score", "ASC",
+			"LIMIT", int64(0), int64(3), "PARAMS", 2, "BLOB", blob, "DIALECT", 2}},
+		{"VectorRange", rangeArgs, []any{"FT.SEARCH", "idx", "@vec:[VECTOR_RANGE 0.5 $BLOB]=>{$YIELD_DISTANCE_AS: d;}",
+			"SORTBY", "d", "ASC", "PARAMS", 2, "BLOB", blob, "DIALECT", 2}},
+	} {
+		if !reflect.DeepEqual(c.args, c.exp) {
+			t.Errorf("%s: got %#v, expected %#v", c.name, c.args, c.exp)
+		}
+	}
+}
+
+func mustArgs(args []any, err error) []any {
+	if err != nil {
+		panic(err)
+	}
+	return args
+}
+
+func TestReplies(t *testing.T) {
+	exchanges, err := redisstacktest.LoadExchanges("testdata/replies.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	score, payload := 1.5, "p"
+	noContent, withScores := &SearchOption{NoContent: true}, &SearchOption{WithScores: true, WithPayloads: true}
+	knn := &KNNQuery{K: 1, Field: "vec", Param: "BLOB"}
+	for _, c := range []struct {
+		name  string
+		args  []any
+		parse func(any) (any, error)
+		exp   any
+	}{
+		{"List", ListArgs(), func(val any) (any, error) { return ListResult(val) }, []string{"idx"}},
+		{"Info", InfoArgs("idx"), func(val any) (any, error) { return InfoResult(val) }, map[string]any{"index_name": "idx", "num_docs": int64(2)}},
+		{"Search", SearchArgs("idx", "*", nil), func(val any) (any, error) { return SearchResult(val, nil) }, &ResultSet{Total: 2, Documents: []*Document{
+			{ID: "a", Fields: []redisstack.StringAnyPair{{Key: "n", Value: "1"}, {Key: "t", Value: "x"}}},
+			{ID: "b", Fields: []redisstack.StringAnyPair{{Key: "t", Value: "y"}}},
+		}}},
+		{"SearchNoContent", SearchArgs("idx", "*", noContent), func(val any) (any, error) { return SearchResult(val, noContent) }, &ResultSet{Total: 1, Documents: []*Document{{ID: "a"}}}},
+		{"SearchWithScores", SearchArgs("idx", "*", withScores), func(val any) (any, error) { return SearchResult(val, withScores) }, &ResultSet{Total: 1, Documents: []*Document{{ID: "a", Score: &score, Payload: &payload, Fields: []redisstack.StringAnyPair{{Key: "t", Value: "x"}}}}}},
+		{"Aggregate", AggregateArgs("idx", NewAggregation("*").GroupBy([]string{"@a"}, CountReducer("n"))), func(val any) (any, error) { return AggregateResult(val) }, &AggregateResultSet{Total: 2, Rows: [][]redisstack.StringAnyPair{{{Key: "a", Value: "1"}, {Key: "n", Value: "3"}}, {{Key: "a", Value: "2"}, {Key: "n", Value: "1"}}}}},
+		{"AggregateCursor", AggregateArgs("idx", NewAggregation("*").Load("@a").WithCursor(1, 0)), func(val any) (any, error) { return AggregateCursorResult(val) }, &AggregateCursorResultSet{AggregateResultSet{1, [][]redisstack.StringAnyPair{{{Key: "a", Value: "1"}}}}, 7}},
+		{"KNN", mustArgs(KNNArgs("idx", knn, Float32Blob([]float32{1, 2}))), func(val any) (any, error) { return KNNResult(val, knn) }, []*VectorHit{{ID: "a", Distance: 0.25, Fields: []redisstack.StringAnyPair{{Key: "t", Value: "x"}}}}},
+	} {
+		redisstacktest.CheckExchange(t, exchanges, c.name, c.args, c.parse, c.exp)
+	}
+}
+
+func TestResultErrors(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		parse func(any) (any, error)
+		val   any
+		path  string
+	}{
+		{"SearchTotal", func(val any) (any, error) { return SearchResult(val, nil) }, []any{"1", "a"}, "[0]"},
+		{"SearchID", func(val any) (any, error) { return SearchResult(val, nil) }, []any{int64(1), int64(1)}, "[1]"},
+		{"SearchScore", func(val any) (any, error) { return SearchResult(val, &SearchOption{WithScores: true}) }, []any{int64(1), "a", "x"}, "[2]"},
+		{"SearchFieldKey", func(val any) (any, error) { return SearchResult(val, nil) }, []any{int64(1), "a", []any{"t", "x", int64(1), "y"}}, "[2][2]"},
+		{"SearchResp3Score", func(val any) (any, error) { return SearchResult(val, nil) },
+			map[any]any{"total_results": int64(1), "results": []any{map[any]any{"id": "a", "score": "x"}}}, `["results"][0]["score"]`},
+		{"SearchResp3Total", func(val any) (any, error) { return SearchResult(val, nil) },
+			map[any]any{"total_results": "1", "results": []any{}}, `["total_results"]`},
+		{"SearchResp3FieldKey", func(val any) (any, error) { return SearchResult(val, nil) },
+			map[any]any{"total_results": int64(1), "results": []any{map[any]any{"id": "a", "extra_attributes": map[any]any{int64(1): "x"}}}}, `["results"][0]["extra_attributes"]["1"]`},
+		{"SearchResp3ID", func(val any) (any, error) { return SearchResult(val, nil) },
+			map[any]any{"total_results": int64(1), "results": []any{map[any]any{"id": int64(1)}}}, `["results"][0]["id"]`},
+		{"AggregateResp3Total", func(val any) (any, error) { return AggregateResult(val) },
+			map[any]any{"results": []any{}}, `["total_results"]`},
+		{"AggregateTotal", func(val any) (any, error) { return AggregateResult(val) }, []any{"1"}, "[0]"},
+		{"AggregateCursor", func(val any) (any, error) { return AggregateCursorResult(val) }, []any{[]any{int64(0)}, "7"}, "[1]"},
+		{"KNNDistance", func(val any) (any, error) { return KNNResult(val, &KNNQuery{K: 1, Field: "vec", Param: "BLOB"}) },
+			[]any{int64(1), "a", []any{"t", "x"}}, "[0]"},
+	} {
+		_, err := c.parse(c.val)
+		var perr *redisstack.ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%s: expected a parse error, got %v", c.name, err)
+		} else if perr.Path != c.path {
+			t.Errorf("%s: path %q, expected %q", c.name, perr.Path, c.path)
+		}
+	}
+}
+
+func TestSearchResultCapacity(t *testing.T) {
+	for _, c := range []struct {
+		option *SearchOption
+		val    []any
+	}{
+		{nil, []any{int64(1) << 40, "a", []any{"t", "x"}, "b", []any{"t", "y"}}},
+		{&SearchOption{NoContent: true}, []any{int64(1) << 40, "a", "b"}},
+		{&SearchOption{WithScores: true, WithSortKeys: true}, []any{int64(1) << 40, "a", "1", "s", []any{"t", "x"}, "b", "2", "s", []any{"t", "y"}}},
+	} {
+		res, err := SearchResult(c.val, c.option)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Documents) != 2 || cap(res.Documents) != 2 {
+			t.Errorf("%+v: len %d, cap %d", c.option, len(res.Documents), cap(res.Documents))
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		qw   QueryWritable
+	}{
+		{"Term", TermQuery("")},
+		{"Phrase", PhraseQuery{}},
+		{"FieldNoQuery", &FieldQuery{Fields: []string{"t"}}},
+		{"FieldNoFields", &FieldQuery{Query: TermQuery("x")}},
+		{"Tag", &TagQuery{Field: "tag"}},
+		{"NumericRange", &NumericRangeQuery{}},
+		{"Not", &NotQuery{}},
+		{"Intersect", IntersectQuery{TermQuery("x"), nil}},
+		{"KNN", &KNNQuery{Field: "vec", Param: "BLOB"}},
+		{"KNNParam", &KNNQuery{K: 1, Field: "vec", Param: "B]=>[KNN 9 @vec $C"}},
+		{"KNNAs", &KNNQuery{K: 1, Field: "vec", Param: "BLOB", As: "d]"}},
+		{"VectorRangeParam", &VectorRangeQuery{Field: "vec", Radius: 0.5}},
+		{"VectorRangeAs", &VectorRangeQuery{Field: "vec", Radius: 0.5, Param: "BLOB", As: "d; $EPSILON: 1"}},
+	} {
+		if _, err := QueryWritableToString(c.qw); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%s: %v", c.name, err)
+		}
+	}
+}
+
+func TestNewAggregateCursorCopies(t *testing.T) {
+	agg := NewAggregation("*").LoadAll()
+	c := NewAggregateCursor(nil, "idx", agg)
+	exp := []any{"FT.AGGREGATE", "idx", "*", "LOAD", "*"}
+	if args := AggregateArgs("idx", agg); !reflect.DeepEqual(args, exp) {
+		t.Errorf("caller's aggregation changed: %#v", args)
+	}
+	if args := AggregateArgs("idx", c.agg); !reflect.DeepEqual(args, append(exp, "WITHCURSOR")) {
+		t.Errorf("cursor aggregation: %#v", args)
+	}
+}
@@ -0,0 +1,312 @@
+package search
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ldeng7/go-redis-stack/redisstack"
+)
+
+type VectorAlgorithm byte
+
+const (
+	VectorAlgorithmFlat = VectorAlgorithm(iota)
+	VectorAlgorithmHNSW
+)
+
+var vectorAlgorithmNames = map[VectorAlgorithm]string{
+	VectorAlgorithmFlat: "FLAT",
+	VectorAlgorithmHNSW: "HNSW",
+}
+
+type VectorType byte
+
+const (
+	VectorTypeFloat32 = VectorType(iota)
+	VectorTypeFloat64
+)
+
+var vectorTypeNames = map[VectorType]string{
+	VectorTypeFloat32: "FLOAT32",
+	VectorTypeFloat64: "FLOAT64",
+}
+
+type DistanceMetric byte
+
+const (
+	DistanceMetricL2 = DistanceMetric(iota)
+	DistanceMetricIP
+	DistanceMetricCosine
+)
+
+var distanceMetricNames = map[DistanceMetric]string{
+	DistanceMetricL2:     "L2",
+	DistanceMetricIP:     "IP",
+	DistanceMetricCosine: "COSINE",
+}
+
+type VectorOption struct {
+	Algorithm      VectorAlgorithm
+	Type           VectorType
+	Dim            int64
+	DistanceMetric DistanceMetric
+	InitialCap     *int64
+	BlockSize      *int64
+	M              *int64
+	EFConstruction *int64
+	EFRuntime      *int64
+	Epsilon        *float64
+	Attributes     []redisstack.StringAnyPair
+}
+
+func (opt *VectorOption) appendArgs(args []any) []any {
+	attrs := make([]any, 0, 20+len(opt.Attributes)*2)
+	attrs = append(attrs, "TYPE", vectorTypeNames[opt.Type], "DIM", opt.Dim,
+		"DISTANCE_METRIC", distanceMetricNames[opt.DistanceMetric])
+	if opt.InitialCap != nil {
+		attrs = append(attrs, "INITIAL_CAP", *opt.InitialCap)
+	}
+	switch opt.Algorithm {
+	case VectorAlgorithmFlat:
+		if opt.BlockSize != nil {
+			attrs = append(attrs, "BLOCK_SIZE", *opt.BlockSize)
+		}
+	case VectorAlgorithmHNSW:
+		if opt.M != nil {
+			attrs = append(attrs, "M", *opt.M)
+		}
+		if opt.EFConstruction != nil {
+			attrs = append(attrs, "EF_CONSTRUCTION", *opt.EFConstruction)
+		}
+		if opt.EFRuntime != nil {
+			attrs = append(attrs, "EF_RUNTIME", *opt.EFRuntime)
+		}
+		if opt.Epsilon != nil {
+			attrs = append(attrs, "EPSILON", *opt.Epsilon)
+		}
+	}
+	for _, attr := range opt.Attributes {
+		attrs = append(attrs, attr.Key, attr.Value)
+	}
+	args = append(args, vectorAlgorithmNames[opt.Algorithm], len(attrs))
+	return append(args, attrs...)
+}
+
+func Float32Blob(vec []float32) []byte {
+	blob := make([]byte, len(vec)*4)
+	for i, f := range vec {
+		binary.LittleEndian.PutUint32(blob[i*4:], math.Float32bits(f))
+	}
+	return blob
+}
+
+func Float64Blob(vec []float64) []byte {
+	blob := make([]byte, len(vec)*8)
+	for i, f := range vec {
+		binary.LittleEndian.PutUint64(blob[i*8:], math.Float64bits(f))
+	}
+	return blob
+}
+
+func ParseFloat32Blob(blob []byte) ([]float32, error) {
+	if len(blob)%4 != 0 {
+		return nil, redisstack.ErrInvalidData
+	}
+	vec := make([]float32, len(blob)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(blob[i*4:]))
+	}
+	return vec, nil
+}
+
+func ParseFloat64Blob(blob []byte) ([]float64, error) {
+	if len(blob)%8 != 0 {
+		return nil, redisstack.ErrInvalidData
+	}
+	vec := make([]float64, len(blob)/8)
+	for i := range vec {
+		vec[i] = math.Float64frombits(binary.LittleEndian.Uint64(blob[i*8:]))
+	}
+	return vec, nil
+}
+
+func isIdentifier(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+type KNNQuery struct {
+	Filter    QueryWritable
+	K         int64
+	Field     string
+	Param     string
+	EFRuntime *int64
+	As        string
+}
+
+func (q *KNNQuery) WriteToQuery(sb *strings.Builder) error {
+	if q.K <= 0 || len(q.Field) == 0 || !isIdentifier(q.Param) || (len(q.As) > 0 && !isIdentifier(q.As)) {
+		return ErrInvalidQuery
+	}
+	if q.Filter != nil {
+		if err := writeGroup("(", q.Filter, sb); err != nil {
+			return err
+		}
+	} else {
+		sb.WriteByte('*')
+	}
+	sb.WriteString("=>[KNN ")
+	sb.WriteString(strconv.FormatInt(q.K, 10))
+	sb.WriteString(" @")
+	writeEscaped(q.Field, sb)
+	sb.WriteString(" $")
+	sb.WriteString(q.Param)
+	if q.EFRuntime != nil {
+		sb.WriteString(" EF_RUNTIME ")
+		sb.WriteString(strconv.FormatInt(*q.EFRuntime, 10))
+	}
+	if len(q.As) > 0 {
+		sb.WriteString(" AS ")
+		sb.WriteString(q.As)
+	}
+	sb.WriteByte(']')
+	return nil
+}
+
+func (q *KNNQuery) DistanceField() string {
+	if len(q.As) > 0 {
+		return q.As
+	}
+	return "__" + q.Field + "_score"
+}
+
+func (q *KNNQuery) SearchOption(blob []byte) *SearchOption {
+	return &SearchOption{
+		SortBy:  &SortBy{Field: q.DistanceField()},
+		Limit:   &Limit{0, q.K},
+		Params:  []redisstack.StringAnyPair{{Key: q.Param, Value: blob}},
+		Dialect: 2,
+	}
+}
+
+func KNNArgs(index string, q *KNNQuery, blob []byte) ([]any, error) {
+	query, err := QueryWritableToString(q)
+	if err != nil {
+		return nil, err
+	}
+	return SearchArgs(index, query, q.SearchOption(blob)), nil
+}
+
+func KNNResult(val any, q *KNNQuery) ([]*VectorHit, error) {
+	res, err := SearchResult(val, nil)
+	if err != nil {
+		return nil, err
+	}
+	return VectorHits(res, q.DistanceField())
+}
+
+type VectorRangeQuery struct {
+	Field   string
+	Radius  float64
+	Param   string
+	Epsilon *float64
+	As      string
+}
+
+func (q *VectorRangeQuery) WriteToQuery(sb *strings.Builder) error {
+	if !isIdentifier(q.Param) || (len(q.As) > 0 && !isIdentifier(q.As)) {
+		return ErrInvalidQuery
+	}
+	if err := writeFieldPrefix([]string{q.Field}, sb); err != nil {
+		return err
+	}
+	sb.WriteString("[VECTOR_RANGE ")
+	writeNumber(q.Radius, sb)
+	sb.WriteString(" $")
+	sb.WriteString(q.Param)
+	sb.WriteString("]=>{$YIELD_DISTANCE_AS: ")
+	sb.WriteString(q.DistanceField())
+	sb.WriteString(";")
+	if q.Epsilon != nil {
+		sb.WriteString(" $EPSILON: ")
+		writeNumber(*q.Epsilon, sb)
+		sb.WriteString(";")
+	}
+	sb.WriteByte('}')
+	return nil
+}
+
+func (q *VectorRangeQuery) DistanceField() string {
+	if len(q.As) > 0 {
+		return q.As
+	}
+	return "__" + q.Field + "_score"
+}
+
+func (q *VectorRangeQuery) SearchOption(blob []byte) *SearchOption {
+	return &SearchOption{
+		SortBy:  &SortBy{Field: q.DistanceField()},
+		Params:  []redisstack.StringAnyPair{{Key: q.Param, Value: blob}},
+		Dialect: 2,
+	}
+}
+
+func VectorRangeArgs(index string, q *VectorRangeQuery, blob []byte, filter QueryWritable) ([]any, error) {
+	var qw QueryWritable = q
+	if filter != nil {
+		qw = IntersectQuery{filter, q}
+	}
+	query, err := QueryWritableToString(qw)
+	if err != nil {
+		return nil, err
+	}
+	return SearchArgs(index, query, q.SearchOption(blob)), nil
+}
+
+func VectorRangeResult(val any, q *VectorRangeQuery) ([]*VectorHit, error) {
+	res, err := SearchResult(val, nil)
+	if err != nil {
+		return nil, err
+	}
+	return VectorHits(res, q.DistanceField())
+}
+
+type VectorHit struct {
+	ID       string
+	Distance float64
+	Fields   []redisstack.StringAnyPair
+}
+
+func VectorHits(res *ResultSet, distanceField string) ([]*VectorHit, error) {
+	hits := make([]*VectorHit, len(res.Documents))
+	for i, doc := range res.Documents {
+		hit := &VectorHit{ID: doc.ID, Fields: make([]redisstack.StringAnyPair, 0, len(doc.Fields))}
+		found := false
+		for _, field := range doc.Fields {
+			if field.Key != distanceField {
+				hit.Fields = append(hit.Fields, field)
+				continue
+			}
+			d, err := redisstack.ParseFloat(field.Value)
+			if err != nil {
+				return nil, redisstack.WithPathIndex(redisstack.WithPathKey(err, distanceField), i)
+			}
+			hit.Distance, found = d, true
+		}
+		if !found {
+			return nil, redisstack.WithPathIndex(redisstack.NewDataError("field "+distanceField, "none", doc.Fields), i)
+		}
+		hits[i] = hit
+	}
+	return hits, nil
+}
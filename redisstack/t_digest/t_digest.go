@@ -0,0 +1,151 @@
+package t_digest
+
+import "github.com/ldeng7/go-redis-stack/redisstack"
+
+type Info struct {
+	Compression       int64
+	Capacity          int64
+	MergedNodes       int64
+	UnmergedNodes     int64
+	MergedWeight      float64
+	UnmergedWeight    float64
+	Observations      int64
+	TotalCompressions int64
+	MemoryUsage       int64
+	Extra             map[string]any
+}
+
+func argsByKeyAndValues[T any](cmd string, key string, values []T) []any {
+	args := make([]any, 0, 2+len(values))
+	args = append(args, cmd, key)
+	for _, value := range values {
+		args = append(args, value)
+	}
+	return args
+}
+
+func AddArgs(key string, values []float64) []any {
+	return argsByKeyAndValues("TDIGEST.ADD", key, values)
+}
+
+func ByRankArgs(key string, ranks []int64) []any {
+	return argsByKeyAndValues("TDIGEST.BYRANK", key, ranks)
+}
+
+func ByRankResult(val any) ([]float64, error) {
+	return redisstack.ParseFloatArray(val, 0)
+}
+
+func ByRevRankArgs(key string, ranks []int64) []any {
+	return argsByKeyAndValues("TDIGEST.BYREVRANK", key, ranks)
+}
+
+func ByRevRankResult(val any) ([]float64, error) {
+	return redisstack.ParseFloatArray(val, 0)
+}
+
+func CDFArgs(key string, values []float64) []any {
+	return argsByKeyAndValues("TDIGEST.CDF", key, values)
+}
+
+func CDFResult(val any) ([]float64, error) {
+	return redisstack.ParseFloatArray(val, 0)
+}
+
+func CreateArgs(key string, compression *int64) []any {
+	if compression != nil {
+		return []any{"TDIGEST.CREATE", key, "COMPRESSION", *compression}
+	}
+	return []any{"TDIGEST.CREATE", key}
+}
+
+func InfoArgs(key string) []any {
+	return []any{"TDIGEST.INFO", key}
+}
+
+func InfoResult(val any) (*Info, error) {
+	res := &Info{}
+	extra, err := redisstack.ParseInfo(val, map[string]any{
+		"Compression":        &res.Compression,
+		"Capacity":           &res.Capacity,
+		"Merged nodes":       &res.MergedNodes,
+		"Unmerged nodes":     &res.UnmergedNodes,
+		"Merged weight":      &res.MergedWeight,
+		"Unmerged weight":    &res.UnmergedWeight,
+		"Observations":       &res.Observations,
+		"Total compressions": &res.TotalCompressions,
+		"Memory usage":       &res.MemoryUsage,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.Extra = extra
+	return res, nil
+}
+
+func MaxArgs(key string) []any {
+	return []any{"TDIGEST.MAX", key}
+}
+
+func MaxResult(val any) (float64, error) {
+	return redisstack.ParseFloat(val)
+}
+
+func MergeArgs(destKey string, srcKeys []string, compression *int64, override bool) []any {
+	args := make([]any, 0, 6+len(srcKeys))
+	args = append(args, "TDIGEST.MERGE", destKey, len(srcKeys))
+	for _, srcKey := range srcKeys {
+		args = append(args, srcKey)
+	}
+	if compression != nil {
+		args = append(args, "COMPRESSION", *compression)
+	}
+	if override {
+		args = append(args, "OVERRIDE")
+	}
+	return args
+}
+
+func MinArgs(key string) []any {
+	return []any{"TDIGEST.MIN", key}
+}
+
+func MinResult(val any) (float64, error) {
+	return redisstack.ParseFloat(val)
+}
+
+func QuantileArgs(key string, quantiles []float64) []any {
+	return argsByKeyAndValues("TDIGEST.QUANTILE", key, quantiles)
+}
+
+func QuantileResult(val any) ([]float64, error) {
+	return redisstack.ParseFloatArray(val, 0)
+}
+
+func RankArgs(key string, values []float64) []any {
+	return argsByKeyAndValues("TDIGEST.RANK", key, values)
+}
+
+func RankResult(val any) ([]int64, error) {
+	return redisstack.ParseScalarArray[int64](val, 0)
+}
+
+func ResetArgs(key string) []any {
+	return []any{"TDIGEST.RESET", key}
+}
+
+func RevRankArgs(key string, values []float64) []any {
+	return argsByKeyAndValues("TDIGEST.REVRANK", key, values)
+}
+
+func RevRankResult(val any) ([]int64, error) {
+	return redisstack.ParseScalarArray[int64](val, 0)
+}
+
+func TrimmedMeanArgs(key string, lowCutQuantile float64, highCutQuantile float64) []any {
+	return []any{"TDIGEST.TRIMMED_MEAN", key, lowCutQuantile, highCutQuantile}
+}
+
+func TrimmedMeanResult(val any) (float64, error) {
+	return redisstack.ParseFloat(val)
+}
@@ -0,0 +1,97 @@
+package t_digest
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+)
+
+func TestArgs(t *testing.T) {
+	compression := int64(200)
+	for _, c := range []struct {
+		args []any
+		exp  []any
+	}{
+		{AddArgs("k", []float64{1, 2.5}), []any{"TDIGEST.ADD", "k", 1.0, 2.5}},
+		{ByRankArgs("k", []int64{0, 1}), []any{"TDIGEST.BYRANK", "k", int64(0), int64(1)}},
+		{ByRevRankArgs("k", []int64{0}), []any{"TDIGEST.BYREVRANK", "k", int64(0)}},
+		{CDFArgs("k", []float64{1.5}), []any{"TDIGEST.CDF", "k", 1.5}},
+		{CreateArgs("k", nil), []any{"TDIGEST.CREATE", "k"}},
+		{CreateArgs("k", &compression), []any{"TDIGEST.CREATE", "k", "COMPRESSION", int64(200)}},
+		{InfoArgs("k"), []any{"TDIGEST.INFO", "k"}},
+		{MaxArgs("k"), []any{"TDIGEST.MAX", "k"}},
+		{MergeArgs("d", []string{"a", "b"}, nil, false), []any{"TDIGEST.MERGE", "d", 2, "a", "b"}},
+		{MergeArgs("d", []string{"a"}, &compression, true), []any{"TDIGEST.MERGE", "d", 1, "a", "COMPRESSION", int64(200), "OVERRIDE"}},
+		{MinArgs("k"), []any{"TDIGEST.MIN", "k"}},
+		{QuantileArgs("k", []float64{0.5, 0.9}), []any{"TDIGEST.QUANTILE", "k", 0.5, 0.9}},
+		{RankArgs("k", []float64{3}), []any{"TDIGEST.RANK", "k", 3.0}},
+		{ResetArgs("k"), []any{"TDIGEST.RESET", "k"}},
+		{RevRankArgs("k", []float64{3}), []any{"TDIGEST.REVRANK", "k", 3.0}},
+		{TrimmedMeanArgs("k", 0.1, 0.9), []any{"TDIGEST.TRIMMED_MEAN", "k", 0.1, 0.9}},
+	} {
+		if !reflect.DeepEqual(c.args, c.exp) {
+			t.Errorf("got %#v, expected %#v", c.args, c.exp)
+		}
+	}
+}
+
+func TestReplies(t *testing.T) {
+	exchanges, err := redisstacktest.LoadExchanges("testdata/replies.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &Info{Compression: 100, Capacity: 610, MergedNodes: 4, UnmergedNodes: 1, MergedWeight: 4, UnmergedWeight: 1,
+		Observations: 5, TotalCompressions: 1, MemoryUsage: 9768, Extra: map[string]any{}}
+	for _, c := range []struct {
+		name  string
+		args  []any
+		parse func(any) (any, error)
+		exp   any
+	}{
+		{"ByRank", ByRankArgs("k", []int64{0, 10}), func(val any) (any, error) { return ByRankResult(val) }, []float64{1, math.Inf(1)}},
+		{"ByRevRank", ByRevRankArgs("k", []int64{0, 10}), func(val any) (any, error) { return ByRevRankResult(val) }, []float64{4, math.Inf(-1)}},
+		{"CDF", CDFArgs("k", []float64{2}), func(val any) (any, error) { return CDFResult(val) }, []float64{0.375}},
+		{"Info", InfoArgs("k"), func(val any) (any, error) { return InfoResult(val) }, info},
+		{"Max", MaxArgs("k"), func(val any) (any, error) { return MaxResult(val) }, 4.0},
+		{"Min", MinArgs("k"), func(val any) (any, error) { return MinResult(val) }, 1.0},
+		{"Quantile", QuantileArgs("k", []float64{0.5, 0.9}), func(val any) (any, error) { return QuantileResult(val) }, []float64{3, 4}},
+		{"Rank", RankArgs("k", []float64{2, 0}), func(val any) (any, error) { return RankResult(val) }, []int64{1, -1}},
+		{"RevRank", RevRankArgs("k", []float64{3}), func(val any) (any, error) { return RevRankResult(val) }, []int64{2}},
+		{"TrimmedMean", TrimmedMeanArgs("k", 0.1, 0.9), func(val any) (any, error) { return TrimmedMeanResult(val) }, 2.5},
+	} {
+		redisstacktest.CheckExchange(t, exchanges, c.name, c.args, c.parse, c.exp)
+	}
+}
+
+func TestNaNResults(t *testing.T) {
+	for protocol, val := range map[int]any{2: "nan", 3: math.NaN()} {
+		if res, err := MinResult(val); err != nil || !math.IsNaN(res) {
+			t.Errorf("RESP%d: %v %v", protocol, res, err)
+		}
+	}
+	if res, err := QuantileResult([]any{"nan"}); err != nil || len(res) != 1 || !math.IsNaN(res[0]) {
+		t.Errorf("%v %v", res, err)
+	}
+}
+
+func TestResultErrors(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		parse func(any) (any, error)
+		val   any
+	}{
+		{"Max", func(val any) (any, error) { return MaxResult(val) }, "x"},
+		{"Quantile", func(val any) (any, error) { return QuantileResult(val) }, []any{true}},
+		{"Rank", func(val any) (any, error) { return RankResult(val) }, []any{"1"}},
+		{"Info", func(val any) (any, error) { return InfoResult(val) }, []any{"Merged weight", "x"}},
+	} {
+		var perr *redisstack.ParseError
+		if _, err := c.parse(c.val); !errors.As(err, &perr) {
+			t.Errorf("%s: expected a parse error, got %v", c.name, err)
+		}
+	}
+}
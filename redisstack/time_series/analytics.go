@@ -0,0 +1,331 @@
+package redisstack
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+func sortedSamples(samples []*Sample) []*Sample {
+	if sort.SliceIsSorted(samples, func(i, j int) bool { return samples[i].Time.UnixMilli() < samples[j].Time.UnixMilli() }) {
+		return samples
+	}
+	res := make([]*Sample, len(samples))
+	copy(res, samples)
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Time.UnixMilli() < res[j].Time.UnixMilli() })
+	return res
+}
+
+func sampleKey(samples []*Sample) string {
+	if len(samples) == 0 {
+		return ""
+	}
+	return samples[0].Key
+}
+
+func reduceValues(aggregator AggregateType, samples []*Sample) float64 {
+	n := float64(len(samples))
+	if len(samples) == 0 {
+		switch aggregator {
+		case AggregateTypeSum, AggregateTypeCount:
+			return 0
+		}
+		return math.NaN()
+	}
+	sum, min, max := 0.0, math.Inf(1), math.Inf(-1)
+	for _, sample := range samples {
+		sum += sample.Value
+		min, max = math.Min(min, sample.Value), math.Max(max, sample.Value)
+	}
+	variance := func(unbiased bool) float64 {
+		if unbiased && len(samples) < 2 {
+			return 0
+		}
+		mean, sq := sum/n, 0.0
+		for _, sample := range samples {
+			sq += (sample.Value - mean) * (sample.Value - mean)
+		}
+		if unbiased {
+			return sq / (n - 1)
+		}
+		return sq / n
+	}
+	switch aggregator {
+	case AggregateTypeAvg:
+		return sum / n
+	case AggregateTypeSum:
+		return sum
+	case AggregateTypeMin:
+		return min
+	case AggregateTypeMax:
+		return max
+	case AggregateTypeRange:
+		return max - min
+	case AggregateTypeCount:
+		return n
+	case AggregateTypeFirst:
+		return samples[0].Value
+	case AggregateTypeLast:
+		return samples[len(samples)-1].Value
+	case AggregateTypeStdP:
+		return math.Sqrt(variance(false))
+	case AggregateTypeStdS:
+		return math.Sqrt(variance(true))
+	case AggregateTypeVarP:
+		return variance(false)
+	case AggregateTypeVarS:
+		return variance(true)
+	case AggregateTypeTWA:
+		return twa(samples, nil, nil, 0, 0)
+	}
+	return math.NaN()
+}
+
+func interpolate(a *Sample, b *Sample, t int64) float64 {
+	ta, tb := a.Time.UnixMilli(), b.Time.UnixMilli()
+	if ta == tb {
+		return b.Value
+	}
+	return a.Value + (b.Value-a.Value)*float64(t-ta)/float64(tb-ta)
+}
+
+func twa(samples []*Sample, prev *Sample, next *Sample, start int64, end int64) float64 {
+	if len(samples) == 0 {
+		if prev == nil || next == nil {
+			return math.NaN()
+		}
+		return (interpolate(prev, next, start) + interpolate(prev, next, end)) / 2
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	firstTime, lastTime := first.Time.UnixMilli(), last.Time.UnixMilli()
+	area := 0.0
+	if prev != nil && firstTime > start {
+		v := interpolate(prev, first, start)
+		area += (v + first.Value) / 2 * float64(firstTime-start)
+		firstTime = start
+	}
+	for i := 1; i < len(samples); i++ {
+		a, b := samples[i-1], samples[i]
+		area += (a.Value + b.Value) / 2 * float64(b.Time.UnixMilli()-a.Time.UnixMilli())
+	}
+	if next != nil && end > lastTime {
+		v := interpolate(last, next, end)
+		area += (last.Value + v) / 2 * float64(end-lastTime)
+		lastTime = end
+	}
+	if lastTime == firstTime {
+		return reduceValues(AggregateTypeAvg, samples)
+	}
+	return area / float64(lastTime-firstTime)
+}
+
+func Reduce(samples []*Sample, aggregator AggregateType) (float64, error) {
+	if _, ok := aggregateTypeNames[aggregator]; !ok || aggregator == AggregateTypeNone {
+		return 0, ErrInvalidQuery
+	}
+	return reduceValues(aggregator, sortedSamples(samples)), nil
+}
+
+func bucketStart(t int64, bucket int64, align int64) int64 {
+	m := (t - align) % bucket
+	if m < 0 {
+		m += bucket
+	}
+	return t - m
+}
+
+func Downsample(samples []*Sample, from Timestamp, to Timestamp, agg *MultiQueryAggregation) ([]*Sample, error) {
+	bucket := agg.BucketDuration.Milliseconds()
+	if _, ok := aggregateTypeNames[agg.Aggregator]; !ok || agg.Aggregator == AggregateTypeNone || bucket <= 0 {
+		return nil, ErrInvalidQuery
+	} else if from.Kind == TimestampKindServer || to.Kind == TimestampKindServer {
+		return nil, ErrInvalidQuery
+	}
+	var align int64
+	switch agg.Align {
+	case "":
+	case "-", "start":
+		if !from.IsExplicit() {
+			return nil, ErrInvalidQuery
+		}
+		align = from.UnixMilli()
+	case "+", "end":
+		if !to.IsExplicit() {
+			return nil, ErrInvalidQuery
+		}
+		align = to.UnixMilli()
+	default:
+		var err error
+		if align, err = strconv.ParseInt(agg.Align, 10, 64); err != nil {
+			return nil, ErrInvalidQuery
+		}
+	}
+	var offset int64
+	switch agg.BucketTimestamp {
+	case "", "-", "start", "low":
+	case "+", "end", "high":
+		offset = bucket
+	case "~", "mid":
+		offset = bucket / 2
+	default:
+		return nil, ErrInvalidQuery
+	}
+
+	samples = sortedSamples(samples)
+	lo, hi := 0, len(samples)
+	if from.IsExplicit() {
+		lo = sort.Search(len(samples), func(i int) bool { return samples[i].Time.UnixMilli() >= from.UnixMilli() })
+	}
+	if to.IsExplicit() {
+		hi = sort.Search(len(samples), func(i int) bool { return samples[i].Time.UnixMilli() > to.UnixMilli() })
+	}
+
+	key, res := sampleKey(samples[lo:hi]), []*Sample{}
+	reduce := func(i int, j int, start int64) float64 {
+		if agg.Aggregator != AggregateTypeTWA {
+			return reduceValues(agg.Aggregator, samples[i:j])
+		}
+		var prev, next *Sample
+		if i > 0 {
+			prev = samples[i-1]
+		}
+		if j < len(samples) {
+			next = samples[j]
+		}
+		return twa(samples[i:j], prev, next, start, start+bucket)
+	}
+	for i := lo; i < hi; {
+		start := bucketStart(samples[i].Time.UnixMilli(), bucket, align)
+		j := i + 1
+		for ; j < hi && samples[j].Time.UnixMilli()-start < bucket; j++ {
+		}
+		res = append(res, &Sample{Key: key, Time: AtMilli(start + offset), Value: reduce(i, j, start)})
+		if agg.Empty && j < hi {
+			next := bucketStart(samples[j].Time.UnixMilli(), bucket, align)
+			for e := start + bucket; e < next; e += bucket {
+				v := reduce(j, j, e)
+				if agg.Aggregator == AggregateTypeLast {
+					v = samples[j-1].Value
+				}
+				res = append(res, &Sample{Key: key, Time: AtMilli(e + offset), Value: v})
+			}
+		}
+		i = j
+	}
+	return res, nil
+}
+
+func Increase(samples []*Sample) []*Sample {
+	samples = sortedSamples(samples)
+	if len(samples) < 2 {
+		return []*Sample{}
+	}
+	res := make([]*Sample, len(samples)-1)
+	for i := 1; i < len(samples); i++ {
+		a, b := samples[i-1], samples[i]
+		d := b.Value - a.Value
+		if d < 0 {
+			d = b.Value
+		}
+		res[i-1] = &Sample{Key: b.Key, Time: b.Time, Value: d}
+	}
+	return res
+}
+
+func Rate(samples []*Sample, unit time.Duration) []*Sample {
+	samples = sortedSamples(samples)
+	res := Increase(samples)
+	j := 0
+	for i, sample := range res {
+		dt := samples[i+1].Time.UnixMilli() - samples[i].Time.UnixMilli()
+		if dt <= 0 {
+			continue
+		}
+		sample.Value = sample.Value / float64(dt) * float64(unit.Milliseconds())
+		res[j] = sample
+		j++
+	}
+	return res[:j]
+}
+
+func FillForward(samples []*Sample, step time.Duration) []*Sample {
+	samples = sortedSamples(samples)
+	ms := step.Milliseconds()
+	if len(samples) == 0 || ms <= 0 {
+		return samples
+	}
+	key, first, last := sampleKey(samples), samples[0].Time.UnixMilli(), samples[len(samples)-1].Time.UnixMilli()
+	res := make([]*Sample, 0, (last-first)/ms+1)
+	i := 0
+	for t := first; t <= last; t += ms {
+		for i+1 < len(samples) && samples[i+1].Time.UnixMilli() <= t {
+			i++
+		}
+		res = append(res, &Sample{Key: key, Time: AtMilli(t), Value: samples[i].Value})
+		if t > math.MaxInt64-ms {
+			break
+		}
+	}
+	return res
+}
+
+type JoinType byte
+
+const (
+	JoinTypeOuter = JoinType(iota)
+	JoinTypeInner
+	JoinTypeLeft
+)
+
+type JoinedSample struct {
+	Time   Timestamp
+	Values []float64
+}
+
+func Join(series [][]*Sample, joinType JoinType, fillForward bool) []*JoinedSample {
+	sorted := make([][]*Sample, len(series))
+	var times []int64
+	seen := map[int64]struct{}{}
+	for i, samples := range series {
+		sorted[i] = sortedSamples(samples)
+		if joinType == JoinTypeLeft && i > 0 {
+			continue
+		}
+		for _, sample := range sorted[i] {
+			t := sample.Time.UnixMilli()
+			if _, ok := seen[t]; !ok {
+				seen[t] = struct{}{}
+				times = append(times, t)
+			}
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	res := make([]*JoinedSample, 0, len(times))
+	pos := make([]int, len(series))
+	last := make([]float64, len(series))
+	for i := range last {
+		last[i] = math.NaN()
+	}
+	for _, t := range times {
+		js := &JoinedSample{Time: AtMilli(t), Values: make([]float64, len(series))}
+		complete := true
+		for i, samples := range sorted {
+			found := false
+			for ; pos[i] < len(samples) && samples[pos[i]].Time.UnixMilli() <= t; pos[i]++ {
+				last[i], found = samples[pos[i]].Value, samples[pos[i]].Time.UnixMilli() == t
+			}
+			if js.Values[i] = last[i]; !found {
+				complete = false
+				if !fillForward {
+					js.Values[i] = math.NaN()
+				}
+			}
+		}
+		if joinType != JoinTypeInner || complete {
+			res = append(res, js)
+		}
+	}
+	return res
+}
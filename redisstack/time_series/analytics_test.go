@@ -0,0 +1,152 @@
+package redisstack_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+	ts "github.com/ldeng7/go-redis-stack/redisstack/time_series"
+)
+
+type point struct {
+	t int64
+	v float64
+}
+
+func samePoints(samples []*ts.Sample, exp []point) bool {
+	if len(samples) != len(exp) {
+		return false
+	}
+	for i, s := range samples {
+		v, e := s.Value, exp[i].v
+		if s.Time.UnixMilli() != exp[i].t {
+			return false
+		} else if math.IsNaN(e) != math.IsNaN(v) || !math.IsNaN(e) && math.Abs(v-e) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+type downsampleCase struct {
+	name string
+	from ts.Timestamp
+	to   ts.Timestamp
+	agg  *ts.MultiQueryAggregation
+	exp  []point
+}
+
+func TestDownsampleMatchesRange(t *testing.T) {
+	data := []point{{100, 1}, {103, 4}, {105, 2}, {109, 6}, {120, 3}}
+	nan := math.NaN()
+	v110 := 6 - 3.0/11
+	twa100 := (3*2.5 + 2*3 + 4*4 + (6+v110)/2) / 10
+	stdp, vars := math.Sqrt(14.75/4), 14.75/3
+
+	type aggCase struct {
+		name string
+		agg  ts.AggregateType
+		b100 float64
+		b110 float64
+		b120 float64
+	}
+	cases := []downsampleCase{}
+	for _, c := range []aggCase{
+		{"avg", ts.AggregateTypeAvg, 3.25, nan, 3},
+		{"sum", ts.AggregateTypeSum, 13, 0, 3},
+		{"min", ts.AggregateTypeMin, 1, nan, 3},
+		{"max", ts.AggregateTypeMax, 6, nan, 3},
+		{"range", ts.AggregateTypeRange, 5, nan, 0},
+		{"count", ts.AggregateTypeCount, 4, 0, 1},
+		{"first", ts.AggregateTypeFirst, 1, nan, 3},
+		{"last", ts.AggregateTypeLast, 6, 6, 3},
+		{"std.p", ts.AggregateTypeStdP, stdp, nan, 0},
+		{"std.s", ts.AggregateTypeStdS, math.Sqrt(vars), nan, 0},
+		{"var.p", ts.AggregateTypeVarP, 14.75 / 4, nan, 0},
+		{"var.s", ts.AggregateTypeVarS, vars, nan, 0},
+		{"twa", ts.AggregateTypeTWA, twa100, (v110 + 3) / 2, 3},
+	} {
+		agg := &ts.MultiQueryAggregation{Aggregator: c.agg, BucketDuration: 10 * time.Millisecond}
+		aggEmpty := *agg
+		aggEmpty.Empty = true
+		cases = append(cases,
+			downsampleCase{c.name, ts.Timestamp{}, ts.Timestamp{}, agg, []point{{100, c.b100}, {120, c.b120}}},
+			downsampleCase{c.name + "/empty", ts.Timestamp{}, ts.Timestamp{}, &aggEmpty, []point{{100, c.b100}, {110, c.b110}, {120, c.b120}}},
+		)
+	}
+	sum := func(align string, bucket time.Duration, bucketTS string) *ts.MultiQueryAggregation {
+		return &ts.MultiQueryAggregation{Align: align, Aggregator: ts.AggregateTypeSum, BucketDuration: bucket, BucketTimestamp: bucketTS}
+	}
+	cases = append(cases, []downsampleCase{
+		{"align/explicit", ts.Timestamp{}, ts.Timestamp{}, sum("5", 10*time.Millisecond, ""), []point{{95, 5}, {105, 8}, {115, 3}}},
+		{"align/start", ts.AtMilli(100), ts.Timestamp{}, sum("-", 7*time.Millisecond, ""), []point{{100, 7}, {107, 6}, {114, 3}}},
+		{"align/end", ts.Timestamp{}, ts.AtMilli(120), sum("+", 7*time.Millisecond, ""), []point{{99, 7}, {106, 6}, {120, 3}}},
+		{"align/start/bounds", ts.AtMilli(102), ts.AtMilli(118), sum("start", 7*time.Millisecond, ""), []point{{102, 6}, {109, 6}}},
+		{"align/end/bounds", ts.Timestamp{}, ts.AtMilli(112), sum("end", 7*time.Millisecond, ""), []point{{98, 5}, {105, 8}}},
+		{"twa/bounds", ts.AtMilli(102), ts.AtMilli(118), &ts.MultiQueryAggregation{
+			Aggregator: ts.AggregateTypeTWA, BucketDuration: 10 * time.Millisecond,
+		}, []point{{100, twa100}}},
+		{"bucketts/high", ts.Timestamp{}, ts.Timestamp{}, sum("", 10*time.Millisecond, "+"), []point{{110, 13}, {130, 3}}},
+		{"bucketts/mid", ts.Timestamp{}, ts.Timestamp{}, sum("", 10*time.Millisecond, "~"), []point{{105, 13}, {125, 3}}},
+		{"bucketts/mid/empty", ts.Timestamp{}, ts.Timestamp{}, &ts.MultiQueryAggregation{
+			Aggregator: ts.AggregateTypeCount, BucketDuration: 10 * time.Millisecond, BucketTimestamp: "mid", Empty: true,
+		}, []point{{105, 4}, {115, 0}, {125, 1}}},
+	}...)
+
+	samples := make([]*ts.Sample, len(data))
+	for i, p := range data {
+		samples[i] = &ts.Sample{Key: "k", Time: ts.AtMilli(p.t), Value: p.v}
+	}
+	for _, c := range cases {
+		if res, err := ts.Downsample(samples, c.from, c.to, c.agg); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		} else if !samePoints(res, c.exp) {
+			t.Errorf("%s: Downsample got %v", c.name, pointsOf(res))
+		}
+	}
+
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		for _, s := range samples {
+			if err := red.Do(ctx, ts.AddArgs(s, nil)...).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, c := range cases {
+			res, err := ts.RangeResult(red.Do(ctx, ts.RangeArgs("k", &ts.MultiQuery{FromTime: c.from, ToTime: c.to, Aggregation: c.agg})...).Val())
+			if err != nil {
+				t.Errorf("%s: %v", c.name, err)
+			} else if !samePoints(res, c.exp) {
+				t.Errorf("%s: TS.RANGE got %v", c.name, pointsOf(res))
+			}
+		}
+	})
+}
+
+func TestDownsampleInvalid(t *testing.T) {
+	samples := []*ts.Sample{{Key: "k", Time: ts.AtMilli(100), Value: 1}}
+	sum := func(align string) *ts.MultiQueryAggregation {
+		return &ts.MultiQueryAggregation{Align: align, Aggregator: ts.AggregateTypeSum, BucketDuration: 10 * time.Millisecond}
+	}
+	for _, c := range []downsampleCase{
+		{"align/start/open", ts.Earliest, ts.Timestamp{}, sum("start"), nil},
+		{"align/end/open", ts.Timestamp{}, ts.Latest, sum("+"), nil},
+		{"server", ts.ServerTime, ts.Timestamp{}, sum(""), nil},
+		{"bucket", ts.Timestamp{}, ts.Timestamp{}, &ts.MultiQueryAggregation{Aggregator: ts.AggregateTypeSum}, nil},
+	} {
+		if _, err := ts.Downsample(samples, c.from, c.to, c.agg); err != ts.ErrInvalidQuery {
+			t.Errorf("%s: %v", c.name, err)
+		}
+	}
+}
+
+func pointsOf(samples []*ts.Sample) []point {
+	res := make([]point, len(samples))
+	for i, s := range samples {
+		res[i] = point{s.Time.UnixMilli(), s.Value}
+	}
+	return res
+}
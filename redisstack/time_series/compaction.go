@@ -0,0 +1,259 @@
+package redisstack
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+)
+
+type Tier struct {
+	Key            string
+	Aggregator     AggregateType
+	BucketDuration time.Duration
+	AlignTime      time.Duration
+	Retention      time.Duration
+	Labels         [][2]string
+}
+
+type Compaction struct {
+	Key              string
+	Option           *Option
+	Tiers            []*Tier
+	RemoveExtraRules bool
+}
+
+func (c *Compaction) TierKey(tier *Tier) string {
+	if len(tier.Key) > 0 {
+		return tier.Key
+	}
+	return c.Key + ":" + strings.ToLower(aggregateTypeNames[tier.Aggregator]) + ":" + strconv.FormatInt(tier.BucketDuration.Milliseconds(), 10)
+}
+
+func (c *Compaction) Keys() []string {
+	keys := make([]string, 1+len(c.Tiers))
+	keys[0] = c.Key
+	for i, tier := range c.Tiers {
+		keys[i+1] = c.TierKey(tier)
+	}
+	return keys
+}
+
+func (c *Compaction) tierOption(tier *Tier) *Option {
+	retention := tier.Retention
+	opt := &Option{Retention: &retention}
+	var labels [][2]string
+	if c.Option != nil {
+		opt.Uncompressed, opt.ChunkSize, opt.DupPolicy = c.Option.Uncompressed, c.Option.ChunkSize, c.Option.DupPolicy
+		labels = c.Option.Labels
+	}
+	if labels != nil || tier.Labels != nil {
+		opt.Labels = mergeLabels(labels, tier.Labels)
+	}
+	return opt
+}
+
+func mergeLabels(base [][2]string, overrides [][2]string) [][2]string {
+	m := make(map[string]string, len(base)+len(overrides))
+	for _, label := range base {
+		m[label[0]] = label[1]
+	}
+	for _, label := range overrides {
+		m[label[0]] = label[1]
+	}
+	labels := make([][2]string, 0, len(m))
+	for k, v := range m {
+		labels = append(labels, [2]string{k, v})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i][0] < labels[j][0] })
+	return labels
+}
+
+func labelsEqual(a [][2]string, b [][2]string) bool {
+	a, b = mergeLabels(nil, a), mergeLabels(nil, b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type DriftKind byte
+
+const (
+	DriftKindMissingSeries = DriftKind(iota)
+	DriftKindRetention
+	DriftKindLabels
+	DriftKindMissingRule
+	DriftKindRule
+	DriftKindForeignRule
+	DriftKindExtraRule
+)
+
+var driftKindNames = map[DriftKind]string{
+	DriftKindMissingSeries: "missing series",
+	DriftKindRetention:     "retention mismatch",
+	DriftKindLabels:        "labels mismatch",
+	DriftKindMissingRule:   "missing rule",
+	DriftKindRule:          "rule mismatch",
+	DriftKindForeignRule:   "rule from foreign source",
+	DriftKindExtraRule:     "extra rule",
+}
+
+func (k DriftKind) String() string {
+	return driftKindNames[k]
+}
+
+type Drift struct {
+	Kind     DriftKind
+	Key      string
+	Expected string
+	Actual   string
+}
+
+func (d *Drift) String() string {
+	s, sep := d.Kind.String()+" on "+d.Key, ": "
+	if len(d.Expected) > 0 {
+		s, sep = s+sep+"expected "+d.Expected, ", "
+	}
+	if len(d.Actual) > 0 {
+		s += sep + "got " + d.Actual
+	}
+	return s
+}
+
+func formatRule(aggregator AggregateType, bucketDuration time.Duration, alignTime time.Duration) string {
+	return aggregateTypeNames[aggregator] + " " + strconv.FormatInt(bucketDuration.Milliseconds(), 10) + " " + strconv.FormatInt(alignTime.Milliseconds(), 10)
+}
+
+func formatLabels(labels [][2]string) string {
+	labels = mergeLabels(nil, labels)
+	ss := make([]string, len(labels))
+	for i, label := range labels {
+		ss[i] = label[0] + "=" + label[1]
+	}
+	return strings.Join(ss, ",")
+}
+
+func info(ctx context.Context, red redis.UniversalClient, key string) (*Info, error) {
+	cmd := red.Do(ctx, InfoArgs(key, false)...)
+	if err := redisstack.ClassifyError(cmd.Err()); errors.Is(err, redisstack.ErrKeyNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return InfoResult(cmd.Val())
+}
+
+type reconciler struct {
+	red    redis.UniversalClient
+	apply  bool
+	drifts []*Drift
+}
+
+func (r *reconciler) do(ctx context.Context, args []any) error {
+	if !r.apply {
+		return nil
+	}
+	return redisstack.ClassifyError(r.red.Do(ctx, args...).Err())
+}
+
+func (r *reconciler) series(ctx context.Context, key string, option *Option) (*Info, error) {
+	inf, err := info(ctx, r.red, key)
+	if err != nil {
+		return nil, err
+	} else if inf == nil {
+		r.drifts = append(r.drifts, &Drift{Kind: DriftKindMissingSeries, Key: key})
+		return nil, r.do(ctx, CreateArgs(key, option))
+	}
+	alter := &Option{}
+	if option != nil && option.Retention != nil && *option.Retention != inf.Retention {
+		r.drifts = append(r.drifts, &Drift{DriftKindRetention, key, option.Retention.String(), inf.Retention.String()})
+		alter.Retention = option.Retention
+	}
+	if option != nil && option.Labels != nil && !labelsEqual(option.Labels, inf.Labels) {
+		r.drifts = append(r.drifts, &Drift{DriftKindLabels, key, formatLabels(option.Labels), formatLabels(inf.Labels)})
+		alter.Labels = option.Labels
+	}
+	if alter.Retention != nil || alter.Labels != nil {
+		return inf, r.do(ctx, AlterArgs(key, alter))
+	}
+	return inf, nil
+}
+
+func (c *Compaction) reconcile(ctx context.Context, red redis.UniversalClient, apply bool) ([]*Drift, error) {
+	r := &reconciler{red: red, apply: apply}
+	src, err := r.series(ctx, c.Key, c.Option)
+	if err != nil {
+		return r.drifts, err
+	}
+	rules := map[string]*Rule{}
+	if src != nil {
+		for _, rule := range src.Rules {
+			rules[rule.DestKey] = rule
+		}
+	}
+	declared := make(map[string]struct{}, len(c.Tiers))
+	for _, tier := range c.Tiers {
+		key := c.TierKey(tier)
+		declared[key] = struct{}{}
+		dest, err := r.series(ctx, key, c.tierOption(tier))
+		if err != nil {
+			return r.drifts, err
+		}
+		expected := formatRule(tier.Aggregator, tier.BucketDuration, tier.AlignTime)
+		if rule, ok := rules[key]; ok {
+			actual := formatRule(rule.Aggregator, rule.BucketDuration, rule.AlignTime)
+			if actual == expected {
+				continue
+			}
+			r.drifts = append(r.drifts, &Drift{DriftKindRule, key, expected, actual})
+			if err = r.do(ctx, DeleteRuleArgs(c.Key, key)); err != nil {
+				return r.drifts, err
+			}
+		} else if dest != nil && len(dest.SourceKey) > 0 && dest.SourceKey != c.Key {
+			r.drifts = append(r.drifts, &Drift{DriftKindForeignRule, key, c.Key, dest.SourceKey})
+			if err = r.do(ctx, DeleteRuleArgs(dest.SourceKey, key)); err != nil {
+				return r.drifts, err
+			}
+		} else {
+			r.drifts = append(r.drifts, &Drift{Kind: DriftKindMissingRule, Key: key, Expected: expected})
+		}
+		alignTime := tier.AlignTime
+		if err = r.do(ctx, CreateRuleArgs(c.Key, key, tier.Aggregator, tier.BucketDuration, &alignTime)); err != nil {
+			return r.drifts, err
+		}
+	}
+	if src != nil {
+		for _, rule := range src.Rules {
+			if _, ok := declared[rule.DestKey]; ok {
+				continue
+			}
+			r.drifts = append(r.drifts, &Drift{Kind: DriftKindExtraRule, Key: rule.DestKey,
+				Actual: formatRule(rule.Aggregator, rule.BucketDuration, rule.AlignTime)})
+			if c.RemoveExtraRules {
+				if err = r.do(ctx, DeleteRuleArgs(c.Key, rule.DestKey)); err != nil {
+					return r.drifts, err
+				}
+			}
+		}
+	}
+	return r.drifts, nil
+}
+
+func (c *Compaction) Diff(ctx context.Context, red redis.UniversalClient) ([]*Drift, error) {
+	return c.reconcile(ctx, red, false)
+}
+
+func (c *Compaction) Reconcile(ctx context.Context, red redis.UniversalClient) ([]*Drift, error) {
+	return c.reconcile(ctx, red, true)
+}
@@ -0,0 +1,175 @@
+package redisstack_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+	ts "github.com/ldeng7/go-redis-stack/redisstack/time_series"
+)
+
+func driftKinds(drifts []*ts.Drift) []string {
+	res := make([]string, len(drifts))
+	for i, d := range drifts {
+		res[i] = d.Kind.String() + " " + d.Key
+	}
+	return res
+}
+
+func seriesInfo(t *testing.T, red *redis.Client, key string) *ts.Info {
+	t.Helper()
+	inf, err := ts.InfoResult(red.Do(context.Background(), ts.InfoArgs(key, false)...).Val())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inf
+}
+
+func newCompaction() *ts.Compaction {
+	retention := time.Hour
+	return &ts.Compaction{
+		Key:    "raw",
+		Option: &ts.Option{Retention: &retention},
+		Tiers: []*ts.Tier{
+			{Aggregator: ts.AggregateTypeAvg, BucketDuration: time.Minute, Retention: 24 * time.Hour},
+			{Key: "raw:max", Aggregator: ts.AggregateTypeMax, BucketDuration: time.Hour, Retention: 0, Labels: [][2]string{{"tier", "max"}}},
+		},
+	}
+}
+
+func TestCompactionMissingSeries(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		c := newCompaction()
+		exp := []string{"missing series raw", "missing series raw:avg:60000", "missing rule raw:avg:60000", "missing series raw:max", "missing rule raw:max"}
+		if drifts, err := c.Diff(ctx, red); err != nil || !reflect.DeepEqual(driftKinds(drifts), exp) {
+			t.Fatalf("diff: %v %v", driftKinds(drifts), err)
+		} else if n, _ := red.Exists(ctx, "raw").Result(); n != 0 {
+			t.Fatal("diff created a series")
+		}
+		if drifts, err := c.Reconcile(ctx, red); err != nil || !reflect.DeepEqual(driftKinds(drifts), exp) {
+			t.Fatalf("reconcile: %v %v", driftKinds(drifts), err)
+		}
+		if drifts, err := c.Diff(ctx, red); err != nil || len(drifts) != 0 {
+			t.Fatalf("after reconcile: %v %v", driftKinds(drifts), err)
+		}
+		inf := seriesInfo(t, red, "raw")
+		if inf.Retention != time.Hour || len(inf.Rules) != 2 {
+			t.Errorf("raw: %+v", inf)
+		}
+		if inf = seriesInfo(t, red, "raw:max"); inf.SourceKey != "raw" || !reflect.DeepEqual(inf.Labels, [][2]string{{"tier", "max"}}) {
+			t.Errorf("raw:max: %+v", inf)
+		}
+	})
+}
+
+func TestCompactionChanged(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		c := newCompaction()
+		if _, err := c.Reconcile(ctx, red); err != nil {
+			t.Fatal(err)
+		}
+		c.Tiers[0].Retention = 48 * time.Hour
+		c.Tiers[1].BucketDuration = 2 * time.Hour
+		exp := []string{"retention mismatch raw:avg:60000", "rule mismatch raw:max"}
+		drifts, err := c.Reconcile(ctx, red)
+		if err != nil || !reflect.DeepEqual(driftKinds(drifts), exp) {
+			t.Fatalf("reconcile: %v %v", driftKinds(drifts), err)
+		} else if drifts[1].Expected != "MAX 7200000 0" || drifts[1].Actual != "MAX 3600000 0" {
+			t.Errorf("rule drift: %s", drifts[1])
+		}
+		if drifts, err := c.Diff(ctx, red); err != nil || len(drifts) != 0 {
+			t.Fatalf("after reconcile: %v %v", driftKinds(drifts), err)
+		}
+		if inf := seriesInfo(t, red, "raw:avg:60000"); inf.Retention != 48*time.Hour {
+			t.Errorf("retention: %v", inf.Retention)
+		}
+	})
+}
+
+func TestCompactionUndeclaredLabels(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		labels := [][2]string{{"team", "a"}}
+		if err := red.Do(ctx, ts.CreateArgs("raw:avg:60000", &ts.Option{Labels: labels})...).Err(); err != nil {
+			t.Fatal(err)
+		}
+		c := newCompaction()
+		c.Option = nil
+		exp := []string{"missing series raw", "retention mismatch raw:avg:60000", "missing rule raw:avg:60000", "missing series raw:max", "missing rule raw:max"}
+		if drifts, err := c.Reconcile(ctx, red); err != nil || !reflect.DeepEqual(driftKinds(drifts), exp) {
+			t.Fatalf("reconcile: %v %v", driftKinds(drifts), err)
+		}
+		if inf := seriesInfo(t, red, "raw:avg:60000"); !reflect.DeepEqual(inf.Labels, labels) {
+			t.Errorf("labels were overwritten: %v", inf.Labels)
+		}
+	})
+}
+
+func TestCompactionForeignRule(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		c := newCompaction()
+		if _, err := c.Reconcile(ctx, red); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]any{
+			ts.DeleteRuleArgs("raw", "raw:max"),
+			ts.CreateArgs("other", nil),
+			ts.CreateRuleArgs("other", "raw:max", ts.AggregateTypeMin, time.Minute, nil),
+		} {
+			if err := red.Do(ctx, args...).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		drifts, err := c.Reconcile(ctx, red)
+		if err != nil || !reflect.DeepEqual(driftKinds(drifts), []string{"rule from foreign source raw:max"}) {
+			t.Fatalf("reconcile: %v %v", driftKinds(drifts), err)
+		} else if drifts[0].Actual != "other" {
+			t.Errorf("foreign source: %s", drifts[0])
+		}
+		if inf := seriesInfo(t, red, "raw:max"); inf.SourceKey != "raw" {
+			t.Errorf("source: %q", inf.SourceKey)
+		}
+		if inf := seriesInfo(t, red, "other"); len(inf.Rules) != 0 {
+			t.Errorf("foreign rule kept: %v", inf.Rules)
+		}
+	})
+}
+
+func TestCompactionExtraRule(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		c := newCompaction()
+		if _, err := c.Reconcile(ctx, red); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]any{
+			ts.CreateArgs("extra", nil),
+			ts.CreateRuleArgs("raw", "extra", ts.AggregateTypeSum, time.Minute, nil),
+		} {
+			if err := red.Do(ctx, args...).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		exp := []string{"extra rule extra"}
+		if drifts, err := c.Reconcile(ctx, red); err != nil || !reflect.DeepEqual(driftKinds(drifts), exp) {
+			t.Fatalf("reconcile: %v %v", driftKinds(drifts), err)
+		} else if inf := seriesInfo(t, red, "raw"); len(inf.Rules) != 3 {
+			t.Fatalf("extra rule removed without RemoveExtraRules: %v", inf.Rules)
+		}
+		c.RemoveExtraRules = true
+		if drifts, err := c.Reconcile(ctx, red); err != nil || !reflect.DeepEqual(driftKinds(drifts), exp) {
+			t.Fatalf("reconcile: %v %v", driftKinds(drifts), err)
+		} else if inf := seriesInfo(t, red, "raw"); len(inf.Rules) != 2 {
+			t.Errorf("extra rule kept: %v", inf.Rules)
+		}
+		if drifts, err := c.Diff(ctx, red); err != nil || len(drifts) != 0 {
+			t.Errorf("after reconcile: %v %v", driftKinds(drifts), err)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package redisstack
+
+import "strings"
+
+type filterOp byte
+
+const (
+	filterOpEq = filterOp(iota)
+	filterOpNotEq
+	filterOpIn
+	filterOpNotIn
+)
+
+type Filter struct {
+	label  string
+	op     filterOp
+	values []string
+}
+
+func Eq(label string, value string) Filter {
+	return Filter{label, filterOpEq, []string{value}}
+}
+
+func NotEq(label string, value string) Filter {
+	return Filter{label, filterOpNotEq, []string{value}}
+}
+
+func In(label string, values ...string) Filter {
+	return Filter{label, filterOpIn, values}
+}
+
+func NotIn(label string, values ...string) Filter {
+	return Filter{label, filterOpNotIn, values}
+}
+
+func Exists(label string) Filter {
+	return Filter{label, filterOpNotEq, []string{""}}
+}
+
+func NotExists(label string) Filter {
+	return Filter{label, filterOpEq, []string{""}}
+}
+
+const (
+	filterLabelSpecialChars = "=!(),\" \t\r\n"
+	filterValueSpecialChars = "(),\""
+)
+
+func ValidFilterValue(value string) bool {
+	return !strings.ContainsAny(value, filterValueSpecialChars)
+}
+
+func (f Filter) positive() bool {
+	switch f.op {
+	case filterOpEq:
+		return len(f.values[0]) > 0
+	case filterOpIn:
+		return true
+	}
+	return false
+}
+
+func (f Filter) validate() error {
+	if len(f.label) == 0 || strings.ContainsAny(f.label, filterLabelSpecialChars) {
+		return ErrInvalidFilter
+	} else if (f.op == filterOpIn || f.op == filterOpNotIn) && len(f.values) == 0 {
+		return ErrInvalidFilter
+	}
+	for _, value := range f.values {
+		if !ValidFilterValue(value) {
+			return ErrInvalidFilter
+		}
+	}
+	return nil
+}
+
+func (f Filter) String() string {
+	sb := &strings.Builder{}
+	sb.WriteString(f.label)
+	if f.op == filterOpNotEq || f.op == filterOpNotIn {
+		sb.WriteByte('!')
+	}
+	sb.WriteByte('=')
+	switch f.op {
+	case filterOpEq, filterOpNotEq:
+		sb.WriteString(f.values[0])
+	default:
+		sb.WriteByte('(')
+		for i, value := range f.values {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(value)
+		}
+		sb.WriteByte(')')
+	}
+	return sb.String()
+}
+
+func Filters(filters ...Filter) ([]string, error) {
+	res := make([]string, len(filters))
+	positive := false
+	for i, f := range filters {
+		if err := f.validate(); err != nil {
+			return nil, err
+		}
+		res[i], positive = f.String(), positive || f.positive()
+	}
+	if !positive {
+		return nil, ErrNoPositiveFilter
+	}
+	return res, nil
+}
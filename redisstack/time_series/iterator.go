@@ -0,0 +1,287 @@
+package redisstack
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/go-redis/redis/v9"
+)
+
+type pager struct {
+	q      MultiQuery
+	rev    bool
+	count  int
+	limit  int
+	bucket int64
+	offset int64
+	done   bool
+}
+
+func newPager(q *MultiQuery, rev bool, pageSize int) *pager {
+	if pageSize <= 0 {
+		pageSize = 1000
+	}
+	p := &pager{q: *q, rev: rev, count: pageSize, bucket: 1}
+	if q.Count != nil && *q.Count > 0 {
+		if p.limit = *q.Count; p.limit < p.count {
+			p.count = p.limit
+		}
+	}
+	p.q.Count = &p.count
+	p.q.FromTime, p.q.ToTime = q.FromTime.or(TimestampKindEarliest), q.ToTime.or(TimestampKindLatest)
+	if q.Aggregation != nil {
+		agg := *q.Aggregation
+		switch agg.Align {
+		case "-", "start":
+			if !rev {
+				agg.Align = strconv.FormatInt(p.q.FromTime.UnixMilli(), 10)
+			}
+		case "+", "end":
+			if rev {
+				agg.Align = strconv.FormatInt(p.q.ToTime.UnixMilli(), 10)
+			}
+		}
+		p.q.Aggregation = &agg
+		if p.bucket = agg.BucketDuration.Milliseconds(); p.bucket <= 0 {
+			p.bucket = 1
+		}
+		switch agg.BucketTimestamp {
+		case "+", "end", "high":
+			p.offset = p.bucket
+		case "~", "mid":
+			p.offset = p.bucket / 2
+		}
+	}
+	return p
+}
+
+func (p *pager) advance(last int64, n int) {
+	if n < p.count {
+		p.done = true
+		return
+	}
+	start := last - p.offset
+	if !p.rev {
+		if start > math.MaxInt64-p.bucket {
+			p.done = true
+			return
+		}
+		from := start + p.bucket
+		p.q.FromTime = AtMilli(from)
+		p.done = from > p.q.ToTime.UnixMilli()
+	} else {
+		to := start - 1
+		p.q.ToTime = AtMilli(to)
+		p.done = to < p.q.FromTime.UnixMilli()
+	}
+}
+
+func (p *pager) remaining(n int) int {
+	if p.limit > 0 {
+		return p.limit - n
+	}
+	return math.MaxInt
+}
+
+func (p *pager) seen(t int64, last *int64) bool {
+	if last == nil {
+		return false
+	} else if p.rev {
+		return t >= *last
+	}
+	return t <= *last
+}
+
+type RangeIterator struct {
+	red    redis.UniversalClient
+	key    string
+	p      *pager
+	buf    []*Sample
+	sample *Sample
+	last   *int64
+	n      int
+	err    error
+}
+
+func newRangeIterator(red redis.UniversalClient, key string, q *MultiQuery, pageSize int, rev bool) *RangeIterator {
+	if err := q.ValidateRange(); err != nil {
+		return &RangeIterator{err: err}
+	}
+	return &RangeIterator{red: red, key: key, p: newPager(q, rev, pageSize)}
+}
+
+func NewRangeIterator(red redis.UniversalClient, key string, q *MultiQuery, pageSize int) *RangeIterator {
+	return newRangeIterator(red, key, q, pageSize, false)
+}
+
+func NewRevRangeIterator(red redis.UniversalClient, key string, q *MultiQuery, pageSize int) *RangeIterator {
+	return newRangeIterator(red, key, q, pageSize, true)
+}
+
+func (it *RangeIterator) fetch(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	args := RangeArgs(it.key, &it.p.q)
+	if it.p.rev {
+		args[0] = "TS.REVRANGE"
+	}
+	cmd := it.red.Do(ctx, args...)
+	if err := cmd.Err(); err != nil {
+		return err
+	}
+	samples, err := RangeResult(cmd.Val())
+	if err != nil {
+		return err
+	}
+	if n := len(samples); n > 0 {
+		it.p.advance(samples[n-1].Time.UnixMilli(), n)
+	} else {
+		it.p.done = true
+	}
+	for _, sample := range samples {
+		if t := sample.Time.UnixMilli(); !it.p.seen(t, it.last) {
+			it.buf = append(it.buf, sample)
+		}
+	}
+	return nil
+}
+
+func (it *RangeIterator) Next(ctx context.Context) bool {
+	if it.err != nil || it.p.remaining(it.n) <= 0 {
+		return false
+	}
+	for len(it.buf) == 0 {
+		if it.err != nil || it.p.done {
+			return false
+		} else if it.err = it.fetch(ctx); it.err != nil {
+			return false
+		}
+	}
+	it.sample, it.buf = it.buf[0], it.buf[1:]
+	t := it.sample.Time.UnixMilli()
+	it.last, it.n = &t, it.n+1
+	return true
+}
+
+func (it *RangeIterator) Sample() *Sample {
+	return it.sample
+}
+
+func (it *RangeIterator) Err() error {
+	return it.err
+}
+
+// MRangeIterator yields one MultiSample per key and page, so a key spanning
+// several pages is returned several times with consecutive samples. Count
+// limits the samples returned per key.
+type MRangeIterator struct {
+	red    redis.UniversalClient
+	p      *pager
+	keys   []string
+	buf    map[string]*MultiSample
+	key    string
+	series *MultiSample
+	last   map[string]int64
+	n      map[string]int
+	err    error
+}
+
+func newMRangeIterator(red redis.UniversalClient, q *MultiQuery, pageSize int, rev bool) *MRangeIterator {
+	if err := q.Validate(); err != nil {
+		return &MRangeIterator{err: err}
+	}
+	return &MRangeIterator{red: red, p: newPager(q, rev, pageSize), last: map[string]int64{}, n: map[string]int{}}
+}
+
+func NewMRangeIterator(red redis.UniversalClient, q *MultiQuery, pageSize int) *MRangeIterator {
+	return newMRangeIterator(red, q, pageSize, false)
+}
+
+func NewMRevRangeIterator(red redis.UniversalClient, q *MultiQuery, pageSize int) *MRangeIterator {
+	return newMRangeIterator(red, q, pageSize, true)
+}
+
+func (it *MRangeIterator) fetch(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	args := MRangeArgs(&it.p.q)
+	if it.p.rev {
+		args[0] = "TS.MREVRANGE"
+	}
+	cmd := it.red.Do(ctx, args...)
+	if err := cmd.Err(); err != nil {
+		return err
+	}
+	res, err := MRangeResult(cmd.Val())
+	if err != nil {
+		return err
+	}
+
+	var next *int64
+	for key, series := range res {
+		samples := series.Samples
+		var last *int64
+		if t, ok := it.last[key]; ok {
+			last = &t
+		}
+		remaining := it.p.remaining(it.n[key])
+		series.Samples = make([]*Sample, 0, len(samples))
+		for _, sample := range samples {
+			if len(series.Samples) >= remaining {
+				break
+			} else if !it.p.seen(sample.Time.UnixMilli(), last) {
+				series.Samples = append(series.Samples, sample)
+			}
+		}
+		if n := len(series.Samples); n > 0 {
+			it.last[key] = series.Samples[n-1].Time.UnixMilli()
+			it.n[key] += n
+			it.keys = append(it.keys, key)
+			it.buf[key] = series
+		}
+		if n := len(samples); n >= it.p.count && it.p.remaining(it.n[key]) > 0 {
+			t := samples[n-1].Time.UnixMilli()
+			if next == nil || (!it.p.rev && t < *next) || (it.p.rev && t > *next) {
+				next = &t
+			}
+		}
+	}
+	sort.Strings(it.keys)
+	if next != nil {
+		it.p.advance(*next, it.p.count)
+	} else {
+		it.p.done = true
+	}
+	return nil
+}
+
+func (it *MRangeIterator) Next(ctx context.Context) bool {
+	for len(it.keys) == 0 {
+		if it.err != nil || it.p.done {
+			return false
+		}
+		it.buf = map[string]*MultiSample{}
+		if it.err = it.fetch(ctx); it.err != nil {
+			return false
+		}
+	}
+	it.key, it.keys = it.keys[0], it.keys[1:]
+	it.series = it.buf[it.key]
+	return true
+}
+
+func (it *MRangeIterator) Key() string {
+	return it.key
+}
+
+func (it *MRangeIterator) Series() *MultiSample {
+	return it.series
+}
+
+func (it *MRangeIterator) Err() error {
+	return it.err
+}
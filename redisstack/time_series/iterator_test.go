@@ -0,0 +1,166 @@
+package redisstack_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+	ts "github.com/ldeng7/go-redis-stack/redisstack/time_series"
+)
+
+func addSamples(t *testing.T, red *redis.Client, key string, label string, from, to int64) {
+	t.Helper()
+	ctx := context.Background()
+	if err := red.Do(ctx, ts.CreateArgs(key, &ts.Option{Labels: [][2]string{{"l", label}}})...).Err(); err != nil {
+		t.Fatal(err)
+	}
+	for mt := from; mt <= to; mt++ {
+		if err := red.Do(ctx, ts.AddArgs(&ts.Sample{Key: key, Time: ts.AtMilli(mt), Value: float64(mt)}, nil)...).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func millis(samples []*ts.Sample) []int64 {
+	res := make([]int64, len(samples))
+	for i, s := range samples {
+		res[i] = s.Time.UnixMilli()
+	}
+	return res
+}
+
+func span(from, to int64) []int64 {
+	res := []int64{}
+	if from <= to {
+		for mt := from; mt <= to; mt++ {
+			res = append(res, mt)
+		}
+	} else {
+		for mt := from; mt >= to; mt-- {
+			res = append(res, mt)
+		}
+	}
+	return res
+}
+
+func TestRangeIterator(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		addSamples(t, red, "a", "x", 1, 25)
+		count := 10
+		for _, c := range []struct {
+			name string
+			rev  bool
+			q    *ts.MultiQuery
+			exp  []int64
+		}{
+			{"All", false, &ts.MultiQuery{}, span(1, 25)},
+			{"Rev", true, &ts.MultiQuery{}, span(25, 1)},
+			{"Window", false, &ts.MultiQuery{FromTime: ts.AtMilli(5), ToTime: ts.AtMilli(17)}, span(5, 17)},
+			{"Count", false, &ts.MultiQuery{Count: &count}, span(1, 10)},
+			{"RevCount", true, &ts.MultiQuery{Count: &count}, span(25, 16)},
+		} {
+			var it *ts.RangeIterator
+			if c.rev {
+				it = ts.NewRevRangeIterator(red, "a", c.q, 4)
+			} else {
+				it = ts.NewRangeIterator(red, "a", c.q, 4)
+			}
+			var samples []*ts.Sample
+			for it.Next(ctx) {
+				samples = append(samples, it.Sample())
+			}
+			if err := it.Err(); err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			} else if got := millis(samples); !reflect.DeepEqual(got, c.exp) {
+				t.Errorf("%s: %v", c.name, got)
+			}
+		}
+	})
+}
+
+func TestRangeIteratorAggregation(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		addSamples(t, red, "a", "x", 1, 25)
+		q := &ts.MultiQuery{Aggregation: &ts.MultiQueryAggregation{Aggregator: ts.AggregateTypeCount, BucketDuration: 5 * time.Millisecond}}
+		it := ts.NewRangeIterator(red, "a", q, 2)
+		var got []int64
+		for it.Next(ctx) {
+			got = append(got, it.Sample().Time.UnixMilli(), int64(it.Sample().Value))
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		exp := []int64{0, 4, 5, 5, 10, 5, 15, 5, 20, 5, 25, 1}
+		if !reflect.DeepEqual(got, exp) {
+			t.Errorf("%v", got)
+		}
+	})
+}
+
+func TestMRangeIterator(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		addSamples(t, red, "a", "x", 1, 10)
+		addSamples(t, red, "b", "x", 4, 6)
+		count := 5
+		for _, c := range []struct {
+			name string
+			rev  bool
+			q    *ts.MultiQuery
+			exp  map[string][]int64
+		}{
+			{"All", false, &ts.MultiQuery{Filters: []string{"l=x"}}, map[string][]int64{"a": span(1, 10), "b": span(4, 6)}},
+			{"Rev", true, &ts.MultiQuery{Filters: []string{"l=x"}}, map[string][]int64{"a": span(10, 1), "b": span(6, 4)}},
+			{"Count", false, &ts.MultiQuery{Filters: []string{"l=x"}, Count: &count}, map[string][]int64{"a": span(1, 5), "b": span(4, 6)}},
+		} {
+			var it *ts.MRangeIterator
+			if c.rev {
+				it = ts.NewMRevRangeIterator(red, c.q, 3)
+			} else {
+				it = ts.NewMRangeIterator(red, c.q, 3)
+			}
+			got := map[string][]int64{}
+			for it.Next(ctx) {
+				got[it.Key()] = append(got[it.Key()], millis(it.Series().Samples)...)
+			}
+			if err := it.Err(); err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			} else if !reflect.DeepEqual(got, c.exp) {
+				t.Errorf("%s: %v", c.name, got)
+			}
+		}
+	})
+}
+
+func TestIteratorValidate(t *testing.T) {
+	ctx := context.Background()
+	zero := 0
+	for _, c := range []struct {
+		name string
+		next func() (bool, error)
+		exp  error
+	}{
+		{"RangeServerTime", func() (bool, error) {
+			it := ts.NewRangeIterator(nil, "a", &ts.MultiQuery{FromTime: ts.ServerTime}, 0)
+			return it.Next(ctx), it.Err()
+		}, ts.ErrInvalidQuery},
+		{"RangeZeroCount", func() (bool, error) {
+			it := ts.NewRangeIterator(nil, "a", &ts.MultiQuery{Count: &zero}, 0)
+			return it.Next(ctx), it.Err()
+		}, ts.ErrInvalidQuery},
+		{"MRangeNoFilter", func() (bool, error) {
+			it := ts.NewMRangeIterator(nil, &ts.MultiQuery{}, 0)
+			return it.Next(ctx), it.Err()
+		}, ts.ErrNoPositiveFilter},
+	} {
+		if ok, err := c.next(); ok || !errors.Is(err, c.exp) {
+			t.Errorf("%s: %v %v", c.name, ok, err)
+		}
+	}
+}
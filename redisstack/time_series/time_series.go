@@ -1,413 +1,936 @@
-package redisstack
-
-import (
-	"strconv"
-	"time"
-
-	"github.com/ldeng7/go-redis-stack/redisstack"
-)
-
-type DupPolicy byte
-
-const (
-	DupPolicyNone = DupPolicy(iota)
-	DupPolicyBlock
-	DupPolicyFirst
-	DupPolicyLast
-	DupPolicyMin
-	DupPolicyMax
-	DupPolicySum
-)
-
-var dupPolicyNames = map[DupPolicy]string{
-	DupPolicyBlock: "BLOCK",
-	DupPolicyFirst: "FIRST",
-	DupPolicyLast:  "LAST",
-	DupPolicyMin:   "MIN",
-	DupPolicyMax:   "MAX",
-	DupPolicySum:   "SUM",
-}
-
-type Option struct {
-	Retention    *time.Duration
-	Uncompressed bool
-	ChunkSize    *int64
-	DupPolicy    DupPolicy
-	Labels       [][2]string
-}
-
-func (opt *Option) appendArgs(args []any, altering bool, dupPolicyTag string) []any {
-	if opt == nil {
-		return args
-	}
-	if opt.Retention != nil {
-		args = append(args, "RETENTION", opt.Retention.Milliseconds())
-	}
-	if opt.Uncompressed {
-		args = append(args, "ENCODING", "UNCOMPRESSED")
-	}
-	if opt.ChunkSize != nil {
-		args = append(args, *opt.ChunkSize)
-	}
-	if opt.DupPolicy != DupPolicyNone {
-		args = append(args, dupPolicyTag, dupPolicyNames[opt.DupPolicy])
-	}
-	if len(opt.Labels) > 0 || (altering && opt.Labels != nil) {
-		args = append(args, "LABELS")
-		for _, label := range opt.Labels {
-			args = append(args, label[0], label[1])
-		}
-	}
-	return args
-}
-
-type AggregateType byte
-
-const (
-	AggregateTypeNone = AggregateType(iota)
-	AggregateTypeAvg
-	AggregateTypeFirst
-	AggregateTypeLast
-	AggregateTypeMin
-	AggregateTypeMax
-	AggregateTypeSum
-	AggregateTypeRange
-	AggregateTypeCount
-	AggregateTypeStdP
-	AggregateTypeStdS
-	AggregateTypeVarP
-	AggregateTypeVarS
-	AggregateTypeTWA
-)
-
-var aggregateTypeNames = map[AggregateType]string{
-	AggregateTypeAvg:   "AVG",
-	AggregateTypeFirst: "FIRST",
-	AggregateTypeLast:  "LAST",
-	AggregateTypeMin:   "MIN",
-	AggregateTypeMax:   "MAX",
-	AggregateTypeSum:   "SUM",
-	AggregateTypeRange: "RANGE",
-	AggregateTypeCount: "COUNT",
-	AggregateTypeStdP:  "STD.P",
-	AggregateTypeStdS:  "STD.S",
-	AggregateTypeVarP:  "VAR.P",
-	AggregateTypeVarS:  "VAR.S",
-	AggregateTypeTWA:   "TWA",
-}
-
-type Sample struct {
-	Key   string
-	Time  *time.Time
-	Value float64
-}
-
-type MultiSample struct {
-	Labels  [][2]string
-	Samples []*Sample
-}
-
-type MultiQueryAggregation struct {
-	Align           string
-	Aggregator      AggregateType
-	BucketDuration  time.Duration
-	BucketTimestamp string
-	Empty           bool
-}
-
-func (agg *MultiQueryAggregation) appendArgs(args []any) []any {
-	if agg == nil {
-		return args
-	}
-	if len(agg.Align) > 0 {
-		args = append(args, "ALIGN", agg.Align)
-	}
-	args = append(args, "AGGREGATION", aggregateTypeNames[agg.Aggregator], agg.BucketDuration.Milliseconds())
-	if len(agg.BucketTimestamp) > 0 {
-		args = append(args, "BUCKETTIMESTAMP", agg.BucketTimestamp)
-	}
-	if agg.Empty {
-		args = append(args, "EMPTY")
-	}
-	return args
-}
-
-type MultiQuery struct {
-	FromTime         *time.Time
-	ToTime           *time.Time
-	Latest           bool
-	FiltersByTime    []*time.Time
-	FilterByValueMin *float64
-	FilterByValueMax *float64
-	WithLabels       bool
-	SelectedLabels   []string
-	Count            *int
-	Aggregation      *MultiQueryAggregation
-	Filters          []string
-	GroupByLabel     string
-	Reducer          AggregateType
-}
-
-func (q *MultiQuery) appendFiltersByTime(args []any) []any {
-	if len(q.FiltersByTime) > 0 {
-		args = append(args, "FILTER_BY_TS")
-		for _, t := range q.FiltersByTime {
-			args = append(args, t.UnixMilli())
-		}
-	}
-	return args
-}
-
-func (q *MultiQuery) appendFilterByValue(args []any) []any {
-	if q.FilterByValueMin != nil && q.FilterByValueMax != nil {
-		args = append(args, "FILTER_BY_VALUE", *q.FilterByValueMin, *q.FilterByValueMax)
-	}
-	return args
-}
-
-func (q *MultiQuery) appendLabelSelection(args []any) []any {
-	if q.WithLabels {
-		args = append(args, "WITHLABELS")
-	} else if len(q.SelectedLabels) > 0 {
-		args = append(args, "SELECTED_LABELS")
-		for _, label := range q.SelectedLabels {
-			args = append(args, label)
-		}
-	}
-	return args
-}
-
-func (q *MultiQuery) appendFilters(args []any) []any {
-	args = append(args, "FILTER")
-	for _, filter := range q.Filters {
-		args = append(args, filter)
-	}
-	return args
-}
-
-func (q *MultiQuery) appendGroupByLabel(args []any) []any {
-	if len(q.GroupByLabel) > 0 && q.Reducer != AggregateTypeNone {
-		args = append(args, "GROUPBY", q.GroupByLabel, "REDUCE", aggregateTypeNames[q.Reducer])
-	}
-	return args
-}
-
-func AddArgs(sample *Sample, option *Option) []any {
-	args := make([]any, 0, 13+len(option.Labels)*2)
-	args = append(args, "TS.ADD", sample.Key, sample.Time.UnixMilli(), sample.Value)
-	return option.appendArgs(args, false, "ON_DUPLICATE")
-}
-
-func AlterArgs(key string, option *Option) []any {
-	args := make([]any, 0, 11+len(option.Labels)*2)
-	args = append(args, "TS.ALTER", key)
-	return option.appendArgs(args, true, "DUPLICATE_POLICY")
-}
-
-func CreateArgs(key string, option *Option) []any {
-	args := make([]any, 0, 11+len(option.Labels)*2)
-	args = append(args, "TS.CREATE", key)
-	return option.appendArgs(args, false, "DUPLICATE_POLICY")
-}
-
-func CreateRuleArgs(srcKey string, destKey string, aggregateType AggregateType, alignTime *time.Duration) []any {
-	args := make([]any, 0, 7)
-	args = append(args, "TS.CREATERULE", srcKey, destKey, "AGGREGATION", aggregateTypeNames[aggregateType])
-	if alignTime != nil {
-		args = append(args, alignTime.Milliseconds())
-	}
-	return args
-}
-
-//TODO: TS.DECRBY
-
-func DelArgs(key string, fromTime *time.Time, toTime *time.Time) []any {
-	return []any{"TS.DEL", key, fromTime.UnixMilli(), toTime.UnixMilli()}
-}
-
-func DelResult(val any) (int64, error) {
-	return redisstack.ParseScalar[int64](val)
-}
-
-func DeleteRuleArgs(srcKey string, destKey string) []any {
-	return []any{"TS.DELETERULE", srcKey, destKey}
-}
-
-func GetArgs(key string) []any {
-	return []any{"TS.GET", key}
-}
-
-func GetResult(val any) (*Sample, error) {
-	arr, err := redisstack.ParseArray(val, 2)
-	if err != nil {
-		return nil, err
-	}
-	mt, _ := arr[0].(int64)
-	t := time.UnixMilli(mt)
-	v, _ := arr[1].(string)
-	f, _ := strconv.ParseFloat(v, 64)
-	s := &Sample{
-		Time:  &t,
-		Value: f,
-	}
-	return s, nil
-}
-
-//TODO: TS.INCRBY
-//TODO: TS.INFO
-
-func MAddArgs(samples []*Sample) []any {
-	args := make([]any, 1+len(samples)*3)
-	args[0] = "TS.MADD"
-	for i, sample := range samples {
-		args[i*3+1], args[i*3+2], args[i*3+3] = sample.Key, sample.Time.UnixMilli(), sample.Value
-	}
-	return args
-}
-
-func MAddResult(val any) ([]*time.Time, error) {
-	return redisstack.ParseToMappedArray(val, 0, func(e any) (*time.Time, error) {
-		if e1, ok := e.(int64); ok {
-			t := time.UnixMilli(e1)
-			return &t, nil
-		}
-		return nil, nil
-	})
-}
-
-func MGetArgs(q *MultiQuery) []any {
-	args := make([]any, 0, 4+len(q.SelectedLabels)+len(q.Filters))
-	args = append(args, "TS.MGET")
-	if q.Latest {
-		args = append(args, "LATEST")
-	}
-	args = q.appendLabelSelection(args)
-	args = q.appendFilters(args)
-	return args
-}
-
-func MGetResult(val any) (map[string]*MultiSample, error) {
-	arr, err := redisstack.ParseArray(val, 0)
-	if err != nil {
-		return nil, err
-	}
-	res := make(map[string]*MultiSample, len(arr))
-	for _, e := range arr {
-		arr1, err := redisstack.ParseArray(e, 3)
-		if err != nil {
-			return nil, err
-		}
-		key, _ := arr1[0].(string)
-
-		labels, err := redisstack.ParseStringPairArray(arr1[1], 0)
-		if err != nil {
-			return nil, err
-		}
-		sample, err := GetResult(arr1[2])
-		if err != nil {
-			return nil, err
-		}
-		res[key] = &MultiSample{Labels: labels, Samples: []*Sample{sample}}
-	}
-	return res, nil
-}
-
-func MRangeArgs(q *MultiQuery) []any {
-	args := make([]any, 0, 24+len(q.FiltersByTime)+len(q.SelectedLabels)+len(q.Filters))
-	args = append(args, "TS.MRANGE")
-	args = append(args, q.FromTime.UnixMilli(), q.ToTime.UnixMilli())
-	if q.Latest {
-		args = append(args, "LATEST")
-	}
-	args = q.appendFiltersByTime(args)
-	args = q.appendFilterByValue(args)
-	args = q.appendLabelSelection(args)
-	if q.Count != nil {
-		args = append(args, "COUNT", *q.Count)
-	}
-	args = q.Aggregation.appendArgs(args)
-	args = q.appendFilters(args)
-	args = q.appendGroupByLabel(args)
-	return args
-}
-
-func MRangeResult(val any) (map[string]*MultiSample, error) {
-	arr, err := redisstack.ParseArray(val, 0)
-	if err != nil {
-		return nil, err
-	}
-	res := make(map[string]*MultiSample, len(arr))
-	for _, e := range arr {
-		arr1, err := redisstack.ParseArray(e, 3)
-		if err != nil {
-			return nil, err
-		}
-		key, _ := arr1[0].(string)
-
-		labels, err := redisstack.ParseStringPairArray(arr1[1], 0)
-		if err != nil {
-			return nil, err
-		}
-
-		samples, err := redisstack.ParseToMappedArray(arr1[2], 0, GetResult)
-		if err != nil {
-			return nil, err
-		}
-
-		res[key] = &MultiSample{Labels: labels, Samples: samples}
-	}
-	return res, nil
-}
-
-func MRevRangeArgs(q *MultiQuery) []any {
-	args := MRangeArgs(q)
-	args[0] = "TS.MREVRANGE"
-	return args
-}
-
-func MRevRangeResult(val any) (map[string]*MultiSample, error) {
-	return MRangeResult(val)
-}
-
-func QueryIndexArgs(filters []string) []any {
-	args := make([]any, 1+len(filters))
-	args[0] = "TS.QUERYINDEX"
-	for i, filter := range filters {
-		args[i+1] = filter
-	}
-	return args
-}
-
-func QueryIndexResult(val any) ([]string, error) {
-	return redisstack.ParseScalarArray[string](val, 0)
-}
-
-func RangeArgs(key string, q *MultiQuery) []any {
-	args := make([]any, 0, 19+len(q.FiltersByTime))
-	args = append(args, "TS.RANGE", key)
-	args = append(args, q.FromTime.UnixMilli(), q.ToTime.UnixMilli())
-	if q.Latest {
-		args = append(args, "LATEST")
-	}
-	args = q.appendFiltersByTime(args)
-	args = q.appendFilterByValue(args)
-	if q.Count != nil {
-		args = append(args, "COUNT", *q.Count)
-	}
-	args = q.Aggregation.appendArgs(args)
-	return args
-}
-
-func RangeResult(val any) ([]*Sample, error) {
-	return redisstack.ParseToMappedArray(val, 0, GetResult)
-}
-
-func RevRangeArgs(key string, q *MultiQuery) []any {
-	args := RangeArgs(key, q)
-	args[0] = "TS.REVRANGE"
-	return args
-}
-
-func RevRangeResult(val any) ([]*Sample, error) {
-	return RangeResult(val)
-}
+package redisstack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+)
+
+var (
+	ErrInvalidFilter    = errors.New("invalid filter")
+	ErrNoPositiveFilter = errors.New("at least one filter of the form label=value or label=(values) is required")
+	ErrInvalidQuery     = errors.New("invalid query")
+	ErrInvalidReducer   = errors.New("reducer is not supported by GROUPBY")
+)
+
+type DupPolicy byte
+
+const (
+	DupPolicyNone = DupPolicy(iota)
+	DupPolicyBlock
+	DupPolicyFirst
+	DupPolicyLast
+	DupPolicyMin
+	DupPolicyMax
+	DupPolicySum
+)
+
+var dupPolicyNames = map[DupPolicy]string{
+	DupPolicyBlock: "BLOCK",
+	DupPolicyFirst: "FIRST",
+	DupPolicyLast:  "LAST",
+	DupPolicyMin:   "MIN",
+	DupPolicyMax:   "MAX",
+	DupPolicySum:   "SUM",
+}
+
+type Option struct {
+	Retention    *time.Duration
+	Uncompressed bool
+	ChunkSize    *int64
+	DupPolicy    DupPolicy
+	Labels       [][2]string
+}
+
+func (opt *Option) argsLen() int {
+	if opt == nil {
+		return 0
+	}
+	return 11 + len(opt.Labels)*2
+}
+
+func (opt *Option) appendArgs(args []any, altering bool, dupPolicyTag string) []any {
+	if opt == nil {
+		return args
+	}
+	if opt.Retention != nil {
+		args = append(args, "RETENTION", opt.Retention.Milliseconds())
+	}
+	if opt.Uncompressed {
+		args = append(args, "ENCODING", "UNCOMPRESSED")
+	}
+	if opt.ChunkSize != nil {
+		args = append(args, "CHUNK_SIZE", *opt.ChunkSize)
+	}
+	if opt.DupPolicy != DupPolicyNone {
+		args = append(args, dupPolicyTag, dupPolicyNames[opt.DupPolicy])
+	}
+	if len(opt.Labels) > 0 || (altering && opt.Labels != nil) {
+		args = append(args, "LABELS")
+		for _, label := range opt.Labels {
+			args = append(args, label[0], label[1])
+		}
+	}
+	return args
+}
+
+func parseDupPolicy(s string) DupPolicy {
+	for p, name := range dupPolicyNames {
+		if strings.EqualFold(s, name) {
+			return p
+		}
+	}
+	return DupPolicyNone
+}
+
+type AggregateType byte
+
+const (
+	AggregateTypeNone = AggregateType(iota)
+	AggregateTypeAvg
+	AggregateTypeFirst
+	AggregateTypeLast
+	AggregateTypeMin
+	AggregateTypeMax
+	AggregateTypeSum
+	AggregateTypeRange
+	AggregateTypeCount
+	AggregateTypeStdP
+	AggregateTypeStdS
+	AggregateTypeVarP
+	AggregateTypeVarS
+	AggregateTypeTWA
+)
+
+var aggregateTypeNames = map[AggregateType]string{
+	AggregateTypeAvg:   "AVG",
+	AggregateTypeFirst: "FIRST",
+	AggregateTypeLast:  "LAST",
+	AggregateTypeMin:   "MIN",
+	AggregateTypeMax:   "MAX",
+	AggregateTypeSum:   "SUM",
+	AggregateTypeRange: "RANGE",
+	AggregateTypeCount: "COUNT",
+	AggregateTypeStdP:  "STD.P",
+	AggregateTypeStdS:  "STD.S",
+	AggregateTypeVarP:  "VAR.P",
+	AggregateTypeVarS:  "VAR.S",
+	AggregateTypeTWA:   "TWA",
+}
+
+var reducerTypes = map[AggregateType]struct{}{
+	AggregateTypeAvg: {}, AggregateTypeSum: {}, AggregateTypeMin: {}, AggregateTypeMax: {}, AggregateTypeRange: {},
+	AggregateTypeCount: {}, AggregateTypeStdP: {}, AggregateTypeStdS: {}, AggregateTypeVarP: {}, AggregateTypeVarS: {},
+}
+
+func parseAggregateType(s string) AggregateType {
+	for t, name := range aggregateTypeNames {
+		if strings.EqualFold(s, name) {
+			return t
+		}
+	}
+	return AggregateTypeNone
+}
+
+type Sample struct {
+	Key   string
+	Time  Timestamp
+	Value float64
+}
+
+type MultiSample struct {
+	Labels  [][2]string
+	Samples []*Sample
+}
+
+type MultiQueryAggregation struct {
+	Align           string
+	Aggregator      AggregateType
+	BucketDuration  time.Duration
+	BucketTimestamp string
+	Empty           bool
+}
+
+func (agg *MultiQueryAggregation) appendArgs(args []any) []any {
+	if agg == nil {
+		return args
+	}
+	if len(agg.Align) > 0 {
+		args = append(args, "ALIGN", agg.Align)
+	}
+	args = append(args, "AGGREGATION", aggregateTypeNames[agg.Aggregator], agg.BucketDuration.Milliseconds())
+	if len(agg.BucketTimestamp) > 0 {
+		args = append(args, "BUCKETTIMESTAMP", agg.BucketTimestamp)
+	}
+	if agg.Empty {
+		args = append(args, "EMPTY")
+	}
+	return args
+}
+
+type MultiQuery struct {
+	FromTime         Timestamp
+	ToTime           Timestamp
+	Latest           bool
+	FiltersByTime    []Timestamp
+	FilterByValueMin *float64
+	FilterByValueMax *float64
+	WithLabels       bool
+	SelectedLabels   []string
+	Count            *int
+	Aggregation      *MultiQueryAggregation
+	Filters          []string
+	GroupByLabel     string
+	Reducer          AggregateType
+}
+
+func (q *MultiQuery) ValidateRange() error {
+	if q.FromTime.Kind == TimestampKindServer || q.ToTime.Kind == TimestampKindServer {
+		return ErrInvalidQuery
+	} else if (q.FilterByValueMin == nil) != (q.FilterByValueMax == nil) {
+		return ErrInvalidQuery
+	} else if q.Count != nil && *q.Count <= 0 {
+		return ErrInvalidQuery
+	} else if agg := q.Aggregation; agg != nil && (agg.Aggregator == AggregateTypeNone || agg.BucketDuration.Milliseconds() <= 0) {
+		return ErrInvalidQuery
+	}
+	for _, t := range q.FiltersByTime {
+		if !t.IsExplicit() {
+			return ErrInvalidQuery
+		}
+	}
+	return nil
+}
+
+func (q *MultiQuery) Validate() error {
+	if err := q.ValidateRange(); err != nil {
+		return err
+	} else if len(q.Filters) == 0 {
+		return ErrNoPositiveFilter
+	}
+	if len(q.GroupByLabel) > 0 || q.Reducer != AggregateTypeNone {
+		if len(q.GroupByLabel) == 0 {
+			return ErrInvalidQuery
+		} else if _, ok := reducerTypes[q.Reducer]; !ok {
+			return ErrInvalidReducer
+		}
+	}
+	return nil
+}
+
+func (q *MultiQuery) ValidateGroup() error {
+	if len(q.GroupByLabel) == 0 || q.Reducer == AggregateTypeNone {
+		return ErrInvalidQuery
+	}
+	return q.Validate()
+}
+
+func (q *MultiQuery) appendFiltersByTime(args []any) []any {
+	if len(q.FiltersByTime) > 0 {
+		args = append(args, "FILTER_BY_TS")
+		for _, t := range q.FiltersByTime {
+			args = append(args, t.UnixMilli())
+		}
+	}
+	return args
+}
+
+func (q *MultiQuery) appendFilterByValue(args []any) []any {
+	if q.FilterByValueMin != nil && q.FilterByValueMax != nil {
+		args = append(args, "FILTER_BY_VALUE", *q.FilterByValueMin, *q.FilterByValueMax)
+	}
+	return args
+}
+
+func (q *MultiQuery) appendLabelSelection(args []any) []any {
+	if q.WithLabels {
+		args = append(args, "WITHLABELS")
+	} else if len(q.SelectedLabels) > 0 {
+		args = append(args, "SELECTED_LABELS")
+		for _, label := range q.SelectedLabels {
+			args = append(args, label)
+		}
+	}
+	return args
+}
+
+func (q *MultiQuery) appendFilters(args []any) []any {
+	args = append(args, "FILTER")
+	for _, filter := range q.Filters {
+		args = append(args, filter)
+	}
+	return args
+}
+
+func (q *MultiQuery) appendGroupByLabel(args []any) []any {
+	if len(q.GroupByLabel) > 0 && q.Reducer != AggregateTypeNone {
+		args = append(args, "GROUPBY", q.GroupByLabel, "REDUCE", aggregateTypeNames[q.Reducer])
+	}
+	return args
+}
+
+func AddArgs(sample *Sample, option *Option) []any {
+	args := make([]any, 0, 4+option.argsLen())
+	args = append(args, "TS.ADD", sample.Key, sample.Time.or(TimestampKindServer).arg(), sample.Value)
+	return option.appendArgs(args, false, "ON_DUPLICATE")
+}
+
+func AddResult(val any) (*time.Time, error) {
+	return IncrByResult(val)
+}
+
+func AlterArgs(key string, option *Option) []any {
+	args := make([]any, 0, 2+option.argsLen())
+	args = append(args, "TS.ALTER", key)
+	return option.appendArgs(args, true, "DUPLICATE_POLICY")
+}
+
+func CreateArgs(key string, option *Option) []any {
+	args := make([]any, 0, 2+option.argsLen())
+	args = append(args, "TS.CREATE", key)
+	return option.appendArgs(args, false, "DUPLICATE_POLICY")
+}
+
+func CreateRuleArgs(srcKey string, destKey string, aggregateType AggregateType, bucketDuration time.Duration, alignTime *time.Duration) []any {
+	args := make([]any, 0, 7)
+	args = append(args, "TS.CREATERULE", srcKey, destKey, "AGGREGATION", aggregateTypeNames[aggregateType], bucketDuration.Milliseconds())
+	if alignTime != nil {
+		args = append(args, alignTime.Milliseconds())
+	}
+	return args
+}
+
+func incrByArgs(cmd string, key string, value float64, t Timestamp, option *Option) ([]any, error) {
+	args := make([]any, 0, 5+option.argsLen())
+	args = append(args, cmd, key, value)
+	switch t.Kind {
+	case TimestampKindDefault:
+	case TimestampKindExplicit, TimestampKindServer:
+		args = append(args, "TIMESTAMP", t.arg())
+	default:
+		return nil, ErrInvalidQuery
+	}
+	return option.appendArgs(args, false, "DUPLICATE_POLICY"), nil
+}
+
+func DecrByArgs(key string, value float64, t Timestamp, option *Option) ([]any, error) {
+	return incrByArgs("TS.DECRBY", key, value, t, option)
+}
+
+func DecrByResult(val any) (*time.Time, error) {
+	return IncrByResult(val)
+}
+
+func DelArgs(key string, fromTime Timestamp, toTime Timestamp) []any {
+	return []any{"TS.DEL", key, fromTime.or(TimestampKindEarliest).arg(), toTime.or(TimestampKindLatest).arg()}
+}
+
+func DelResult(val any) (int64, error) {
+	return redisstack.ParseScalar[int64](val)
+}
+
+func DeleteRuleArgs(srcKey string, destKey string) []any {
+	return []any{"TS.DELETERULE", srcKey, destKey}
+}
+
+func GetArgs(key string) []any {
+	return []any{"TS.GET", key}
+}
+
+func parseSample(val any) (*Sample, error) {
+	arr, err := redisstack.ParseArray(val, 2)
+	if err != nil {
+		return nil, err
+	}
+	mt, err := redisstack.ParseScalar[int64](arr[0])
+	if err != nil {
+		return nil, redisstack.WithPathIndex(err, 0)
+	}
+	f, err := redisstack.ParseFloat(arr[1])
+	if err != nil {
+		return nil, redisstack.WithPathIndex(err, 1)
+	}
+	s := &Sample{
+		Time:  AtMilli(mt),
+		Value: f,
+	}
+	return s, nil
+}
+
+func GetResult(val any) (*Sample, error) {
+	if arr, ok := val.([]any); ok && len(arr) == 0 {
+		return nil, nil
+	}
+	return parseSample(val)
+}
+
+func IncrByArgs(key string, value float64, t Timestamp, option *Option) ([]any, error) {
+	return incrByArgs("TS.INCRBY", key, value, t, option)
+}
+
+func IncrByResult(val any) (*time.Time, error) {
+	mt, err := redisstack.ParseScalar[int64](val)
+	if err != nil {
+		return nil, err
+	}
+	t := time.UnixMilli(mt)
+	return &t, nil
+}
+
+type Rule struct {
+	DestKey        string
+	Aggregator     AggregateType
+	BucketDuration time.Duration
+	AlignTime      time.Duration
+}
+
+type Chunk struct {
+	StartTime      time.Time
+	EndTime        time.Time
+	Samples        int64
+	Size           int64
+	BytesPerSample float64
+}
+
+type Info struct {
+	TotalSamples int64
+	MemoryUsage  int64
+	FirstTime    time.Time
+	LastTime     time.Time
+	Retention    time.Duration
+	ChunkCount   int64
+	ChunkSize    int64
+	ChunkType    string
+	DupPolicy    DupPolicy
+	Labels       [][2]string
+	SourceKey    string
+	Rules        []*Rule
+	Chunks       []*Chunk
+	Extra        map[string]any
+}
+
+func InfoArgs(key string, debug bool) []any {
+	if debug {
+		return []any{"TS.INFO", key, "DEBUG"}
+	}
+	return []any{"TS.INFO", key}
+}
+
+func parseRule(destKey any, val any) (*Rule, error) {
+	arr, err := redisstack.ParseArray(val, 2)
+	if err != nil {
+		return nil, err
+	}
+	rule := &Rule{}
+	if rule.DestKey, err = redisstack.ParseScalar[string](destKey); err != nil {
+		return nil, err
+	}
+	bucket, err := redisstack.ParseScalar[int64](arr[0])
+	if err != nil {
+		return nil, redisstack.WithPathIndex(err, 0)
+	}
+	rule.BucketDuration = time.Duration(bucket) * time.Millisecond
+	aggregator, err := redisstack.ParseScalar[string](arr[1])
+	if err != nil {
+		return nil, redisstack.WithPathIndex(err, 1)
+	}
+	rule.Aggregator = parseAggregateType(aggregator)
+	if len(arr) > 2 {
+		align, err := redisstack.ParseScalar[int64](arr[2])
+		if err != nil {
+			return nil, redisstack.WithPathIndex(err, 2)
+		}
+		rule.AlignTime = time.Duration(align) * time.Millisecond
+	}
+	return rule, nil
+}
+
+func parseRules(val any) ([]*Rule, error) {
+	if m, ok := val.(map[any]any); ok {
+		return redisstack.ParseToInterlacedMappedArray(m, 0, parseRule)
+	}
+	return redisstack.ParseToMappedArray(val, 0, func(e any) (*Rule, error) {
+		arr, err := redisstack.ParseArray(e, 3)
+		if err != nil {
+			return nil, err
+		}
+		return parseRule(arr[0], arr[1:])
+	})
+}
+
+func parseChunk(val any) (*Chunk, error) {
+	chunk := &Chunk{}
+	var start, end int64
+	_, err := redisstack.ParseInfo(val, map[string]any{
+		"startTimestamp": &start,
+		"endTimestamp":   &end,
+		"samples":        &chunk.Samples,
+		"size":           &chunk.Size,
+		"bytesPerSample": &chunk.BytesPerSample,
+	})
+	if err != nil {
+		return nil, err
+	}
+	chunk.StartTime, chunk.EndTime = time.UnixMilli(start), time.UnixMilli(end)
+	return chunk, nil
+}
+
+func InfoResult(val any) (*Info, error) {
+	res := &Info{}
+	var first, last, retention int64
+	var dupPolicy, labels, rules, chunks any
+	extra, err := redisstack.ParseInfo(val, map[string]any{
+		"totalSamples":    &res.TotalSamples,
+		"memoryUsage":     &res.MemoryUsage,
+		"firstTimestamp":  &first,
+		"lastTimestamp":   &last,
+		"retentionTime":   &retention,
+		"chunkCount":      &res.ChunkCount,
+		"chunkSize":       &res.ChunkSize,
+		"chunkType":       &res.ChunkType,
+		"duplicatePolicy": &dupPolicy,
+		"labels":          &labels,
+		"sourceKey":       &res.SourceKey,
+		"rules":           &rules,
+		"Chunks":          &chunks,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.FirstTime, res.LastTime = time.UnixMilli(first), time.UnixMilli(last)
+	res.Retention = time.Duration(retention) * time.Millisecond
+	if s, ok := dupPolicy.(string); ok {
+		res.DupPolicy = parseDupPolicy(s)
+	}
+	if labels != nil {
+		if res.Labels, err = redisstack.ParseStringPairArray(labels, 0); err != nil {
+			return nil, redisstack.WithPathKey(err, "labels")
+		}
+	}
+	if rules != nil {
+		if res.Rules, err = parseRules(rules); err != nil {
+			return nil, redisstack.WithPathKey(err, "rules")
+		}
+	}
+	if chunks != nil {
+		if res.Chunks, err = redisstack.ParseToMappedArray(chunks, 0, parseChunk); err != nil {
+			return nil, redisstack.WithPathKey(err, "Chunks")
+		}
+	}
+	res.Extra = extra
+	return res, nil
+}
+
+func MAddArgs(samples []*Sample) []any {
+	args := make([]any, 1+len(samples)*3)
+	args[0] = "TS.MADD"
+	for i, sample := range samples {
+		args[i*3+1], args[i*3+2], args[i*3+3] = sample.Key, sample.Time.or(TimestampKindServer).arg(), sample.Value
+	}
+	return args
+}
+
+func MAddResult(val any) ([]*time.Time, error) {
+	return redisstack.ParseToMappedArray(val, 0, func(e any) (*time.Time, error) {
+		if e1, ok := e.(int64); ok {
+			t := time.UnixMilli(e1)
+			return &t, nil
+		}
+		return nil, nil
+	})
+}
+
+type MAddReply struct {
+	Time *time.Time
+	Err  error
+}
+
+func MAddDetailResult(val any) ([]*MAddReply, error) {
+	return redisstack.ParseToMappedArray(val, 0, func(e any) (*MAddReply, error) {
+		switch e1 := e.(type) {
+		case int64:
+			t := time.UnixMilli(e1)
+			return &MAddReply{Time: &t}, nil
+		case error:
+			return &MAddReply{Err: redisstack.ClassifyError(e1)}, nil
+		}
+		return nil, redisstack.NewTypeError("int64 or error", e)
+	})
+}
+
+type mAddCmd struct {
+	samples []*Sample
+	group   []int
+	cmd     *redis.Cmd
+}
+
+func queueMAdd(ctx context.Context, pipe redis.Pipeliner, sharding redisstack.Sharding, samples []*Sample, cmds []*mAddCmd) []*mAddCmd {
+	keys := make([]string, len(samples))
+	for i, sample := range samples {
+		keys[i] = sample.Key
+	}
+	for _, group := range sharding.Group(keys) {
+		samples1 := make([]*Sample, len(group))
+		for j, k := range group {
+			samples1[j] = samples[k]
+		}
+		cmds = append(cmds, &mAddCmd{samples1, group, pipe.Do(ctx, MAddArgs(samples1)...)})
+	}
+	return cmds
+}
+
+func (c *mAddCmd) replies() []*MAddReply {
+	res := make([]*MAddReply, len(c.samples))
+	fail := func(err error) []*MAddReply {
+		for i := range res {
+			res[i] = &MAddReply{Err: err}
+		}
+		return res
+	}
+	if err := c.cmd.Err(); err != nil {
+		return fail(redisstack.ClassifyError(err))
+	}
+	replies, err := MAddDetailResult(c.cmd.Val())
+	if err != nil {
+		return fail(redisstack.WithCommand(err, "TS.MADD"))
+	} else if len(replies) != len(c.samples) {
+		err = redisstack.NewDataError("array of length "+strconv.Itoa(len(c.samples)), "array of length "+strconv.Itoa(len(replies)), c.cmd.Val())
+		return fail(redisstack.WithCommand(err, "TS.MADD"))
+	}
+	return replies
+}
+
+func MAddBatch(ctx context.Context, red redis.UniversalClient, samples []*Sample) ([]*time.Time, error) {
+	pipe := red.Pipeline()
+	cmds := queueMAdd(ctx, pipe, redisstack.ShardingOf(red), samples, nil)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	res := make([]*time.Time, len(samples))
+	for _, c := range cmds {
+		res1, err := MAddResult(c.cmd.Val())
+		if err != nil {
+			return nil, err
+		} else if len(res1) != len(c.group) {
+			return nil, redisstack.NewDataError("array of length "+strconv.Itoa(len(c.group)), "array of length "+strconv.Itoa(len(res1)), c.cmd.Val())
+		}
+		for j, k := range c.group {
+			res[k] = res1[j]
+		}
+	}
+	return res, nil
+}
+
+type OptionResolver func(key string) *Option
+
+func execMAdd(ctx context.Context, red redis.UniversalClient, batches [][]*Sample) ([][]*MAddReply, error) {
+	pipe, sharding := red.Pipeline(), redisstack.ShardingOf(red)
+	cmds := make([][]*mAddCmd, len(batches))
+	for i, batch := range batches {
+		if len(batch) > 0 {
+			cmds[i] = queueMAdd(ctx, pipe, sharding, batch, nil)
+		}
+	}
+	var re redis.Error
+	if _, err := pipe.Exec(ctx); err != nil && !errors.As(err, &re) {
+		return nil, err
+	}
+	res := make([][]*MAddReply, len(batches))
+	for i, batch := range batches {
+		res[i] = make([]*MAddReply, len(batch))
+		for _, c := range cmds[i] {
+			for j, reply := range c.replies() {
+				res[i][c.group[j]] = reply
+			}
+		}
+	}
+	return res, nil
+}
+
+func mAdd(ctx context.Context, red redis.UniversalClient, batches [][]*Sample, resolve OptionResolver) ([][]*MAddReply, error) {
+	res, err := execMAdd(ctx, red, batches)
+	if err != nil || resolve == nil {
+		return res, err
+	}
+
+	type pos struct{ i, j int }
+	missing := map[string][]pos{}
+	var keys []string
+	for i, replies := range res {
+		for j, reply := range replies {
+			if !errors.Is(reply.Err, redisstack.ErrKeyNotFound) {
+				continue
+			}
+			key := batches[i][j].Key
+			if _, ok := missing[key]; !ok {
+				keys = append(keys, key)
+			}
+			missing[key] = append(missing[key], pos{i, j})
+		}
+	}
+	if len(keys) == 0 {
+		return res, nil
+	}
+	pipe := red.Pipeline()
+	creates := make([]*redis.Cmd, len(keys))
+	for i, key := range keys {
+		if option := resolve(key); option != nil {
+			creates[i] = pipe.Do(ctx, CreateArgs(key, option)...)
+		}
+	}
+	if pipe.Len() == 0 {
+		return res, nil
+	}
+	var re redis.Error
+	if _, err = pipe.Exec(ctx); err != nil && !errors.As(err, &re) {
+		return nil, err
+	}
+	var retry []*Sample
+	var retryPos []pos
+	for i, key := range keys {
+		if creates[i] == nil {
+			continue
+		}
+		if err := redisstack.ClassifyError(creates[i].Err()); err != nil && !errors.Is(err, redisstack.ErrKeyExists) {
+			for _, p := range missing[key] {
+				res[p.i][p.j].Err = err
+			}
+			continue
+		}
+		for _, p := range missing[key] {
+			retry, retryPos = append(retry, batches[p.i][p.j]), append(retryPos, p)
+		}
+	}
+	if len(retry) == 0 {
+		return res, nil
+	}
+	res1, err := execMAdd(ctx, red, [][]*Sample{retry})
+	if err != nil {
+		return nil, err
+	}
+	for k, p := range retryPos {
+		res[p.i][p.j] = res1[0][k]
+	}
+	return res, nil
+}
+
+func MAddAutoCreate(ctx context.Context, red redis.UniversalClient, samples []*Sample, resolve OptionResolver) ([]*MAddReply, error) {
+	res, err := mAdd(ctx, red, [][]*Sample{samples}, resolve)
+	if err != nil {
+		return nil, err
+	}
+	return res[0], nil
+}
+
+func MGetArgs(q *MultiQuery) []any {
+	args := make([]any, 0, 4+len(q.SelectedLabels)+len(q.Filters))
+	args = append(args, "TS.MGET")
+	if q.Latest {
+		args = append(args, "LATEST")
+	}
+	args = q.appendLabelSelection(args)
+	args = q.appendFilters(args)
+	return args
+}
+
+func parseMultiResult[T any](val any, f func(labels [][2]string, e any) (T, error), meta func(res T, e any) error) (map[string]T, error) {
+	if m, ok := val.(map[any]any); ok {
+		res := make(map[string]T, len(m))
+		for k, e := range m {
+			key, err := redisstack.ParseScalar[string](k)
+			if err != nil {
+				return nil, redisstack.WithPathKey(err, fmt.Sprint(k))
+			}
+			arr, err := redisstack.ParseArray(e, 2)
+			if err != nil {
+				return nil, redisstack.WithPathKey(err, key)
+			}
+			labels, err := redisstack.ParseStringPairArray(arr[0], 0)
+			if err != nil {
+				return nil, redisstack.WithPathKey(redisstack.WithPathIndex(err, 0), key)
+			}
+			if res[key], err = f(labels, arr[len(arr)-1]); err != nil {
+				return nil, redisstack.WithPathKey(redisstack.WithPathIndex(err, len(arr)-1), key)
+			}
+			for j := 1; meta != nil && j < len(arr)-1; j++ {
+				if err = meta(res[key], arr[j]); err != nil {
+					return nil, redisstack.WithPathKey(redisstack.WithPathIndex(err, j), key)
+				}
+			}
+		}
+		return res, nil
+	}
+
+	arr, err := redisstack.ParseArray(val, 0)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]T, len(arr))
+	for i, e := range arr {
+		arr1, err := redisstack.ParseArray(e, 3)
+		if err != nil {
+			return nil, redisstack.WithPathIndex(err, i)
+		}
+		key, err := redisstack.ParseScalar[string](arr1[0])
+		if err != nil {
+			return nil, redisstack.WithPathIndex(redisstack.WithPathIndex(err, 0), i)
+		}
+		labels, err := redisstack.ParseStringPairArray(arr1[1], 0)
+		if err != nil {
+			return nil, redisstack.WithPathIndex(redisstack.WithPathIndex(err, 1), i)
+		}
+		if res[key], err = f(labels, arr1[2]); err != nil {
+			return nil, redisstack.WithPathIndex(redisstack.WithPathIndex(err, 2), i)
+		}
+	}
+	return res, nil
+}
+
+func MGetResult(val any) (map[string]*MultiSample, error) {
+	return parseMultiResult(val, func(labels [][2]string, e any) (*MultiSample, error) {
+		sample, err := GetResult(e)
+		if err != nil {
+			return nil, err
+		} else if sample == nil {
+			return &MultiSample{Labels: labels}, nil
+		}
+		return &MultiSample{Labels: labels, Samples: []*Sample{sample}}, nil
+	}, nil)
+}
+
+func MRangeArgs(q *MultiQuery) []any {
+	args := make([]any, 0, 24+len(q.FiltersByTime)+len(q.SelectedLabels)+len(q.Filters))
+	args = append(args, "TS.MRANGE")
+	args = append(args, q.FromTime.or(TimestampKindEarliest).arg(), q.ToTime.or(TimestampKindLatest).arg())
+	if q.Latest {
+		args = append(args, "LATEST")
+	}
+	args = q.appendFiltersByTime(args)
+	args = q.appendFilterByValue(args)
+	args = q.appendLabelSelection(args)
+	if q.Count != nil {
+		args = append(args, "COUNT", *q.Count)
+	}
+	args = q.Aggregation.appendArgs(args)
+	args = q.appendFilters(args)
+	args = q.appendGroupByLabel(args)
+	return args
+}
+
+func MRangeResult(val any) (map[string]*MultiSample, error) {
+	return parseMultiResult(val, func(labels [][2]string, e any) (*MultiSample, error) {
+		samples, err := redisstack.ParseToMappedArray(e, 0, parseSample)
+		if err != nil {
+			return nil, err
+		}
+		return &MultiSample{Labels: labels, Samples: samples}, nil
+	}, nil)
+}
+
+type GroupedSeries struct {
+	Label   string
+	Value   string
+	Reducer AggregateType
+	Sources []string
+	Samples []*Sample
+}
+
+func MRangeGroupResult(val any) (map[string]*GroupedSeries, error) {
+	return parseMultiResult(val, func(labels [][2]string, e any) (*GroupedSeries, error) {
+		gs := &GroupedSeries{}
+		for _, label := range labels {
+			switch label[0] {
+			case "__reducer__":
+				gs.Reducer = parseAggregateType(label[1])
+			case "__source__":
+				if len(label[1]) > 0 {
+					gs.Sources = strings.Split(label[1], ",")
+				}
+			default:
+				gs.Label, gs.Value = label[0], label[1]
+			}
+		}
+		var err error
+		if gs.Samples, err = redisstack.ParseToMappedArray(e, 0, parseSample); err != nil {
+			return nil, err
+		}
+		return gs, nil
+	}, func(gs *GroupedSeries, e any) error {
+		m, err := redisstack.ParseMap(e)
+		if err != nil {
+			return err
+		}
+		if reducers, err := redisstack.ParseScalarArray[string](m["reducers"], 1); err == nil {
+			gs.Reducer = parseAggregateType(reducers[0])
+		}
+		if sources, ok := m["sources"]; ok {
+			if gs.Sources, err = redisstack.ParseScalarArray[string](sources, 0); err != nil {
+				return redisstack.WithPathKey(err, "sources")
+			}
+		}
+		return nil
+	})
+}
+
+func MRevRangeArgs(q *MultiQuery) []any {
+	args := MRangeArgs(q)
+	args[0] = "TS.MREVRANGE"
+	return args
+}
+
+func MRevRangeResult(val any) (map[string]*MultiSample, error) {
+	return MRangeResult(val)
+}
+
+func MRevRangeGroupResult(val any) (map[string]*GroupedSeries, error) {
+	return MRangeGroupResult(val)
+}
+
+func QueryIndexArgs(filters []string) []any {
+	args := make([]any, 1+len(filters))
+	args[0] = "TS.QUERYINDEX"
+	for i, filter := range filters {
+		args[i+1] = filter
+	}
+	return args
+}
+
+func QueryIndexResult(val any) ([]string, error) {
+	return redisstack.ParseScalarArray[string](val, 0)
+}
+
+func RangeArgs(key string, q *MultiQuery) []any {
+	args := make([]any, 0, 19+len(q.FiltersByTime))
+	args = append(args, "TS.RANGE", key)
+	args = append(args, q.FromTime.or(TimestampKindEarliest).arg(), q.ToTime.or(TimestampKindLatest).arg())
+	if q.Latest {
+		args = append(args, "LATEST")
+	}
+	args = q.appendFiltersByTime(args)
+	args = q.appendFilterByValue(args)
+	if q.Count != nil {
+		args = append(args, "COUNT", *q.Count)
+	}
+	args = q.Aggregation.appendArgs(args)
+	return args
+}
+
+func RangeResult(val any) ([]*Sample, error) {
+	return redisstack.ParseToMappedArray(val, 0, parseSample)
+}
+
+func RevRangeArgs(key string, q *MultiQuery) []any {
+	args := RangeArgs(key, q)
+	args[0] = "TS.REVRANGE"
+	return args
+}
+
+func RevRangeResult(val any) ([]*Sample, error) {
+	return RangeResult(val)
+}
@@ -0,0 +1,270 @@
+package redisstack
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+)
+
+func TestArgs(t *testing.T) {
+	retention, chunkSize := time.Hour, int64(4096)
+	option := &Option{Retention: &retention, Uncompressed: true, ChunkSize: &chunkSize, DupPolicy: DupPolicyMax, Labels: [][2]string{{"a", "1"}}}
+	align := 5 * time.Second
+	count := 10
+	min, max := 1.5, 2.5
+	q := &MultiQuery{
+		FromTime:         AtMilli(1000),
+		ToTime:           AtMilli(2000),
+		Latest:           true,
+		FiltersByTime:    []Timestamp{AtMilli(1500)},
+		FilterByValueMin: &min,
+		FilterByValueMax: &max,
+		WithLabels:       true,
+		Count:            &count,
+		Aggregation: &MultiQueryAggregation{
+			Align: "-", Aggregator: AggregateTypeAvg, BucketDuration: time.Second, BucketTimestamp: "mid", Empty: true,
+		},
+		Filters:      []string{"a=1"},
+		GroupByLabel: "a",
+		Reducer:      AggregateTypeSum,
+	}
+	for _, c := range []struct {
+		name string
+		args []any
+		exp  []any
+	}{
+		{"AddDefaultTime", AddArgs(&Sample{Key: "k", Value: 1.5}, nil), []any{"TS.ADD", "k", "*", 1.5}},
+		{"Add", AddArgs(&Sample{Key: "k", Time: AtMilli(1000), Value: 1.5}, option),
+			[]any{"TS.ADD", "k", int64(1000), 1.5, "RETENTION", int64(3600000), "ENCODING", "UNCOMPRESSED", "CHUNK_SIZE", int64(4096), "ON_DUPLICATE", "MAX", "LABELS", "a", "1"}},
+		{"AlterNil", AlterArgs("k", nil), []any{"TS.ALTER", "k"}},
+		{"AlterEmptyLabels", AlterArgs("k", &Option{Labels: [][2]string{}}), []any{"TS.ALTER", "k", "LABELS"}},
+		{"Alter", AlterArgs("k", &Option{ChunkSize: &chunkSize, DupPolicy: DupPolicyLast}),
+			[]any{"TS.ALTER", "k", "CHUNK_SIZE", int64(4096), "DUPLICATE_POLICY", "LAST"}},
+		{"CreateNil", CreateArgs("k", nil), []any{"TS.CREATE", "k"}},
+		{"CreateNoEmptyLabels", CreateArgs("k", &Option{Labels: [][2]string{}}), []any{"TS.CREATE", "k"}},
+		{"Create", CreateArgs("k", option),
+			[]any{"TS.CREATE", "k", "RETENTION", int64(3600000), "ENCODING", "UNCOMPRESSED", "CHUNK_SIZE", int64(4096), "DUPLICATE_POLICY", "MAX", "LABELS", "a", "1"}},
+		{"CreateRule", CreateRuleArgs("s", "d", AggregateTypeTWA, time.Minute, nil), []any{"TS.CREATERULE", "s", "d", "AGGREGATION", "TWA", int64(60000)}},
+		{"CreateRuleAlign", CreateRuleArgs("s", "d", AggregateTypeStdP, time.Minute, &align),
+			[]any{"TS.CREATERULE", "s", "d", "AGGREGATION", "STD.P", int64(60000), int64(5000)}},
+		{"DelDefault", DelArgs("k", Timestamp{}, Timestamp{}), []any{"TS.DEL", "k", "-", "+"}},
+		{"Del", DelArgs("k", AtMilli(1000), AtMilli(2000)), []any{"TS.DEL", "k", int64(1000), int64(2000)}},
+		{"DeleteRule", DeleteRuleArgs("s", "d"), []any{"TS.DELETERULE", "s", "d"}},
+		{"Get", GetArgs("k"), []any{"TS.GET", "k"}},
+		{"Info", InfoArgs("k", false), []any{"TS.INFO", "k"}},
+		{"InfoDebug", InfoArgs("k", true), []any{"TS.INFO", "k", "DEBUG"}},
+		{"MAdd", MAddArgs([]*Sample{{Key: "a", Time: AtMilli(1000), Value: 1}, {Key: "b", Value: 2}}),
+			[]any{"TS.MADD", "a", int64(1000), 1.0, "b", "*", 2.0}},
+		{"MGet", MGetArgs(&MultiQuery{Latest: true, SelectedLabels: []string{"a", "b"}, Filters: []string{"a=1", "b!="}}),
+			[]any{"TS.MGET", "LATEST", "SELECTED_LABELS", "a", "b", "FILTER", "a=1", "b!="}},
+		{"MRangeDefault", MRangeArgs(&MultiQuery{Filters: []string{"a=1"}}), []any{"TS.MRANGE", "-", "+", "FILTER", "a=1"}},
+		{"MRange", MRangeArgs(q),
+			[]any{"TS.MRANGE", int64(1000), int64(2000), "LATEST", "FILTER_BY_TS", int64(1500), "FILTER_BY_VALUE", 1.5, 2.5, "WITHLABELS",
+				"COUNT", 10, "ALIGN", "-", "AGGREGATION", "AVG", int64(1000), "BUCKETTIMESTAMP", "mid", "EMPTY",
+				"FILTER", "a=1", "GROUPBY", "a", "REDUCE", "SUM"}},
+		{"MRevRange", MRevRangeArgs(&MultiQuery{ToTime: AtMilli(2000), Filters: []string{"a=1"}}), []any{"TS.MREVRANGE", "-", int64(2000), "FILTER", "a=1"}},
+		{"QueryIndex", QueryIndexArgs([]string{"a=1", "b=(x,y)"}), []any{"TS.QUERYINDEX", "a=1", "b=(x,y)"}},
+		{"RangeDefault", RangeArgs("k", &MultiQuery{}), []any{"TS.RANGE", "k", "-", "+"}},
+		{"Range", RangeArgs("k", q),
+			[]any{"TS.RANGE", "k", int64(1000), int64(2000), "LATEST", "FILTER_BY_TS", int64(1500), "FILTER_BY_VALUE", 1.5, 2.5,
+				"COUNT", 10, "ALIGN", "-", "AGGREGATION", "AVG", int64(1000), "BUCKETTIMESTAMP", "mid", "EMPTY"}},
+		{"RevRange", RevRangeArgs("k", &MultiQuery{FromTime: AtMilli(1000)}), []any{"TS.REVRANGE", "k", int64(1000), "+"}},
+	} {
+		if !reflect.DeepEqual(c.args, c.exp) {
+			t.Errorf("%s: got %#v, expected %#v", c.name, c.args, c.exp)
+		}
+	}
+}
+
+func TestIncrByArgs(t *testing.T) {
+	chunkSize := int64(4096)
+	for _, c := range []struct {
+		name string
+		args func() ([]any, error)
+		exp  []any
+		err  error
+	}{
+		{"DecrBy", func() ([]any, error) { return DecrByArgs("k", 2, Timestamp{}, nil) }, []any{"TS.DECRBY", "k", 2.0}, nil},
+		{"DecrByServerTime", func() ([]any, error) { return DecrByArgs("k", 2, ServerTime, nil) }, []any{"TS.DECRBY", "k", 2.0, "TIMESTAMP", "*"}, nil},
+		{"IncrBy", func() ([]any, error) { return IncrByArgs("k", 2, AtMilli(1000), &Option{ChunkSize: &chunkSize}) },
+			[]any{"TS.INCRBY", "k", 2.0, "TIMESTAMP", int64(1000), "CHUNK_SIZE", int64(4096)}, nil},
+		{"IncrByEarliest", func() ([]any, error) { return IncrByArgs("k", 2, Earliest, nil) }, nil, ErrInvalidQuery},
+		{"DecrByLatest", func() ([]any, error) { return DecrByArgs("k", 2, Latest, nil) }, nil, ErrInvalidQuery},
+	} {
+		args, err := c.args()
+		if !errors.Is(err, c.err) {
+			t.Errorf("%s: %v", c.name, err)
+		} else if !reflect.DeepEqual(args, c.exp) {
+			t.Errorf("%s: got %#v, expected %#v", c.name, args, c.exp)
+		}
+	}
+}
+
+func timePtr(mt int64) *time.Time {
+	t := time.UnixMilli(mt)
+	return &t
+}
+
+func mustArgs(args []any, err error) []any {
+	if err != nil {
+		panic(err)
+	}
+	return args
+}
+
+func TestReplies(t *testing.T) {
+	exchanges, err := redisstacktest.LoadExchanges("testdata/replies.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &Info{
+		TotalSamples: 3, MemoryUsage: 4232, FirstTime: time.UnixMilli(1000), LastTime: time.UnixMilli(3000),
+		Retention: time.Hour, ChunkCount: 1, ChunkSize: 4096, ChunkType: "compressed", DupPolicy: DupPolicyLast,
+		Labels: [][2]string{{"a", "1"}}, SourceKey: "src",
+		Rules:  []*Rule{{DestKey: "d", Aggregator: AggregateTypeAvg, BucketDuration: time.Minute, AlignTime: time.Second}},
+		Chunks: []*Chunk{{StartTime: time.UnixMilli(1000), EndTime: time.UnixMilli(3000), Samples: 3, Size: 4096, BytesPerSample: 1365.3333}},
+		Extra:  map[string]any{},
+	}
+	for _, c := range []struct {
+		name  string
+		args  []any
+		parse func(any) (any, error)
+		exp   any
+	}{
+		{"Add", AddArgs(&Sample{Key: "k", Time: AtMilli(1000), Value: 1.5}, nil), func(val any) (any, error) { return AddResult(val) }, timePtr(1000)},
+		{"IncrBy", mustArgs(IncrByArgs("k", 1, AtMilli(1000), nil)), func(val any) (any, error) { return IncrByResult(val) }, timePtr(1000)},
+		{"Del", DelArgs("k", AtMilli(1000), AtMilli(2000)), func(val any) (any, error) { return DelResult(val) }, int64(2)},
+		{"Get", GetArgs("k"), func(val any) (any, error) { return GetResult(val) }, &Sample{Time: AtMilli(1000), Value: 1.5}},
+		{"GetEmpty", GetArgs("empty"), func(val any) (any, error) { return GetResult(val) }, (*Sample)(nil)},
+		{"Info", InfoArgs("k", false), func(val any) (any, error) { return InfoResult(val) }, info},
+		{"MAdd", MAddArgs([]*Sample{{Key: "k", Time: AtMilli(1000), Value: 1}, {Key: "missing", Time: AtMilli(1000), Value: 2}}), func(val any) (any, error) { return MAddResult(val) }, []*time.Time{timePtr(1000), nil}},
+		{"MGet", MGetArgs(&MultiQuery{WithLabels: true, Filters: []string{"l!=2"}}), func(val any) (any, error) { return MGetResult(val) }, map[string]*MultiSample{
+			"a": {Labels: [][2]string{{"l", "1"}}, Samples: []*Sample{{Time: AtMilli(1000), Value: 1.5}}},
+			"b": {Labels: [][2]string{}},
+		}},
+		{"MRange", MRangeArgs(&MultiQuery{Filters: []string{"l!=2"}}), func(val any) (any, error) { return MRangeResult(val) }, map[string]*MultiSample{
+			"a": {Labels: [][2]string{}, Samples: []*Sample{{Time: AtMilli(1000), Value: 1}, {Time: AtMilli(2000), Value: 2}}},
+		}},
+		{"MRangeGroup", MRangeArgs(&MultiQuery{Filters: []string{"g=x"}, GroupByLabel: "g", Reducer: AggregateTypeSum}), func(val any) (any, error) { return MRangeGroupResult(val) }, map[string]*GroupedSeries{
+			"g=x": {Label: "g", Value: "x", Reducer: AggregateTypeSum, Sources: []string{"a", "b"}, Samples: []*Sample{{Time: AtMilli(1000), Value: 3}}},
+		}},
+		{"MRevRangeGroupMultiple", MRevRangeArgs(&MultiQuery{Filters: []string{"g!="}, GroupByLabel: "g", Reducer: AggregateTypeMax}), func(val any) (any, error) { return MRevRangeGroupResult(val) }, map[string]*GroupedSeries{
+			"g=x": {Label: "g", Value: "x", Reducer: AggregateTypeMax, Sources: []string{"a"}, Samples: []*Sample{{Time: AtMilli(2000), Value: 2}}},
+			"g=y": {Label: "g", Value: "y", Reducer: AggregateTypeMax, Sources: []string{"b", "c"}, Samples: []*Sample{}},
+		}},
+		{"QueryIndex", QueryIndexArgs([]string{"l!=2"}), func(val any) (any, error) { return QueryIndexResult(val) }, []string{"a", "b"}},
+		{"Range", RangeArgs("k", &MultiQuery{}), func(val any) (any, error) { return RangeResult(val) }, []*Sample{{Time: AtMilli(1000), Value: 1}, {Time: AtMilli(2000), Value: 2}}},
+	} {
+		redisstacktest.CheckExchange(t, exchanges, c.name, c.args, c.parse, c.exp)
+	}
+}
+
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+func (redisError) RedisError() {}
+
+func TestMAddDetailResult(t *testing.T) {
+	res, err := MAddDetailResult([]any{int64(1000), redisError("ERR TSDB: the key does not exist")})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(res) != 2 || res[0].Time.UnixMilli() != 1000 || res[0].Err != nil {
+		t.Errorf("unexpected first reply %+v", res[0])
+	} else if res[1].Time != nil || !errors.Is(res[1].Err, redisstack.ErrKeyNotFound) {
+		t.Errorf("unexpected second reply %+v", res[1])
+	}
+	if _, err := MAddDetailResult([]any{"x"}); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestResultErrors(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		parse func(any) (any, error)
+		val   any
+		path  string
+	}{
+		{"GetTime", func(val any) (any, error) { return GetResult(val) }, []any{"1000", "1"}, "[0]"},
+		{"GetValue", func(val any) (any, error) { return GetResult(val) }, []any{int64(1000), "x"}, "[1]"},
+		{"RangeSample", func(val any) (any, error) { return RangeResult(val) }, []any{[]any{int64(1000), "1"}, []any{int64(1000)}}, "[1]"},
+		{"InfoLabels", func(val any) (any, error) { return InfoResult(val) }, []any{"labels", []any{[]any{"a", int64(1)}}}, `["labels"][0][1]`},
+		{"InfoRules", func(val any) (any, error) { return InfoResult(val) }, []any{"rules", []any{[]any{int64(1), int64(2), "AVG"}}}, `["rules"][0]`},
+		{"MRangeKey", func(val any) (any, error) { return MRangeResult(val) }, []any{[]any{int64(1), []any{}, []any{}}}, "[0][0]"},
+		{"MRangeMapKey", func(val any) (any, error) { return MRangeResult(val) }, map[any]any{int64(1): []any{map[any]any{}, []any{}}}, `["1"]`},
+		{"MRangeSamples", func(val any) (any, error) { return MRangeResult(val) }, []any{[]any{"a", []any{}, []any{[]any{"x", "1"}}}}, "[0][2][0][0]"},
+		{"MRangeGroupSources", func(val any) (any, error) { return MRangeGroupResult(val) },
+			map[any]any{"g=x": []any{map[any]any{"g": "x"}, map[any]any{"sources": "a"}, []any{}}}, `["g=x"][1]["sources"]`},
+	} {
+		_, err := c.parse(c.val)
+		var perr *redisstack.ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%s: expected a parse error, got %v", c.name, err)
+		} else if perr.Path != c.path {
+			t.Errorf("%s: path %q, expected %q", c.name, perr.Path, c.path)
+		}
+	}
+}
+
+func TestFilters(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		filters []Filter
+		exp     []string
+		err     error
+	}{
+		{"Eq", []Filter{Eq("a", "1"), NotEq("b", "x y")}, []string{"a=1", "b!=x y"}, nil},
+		{"In", []Filter{In("a", "1", "2"), NotIn("b", "3")}, []string{"a=(1,2)", "b!=(3)"}, nil},
+		{"Exists", []Filter{Eq("a", "1"), Exists("b"), NotExists("c")}, []string{"a=1", "b!=", "c="}, nil},
+		{"NoPositive", []Filter{NotEq("a", "1"), NotExists("b")}, nil, ErrNoPositiveFilter},
+		{"EmptyIn", []Filter{In("a")}, nil, ErrInvalidFilter},
+		{"LabelChars", []Filter{Eq("a=b", "1")}, nil, ErrInvalidFilter},
+		{"Comma", []Filter{Eq("a", "1,2")}, nil, ErrInvalidFilter},
+		{"Paren", []Filter{In("a", "1", "(2)")}, nil, ErrInvalidFilter},
+		{"Quote", []Filter{Eq("a", `"1"`)}, nil, ErrInvalidFilter},
+	} {
+		res, err := Filters(c.filters...)
+		if !errors.Is(err, c.err) {
+			t.Errorf("%s: %v", c.name, err)
+		} else if !reflect.DeepEqual(res, c.exp) {
+			t.Errorf("%s: %#v", c.name, res)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	min, zero := 1.0, 0
+	for _, c := range []struct {
+		name  string
+		q     *MultiQuery
+		group bool
+		exp   error
+	}{
+		{"Valid", &MultiQuery{Filters: []string{"a=1"}}, false, nil},
+		{"NoFilters", &MultiQuery{}, false, ErrNoPositiveFilter},
+		{"ServerTime", &MultiQuery{FromTime: ServerTime, Filters: []string{"a=1"}}, false, ErrInvalidQuery},
+		{"HalfValueFilter", &MultiQuery{FilterByValueMin: &min, Filters: []string{"a=1"}}, false, ErrInvalidQuery},
+		{"ZeroCount", &MultiQuery{Count: &zero, Filters: []string{"a=1"}}, false, ErrInvalidQuery},
+		{"FilterByServerTime", &MultiQuery{FiltersByTime: []Timestamp{AtMilli(1), ServerTime}, Filters: []string{"a=1"}}, false, ErrInvalidQuery},
+		{"NoAggregator", &MultiQuery{Aggregation: &MultiQueryAggregation{BucketDuration: time.Second}, Filters: []string{"a=1"}}, false, ErrInvalidQuery},
+		{"GroupWithoutReducer", &MultiQuery{GroupByLabel: "g", Filters: []string{"a=1"}}, false, ErrInvalidReducer},
+		{"ReducerWithoutGroup", &MultiQuery{Reducer: AggregateTypeSum, Filters: []string{"a=1"}}, false, ErrInvalidQuery},
+		{"BadReducer", &MultiQuery{GroupByLabel: "g", Reducer: AggregateTypeFirst, Filters: []string{"a=1"}}, false, ErrInvalidReducer},
+		{"Group", &MultiQuery{GroupByLabel: "g", Reducer: AggregateTypeSum, Filters: []string{"a=1"}}, true, nil},
+		{"GroupMissing", &MultiQuery{Filters: []string{"a=1"}}, true, ErrInvalidQuery},
+		{"GroupNoFilters", &MultiQuery{GroupByLabel: "g", Reducer: AggregateTypeSum}, true, ErrNoPositiveFilter},
+	} {
+		validate := c.q.Validate
+		if c.group {
+			validate = c.q.ValidateGroup
+		}
+		if err := validate(); !errors.Is(err, c.exp) {
+			t.Errorf("%s: %v", c.name, err)
+		}
+	}
+}
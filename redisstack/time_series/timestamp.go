@@ -0,0 +1,80 @@
+package redisstack
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+type TimestampKind byte
+
+const (
+	TimestampKindDefault = TimestampKind(iota)
+	TimestampKindExplicit
+	TimestampKindServer
+	TimestampKindEarliest
+	TimestampKindLatest
+)
+
+var timestampKindNames = map[TimestampKind]string{
+	TimestampKindServer:   "*",
+	TimestampKindEarliest: "-",
+	TimestampKindLatest:   "+",
+}
+
+type Timestamp struct {
+	Kind TimestampKind
+	Time time.Time
+}
+
+var (
+	ServerTime = Timestamp{Kind: TimestampKindServer}
+	Earliest   = Timestamp{Kind: TimestampKindEarliest}
+	Latest     = Timestamp{Kind: TimestampKindLatest}
+)
+
+func At(t time.Time) Timestamp {
+	return Timestamp{TimestampKindExplicit, t}
+}
+
+func AtMilli(mt int64) Timestamp {
+	return Timestamp{TimestampKindExplicit, time.UnixMilli(mt)}
+}
+
+func (ts Timestamp) IsExplicit() bool {
+	return ts.Kind == TimestampKindExplicit
+}
+
+func (ts Timestamp) or(kind TimestampKind) Timestamp {
+	if ts.Kind == TimestampKindDefault {
+		ts.Kind = kind
+	}
+	return ts
+}
+
+func (ts Timestamp) UnixMilli() int64 {
+	switch ts.Kind {
+	case TimestampKindExplicit:
+		return ts.Time.UnixMilli()
+	case TimestampKindLatest:
+		return math.MaxInt64
+	}
+	return 0
+}
+
+func (ts Timestamp) arg() any {
+	if ts.Kind == TimestampKindExplicit {
+		return ts.Time.UnixMilli()
+	}
+	return timestampKindNames[ts.Kind]
+}
+
+func (ts Timestamp) String() string {
+	switch ts.Kind {
+	case TimestampKindDefault:
+		return ""
+	case TimestampKindExplicit:
+		return strconv.FormatInt(ts.Time.UnixMilli(), 10)
+	}
+	return timestampKindNames[ts.Kind]
+}
@@ -0,0 +1,510 @@
+package redisstack
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+)
+
+var ErrInvalidFormat = errors.New("invalid transfer format")
+
+type TransferFormat byte
+
+const (
+	TransferFormatOpenMetrics = TransferFormat(iota)
+	TransferFormatCSV
+)
+
+type RuleMeta struct {
+	DestKey        string `json:"dest_key"`
+	Aggregator     string `json:"aggregator"`
+	BucketDuration int64  `json:"bucket_duration"`
+	AlignTime      int64  `json:"align_time,omitempty"`
+}
+
+type SeriesMeta struct {
+	Key          string      `json:"key"`
+	Retention    int64       `json:"retention"`
+	ChunkSize    int64       `json:"chunk_size,omitempty"`
+	Uncompressed bool        `json:"uncompressed,omitempty"`
+	DupPolicy    string      `json:"duplicate_policy,omitempty"`
+	Labels       [][2]string `json:"labels"`
+	Rules        []*RuleMeta `json:"rules,omitempty"`
+}
+
+func newSeriesMeta(key string, info *Info) *SeriesMeta {
+	meta := &SeriesMeta{
+		Key:          key,
+		Retention:    info.Retention.Milliseconds(),
+		ChunkSize:    info.ChunkSize,
+		Uncompressed: info.ChunkType == "uncompressed",
+		DupPolicy:    dupPolicyNames[info.DupPolicy],
+		Labels:       info.Labels,
+	}
+	if meta.Labels == nil {
+		meta.Labels = [][2]string{}
+	}
+	for _, rule := range info.Rules {
+		meta.Rules = append(meta.Rules, &RuleMeta{
+			DestKey:        rule.DestKey,
+			Aggregator:     aggregateTypeNames[rule.Aggregator],
+			BucketDuration: rule.BucketDuration.Milliseconds(),
+			AlignTime:      rule.AlignTime.Milliseconds(),
+		})
+	}
+	return meta
+}
+
+func (meta *SeriesMeta) option(dupPolicy DupPolicy) *Option {
+	retention := time.Duration(meta.Retention) * time.Millisecond
+	opt := &Option{Retention: &retention, Uncompressed: meta.Uncompressed, DupPolicy: parseDupPolicy(meta.DupPolicy), Labels: meta.Labels}
+	if meta.ChunkSize > 0 {
+		opt.ChunkSize = &meta.ChunkSize
+	}
+	if dupPolicy != DupPolicyNone {
+		opt.DupPolicy = dupPolicy
+	}
+	return opt
+}
+
+type ExportOption struct {
+	Format   TransferFormat
+	Filters  []string
+	FromTime Timestamp
+	ToTime   Timestamp
+	PageSize int
+}
+
+type exportWriter interface {
+	writeMeta(meta *SeriesMeta) error
+	writeSample(meta *SeriesMeta, sample *Sample) error
+	close() error
+}
+
+type openMetricsWriter struct {
+	w        *bufio.Writer
+	families map[string]struct{}
+	family   string
+}
+
+const openMetricsMetaSuffix = "_series"
+
+var openMetricsEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func metricName(key string) string {
+	b := []byte(key)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == ':' || c >= '0' && c <= '9' && i > 0) {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
+
+func formatOpenMetricsValue(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func (w *openMetricsWriter) reserve(base string) string {
+	name := base
+	for i := 2; ; i++ {
+		_, ok1 := w.families[name]
+		_, ok2 := w.families[name+openMetricsMetaSuffix]
+		if !ok1 && !ok2 {
+			break
+		}
+		name = base + "_" + strconv.Itoa(i)
+	}
+	w.families[name], w.families[name+openMetricsMetaSuffix] = struct{}{}, struct{}{}
+	return name
+}
+
+func (w *openMetricsWriter) writeMeta(meta *SeriesMeta) error {
+	w.family = w.reserve(metricName(meta.Key))
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	key, metaFamily := openMetricsEscaper.Replace(meta.Key), w.family+openMetricsMetaSuffix
+	_, err = fmt.Fprintf(w.w, "# TYPE %s info\n# HELP %s Metadata of time series %s.\n%s_info{__key__=\"%s\",meta=\"%s\"} 1\n",
+		metaFamily, metaFamily, key, metaFamily, key, openMetricsEscaper.Replace(string(b)))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w.w, "# TYPE %s unknown\n# HELP %s Samples of time series %s.\n", w.family, w.family, key)
+	return err
+}
+
+func (w *openMetricsWriter) writeSample(meta *SeriesMeta, sample *Sample) error {
+	w.w.WriteString(w.family)
+	w.w.WriteString(`{__key__="`)
+	w.w.WriteString(openMetricsEscaper.Replace(meta.Key))
+	w.w.WriteByte('"')
+	for _, label := range meta.Labels {
+		if label[0] == "__key__" {
+			continue
+		}
+		fmt.Fprintf(w.w, `,%s="%s"`, metricName(label[0]), openMetricsEscaper.Replace(label[1]))
+	}
+	mt := sample.Time.UnixMilli()
+	_, err := fmt.Fprintf(w.w, "} %s %d.%03d\n", formatOpenMetricsValue(sample.Value), mt/1000, mt%1000)
+	return err
+}
+
+func (w *openMetricsWriter) close() error {
+	w.w.WriteString("# EOF\n")
+	return w.w.Flush()
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+var csvHeader = []string{"kind", "key", "timestamp", "value", "meta"}
+
+func (w *csvWriter) writeMeta(meta *SeriesMeta) error {
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return w.w.Write([]string{"series", meta.Key, "", "", string(b)})
+}
+
+func (w *csvWriter) writeSample(meta *SeriesMeta, sample *Sample) error {
+	return w.w.Write([]string{"sample", meta.Key, strconv.FormatInt(sample.Time.UnixMilli(), 10),
+		strconv.FormatFloat(sample.Value, 'g', -1, 64), ""})
+}
+
+func (w *csvWriter) close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func Export(ctx context.Context, red redis.UniversalClient, w io.Writer, opt *ExportOption) error {
+	if opt == nil {
+		opt = &ExportOption{}
+	}
+	q := &MultiQuery{FromTime: opt.FromTime, ToTime: opt.ToTime, Filters: opt.Filters}
+	if err := q.Validate(); err != nil {
+		return err
+	}
+	var ew exportWriter
+	switch opt.Format {
+	case TransferFormatOpenMetrics:
+		ew = &openMetricsWriter{w: bufio.NewWriter(w), families: map[string]struct{}{}}
+	case TransferFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		ew = &csvWriter{cw}
+	default:
+		return ErrInvalidFormat
+	}
+
+	cmd := red.Do(ctx, QueryIndexArgs(opt.Filters)...)
+	if err := cmd.Err(); err != nil {
+		return redisstack.ClassifyError(err)
+	}
+	keys, err := QueryIndexResult(cmd.Val())
+	if err != nil {
+		return err
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		info, err := info(ctx, red, key)
+		if err != nil {
+			return err
+		} else if info == nil {
+			continue
+		}
+		meta := newSeriesMeta(key, info)
+		if err = ew.writeMeta(meta); err != nil {
+			return err
+		}
+		it := NewRangeIterator(red, key, &MultiQuery{FromTime: q.FromTime, ToTime: q.ToTime}, opt.PageSize)
+		for it.Next(ctx) {
+			if err = ew.writeSample(meta, it.Sample()); err != nil {
+				return err
+			}
+		}
+		if err = it.Err(); err != nil {
+			return redisstack.ClassifyError(err)
+		}
+	}
+	return ew.close()
+}
+
+type ImportOption struct {
+	Format    TransferFormat
+	BatchSize int
+	DupPolicy DupPolicy
+	SkipRules bool
+	OnError   func(sample *Sample, err error)
+}
+
+type ImportStats struct {
+	Series  int
+	Samples int
+	Failed  int
+	Rules   int
+}
+
+type importer struct {
+	red     redis.UniversalClient
+	opt     *ImportOption
+	stats   *ImportStats
+	metas   map[string]*SeriesMeta
+	rules   []*SeriesMeta
+	samples []*Sample
+}
+
+func (im *importer) addMeta(ctx context.Context, meta *SeriesMeta) error {
+	if len(meta.Key) == 0 {
+		return ErrInvalidFormat
+	}
+	opt := meta.option(im.opt.DupPolicy)
+	err := redisstack.ClassifyError(im.red.Do(ctx, CreateArgs(meta.Key, opt)...).Err())
+	if errors.Is(err, redisstack.ErrKeyExists) {
+		opt.ChunkSize, opt.Uncompressed = nil, false
+		err = redisstack.ClassifyError(im.red.Do(ctx, AlterArgs(meta.Key, opt)...).Err())
+	}
+	if err != nil {
+		return err
+	}
+	im.metas[meta.Key] = meta
+	if len(meta.Rules) > 0 {
+		im.rules = append(im.rules, meta)
+	}
+	im.stats.Series++
+	return nil
+}
+
+func (im *importer) addSample(ctx context.Context, key string, t int64, value float64) error {
+	if _, ok := im.metas[key]; !ok {
+		return fmt.Errorf("%w: sample for undeclared series %q", ErrInvalidFormat, key)
+	}
+	im.samples = append(im.samples, &Sample{Key: key, Time: AtMilli(t), Value: value})
+	if len(im.samples) >= im.opt.BatchSize {
+		return im.flush(ctx)
+	}
+	return nil
+}
+
+func (im *importer) flush(ctx context.Context) error {
+	if len(im.samples) == 0 {
+		return nil
+	}
+	replies, err := MAddAutoCreate(ctx, im.red, im.samples, nil)
+	if err != nil {
+		return redisstack.ClassifyError(err)
+	}
+	for i, reply := range replies {
+		if reply.Err == nil {
+			im.stats.Samples++
+			continue
+		}
+		im.stats.Failed++
+		if im.opt.OnError != nil {
+			im.opt.OnError(im.samples[i], reply.Err)
+		}
+	}
+	im.samples = im.samples[:0]
+	return nil
+}
+
+func (im *importer) createRules(ctx context.Context) error {
+	if im.opt.SkipRules {
+		return nil
+	}
+	for _, meta := range im.rules {
+		for _, rule := range meta.Rules {
+			alignTime := time.Duration(rule.AlignTime) * time.Millisecond
+			args := CreateRuleArgs(meta.Key, rule.DestKey, parseAggregateType(rule.Aggregator),
+				time.Duration(rule.BucketDuration)*time.Millisecond, &alignTime)
+			if err := im.red.Do(ctx, args...).Err(); err != nil {
+				return redisstack.ClassifyError(err)
+			}
+			im.stats.Rules++
+		}
+	}
+	return nil
+}
+
+func parseOpenMetricsLabels(s string) (map[string]string, string, error) {
+	labels := map[string]string{}
+	for {
+		s = strings.TrimLeft(s, " ,")
+		if strings.HasPrefix(s, "}") {
+			return labels, s[1:], nil
+		}
+		i := strings.Index(s, `="`)
+		if i <= 0 {
+			return nil, "", ErrInvalidFormat
+		}
+		name, sb := s[:i], &strings.Builder{}
+		s = s[i+2:]
+		for i = 0; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				if s[i] == 'n' {
+					sb.WriteByte('\n')
+					continue
+				}
+			}
+			sb.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return nil, "", ErrInvalidFormat
+		}
+		labels[name], s = sb.String(), s[i+1:]
+	}
+}
+
+func (im *importer) importOpenMetrics(ctx context.Context, r io.Reader) error {
+	infos := map[string]struct{}{}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<24)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case len(line) == 0 || strings.HasPrefix(line, "# HELP ") || strings.HasPrefix(line, "# UNIT "):
+			continue
+		case line == "# EOF":
+			return nil
+		case strings.HasPrefix(line, "# TYPE "):
+			if parts := strings.Fields(line[7:]); len(parts) != 2 {
+				return ErrInvalidFormat
+			} else if parts[1] == "info" {
+				infos[parts[0]+"_info"] = struct{}{}
+			}
+		case strings.HasPrefix(line, "#"):
+			return ErrInvalidFormat
+		default:
+			i := strings.IndexByte(line, '{')
+			if i <= 0 {
+				return ErrInvalidFormat
+			}
+			labels, rest, err := parseOpenMetricsLabels(line[i+1:])
+			if err != nil {
+				return err
+			}
+			if _, ok := infos[line[:i]]; ok {
+				meta := &SeriesMeta{}
+				if err = json.Unmarshal([]byte(labels["meta"]), meta); err != nil {
+					return fmt.Errorf("%w: %s", ErrInvalidFormat, err.Error())
+				}
+				if err = im.addMeta(ctx, meta); err != nil {
+					return err
+				}
+				continue
+			}
+			key, ok := labels["__key__"]
+			fields := strings.Fields(rest)
+			if !ok || len(fields) != 2 {
+				return ErrInvalidFormat
+			}
+			value, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return ErrInvalidFormat
+			}
+			ts, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return ErrInvalidFormat
+			}
+			if err = im.addSample(ctx, key, int64(math.Round(ts*1000)), value); err != nil {
+				return err
+			}
+		}
+	}
+	return sc.Err()
+}
+
+func (im *importer) importCSV(ctx context.Context, r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+	if _, err := cr.Read(); err != nil {
+		return err
+	}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		switch record[0] {
+		case "series":
+			meta := &SeriesMeta{}
+			if err = json.Unmarshal([]byte(record[4]), meta); err != nil {
+				return fmt.Errorf("%w: %s", ErrInvalidFormat, err.Error())
+			}
+			if err = im.addMeta(ctx, meta); err != nil {
+				return err
+			}
+		case "sample":
+			t, err := strconv.ParseInt(record[2], 10, 64)
+			if err != nil {
+				return ErrInvalidFormat
+			}
+			value, err := strconv.ParseFloat(record[3], 64)
+			if err != nil {
+				return ErrInvalidFormat
+			}
+			if err = im.addSample(ctx, record[1], t, value); err != nil {
+				return err
+			}
+		default:
+			return ErrInvalidFormat
+		}
+	}
+}
+
+func Import(ctx context.Context, red redis.UniversalClient, r io.Reader, opt *ImportOption) (*ImportStats, error) {
+	im := &importer{red: red, opt: &ImportOption{}, stats: &ImportStats{}, metas: map[string]*SeriesMeta{}}
+	if opt != nil {
+		*im.opt = *opt
+	}
+	if im.opt.BatchSize <= 0 {
+		im.opt.BatchSize = 1000
+	}
+	var err error
+	switch im.opt.Format {
+	case TransferFormatOpenMetrics:
+		err = im.importOpenMetrics(ctx, r)
+	case TransferFormatCSV:
+		err = im.importCSV(ctx, r)
+	default:
+		err = ErrInvalidFormat
+	}
+	if err == nil {
+		err = im.flush(ctx)
+	}
+	if err == nil {
+		err = im.createRules(ctx)
+	}
+	return im.stats, err
+}
@@ -0,0 +1,148 @@
+package redisstack_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+	ts "github.com/ldeng7/go-redis-stack/redisstack/time_series"
+)
+
+type seriesState struct {
+	retention time.Duration
+	dupPolicy ts.DupPolicy
+	labels    [][2]string
+	rules     []*ts.Rule
+	samples   []int64
+}
+
+func readSeries(t *testing.T, red *redis.Client, key string) *seriesState {
+	t.Helper()
+	ctx := context.Background()
+	info, err := ts.InfoResult(red.Do(ctx, ts.InfoArgs(key, false)...).Val())
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples, err := ts.RangeResult(red.Do(ctx, ts.RangeArgs(key, &ts.MultiQuery{})...).Val())
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := &seriesState{retention: info.Retention, dupPolicy: info.DupPolicy, labels: info.Labels, rules: info.Rules}
+	for _, s := range samples {
+		st.samples = append(st.samples, s.Time.UnixMilli(), int64(s.Value))
+	}
+	return st
+}
+
+func TestTransferRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		retention := time.Hour
+		for _, args := range [][]any{
+			ts.CreateArgs("src", &ts.Option{Retention: &retention, DupPolicy: ts.DupPolicyLast, Labels: [][2]string{{"app", "web"}, {"note", "a \"b\"\nc"}}}),
+			ts.CreateArgs("dst", &ts.Option{Labels: [][2]string{{"app", "web"}, {"kind", "agg"}}}),
+			ts.CreateArgs("other", &ts.Option{Labels: [][2]string{{"app", "db"}}}),
+			ts.CreateRuleArgs("src", "dst", ts.AggregateTypeSum, 10*time.Millisecond, nil),
+		} {
+			if err := red.Do(ctx, args...).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for mt := int64(0); mt <= 25; mt += 5 {
+			if err := red.Do(ctx, ts.AddArgs(&ts.Sample{Key: "src", Time: ts.AtMilli(mt), Value: float64(mt)}, nil)...).Err(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		exp := map[string]*seriesState{"src": readSeries(t, red, "src"), "dst": readSeries(t, red, "dst")}
+
+		for _, format := range []ts.TransferFormat{ts.TransferFormatOpenMetrics, ts.TransferFormatCSV} {
+			buf := &bytes.Buffer{}
+			if err := ts.Export(ctx, red, buf, &ts.ExportOption{Format: format, Filters: []string{"app=web"}, PageSize: 2}); err != nil {
+				t.Fatal(err)
+			}
+			if format == ts.TransferFormatOpenMetrics {
+				for _, line := range strings.Split(buf.String(), "\n") {
+					if strings.HasPrefix(line, "# HELP ") && strings.Contains(line, "{") {
+						t.Errorf("HELP is not plain text: %s", line)
+					}
+				}
+			}
+
+			red1 := redisstacktest.NewTestClient(t, 3)
+			stats, err := ts.Import(ctx, red1, bytes.NewReader(buf.Bytes()), &ts.ImportOption{Format: format, BatchSize: 3})
+			if err != nil {
+				t.Fatalf("%d: %v\n%s", format, err, buf.String())
+			} else if *stats != (ts.ImportStats{Series: 2, Samples: len(exp["src"].samples)/2 + len(exp["dst"].samples)/2, Rules: 1}) {
+				t.Errorf("%d: stats %+v", format, stats)
+			}
+			for key, st := range exp {
+				if got := readSeries(t, red1, key); !reflect.DeepEqual(got, st) {
+					t.Errorf("%d %s: got %+v, expected %+v", format, key, got, st)
+				}
+			}
+
+			var rejected []error
+			stats, err = ts.Import(ctx, red1, bytes.NewReader(buf.Bytes()), &ts.ImportOption{
+				Format: format, DupPolicy: ts.DupPolicyBlock, SkipRules: true,
+				OnError: func(sample *ts.Sample, err error) { rejected = append(rejected, err) },
+			})
+			if err != nil {
+				t.Fatal(err)
+			} else if stats.Samples != 0 || stats.Failed != len(rejected) || len(rejected) == 0 {
+				t.Errorf("%d: reimport stats %+v, %d rejected", format, stats, len(rejected))
+			}
+			for _, err := range rejected {
+				if !errors.Is(err, redisstack.ErrDuplicateSample) {
+					t.Errorf("%d: %v", format, err)
+				}
+			}
+		}
+	})
+}
+
+func TestExportValidate(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range []struct {
+		name string
+		opt  *ts.ExportOption
+		exp  error
+	}{
+		{"NoFilters", &ts.ExportOption{}, ts.ErrNoPositiveFilter},
+		{"ServerTime", &ts.ExportOption{Filters: []string{"a=1"}, FromTime: ts.ServerTime}, ts.ErrInvalidQuery},
+		{"Format", &ts.ExportOption{Filters: []string{"a=1"}, Format: ts.TransferFormat(9)}, ts.ErrInvalidFormat},
+	} {
+		if err := ts.Export(ctx, nil, &bytes.Buffer{}, c.opt); !errors.Is(err, c.exp) {
+			t.Errorf("%s: %v", c.name, err)
+		}
+	}
+}
+
+func TestTransferNilOption(t *testing.T) {
+	ctx := context.Background()
+	if err := ts.Export(ctx, nil, &bytes.Buffer{}, nil); !errors.Is(err, ts.ErrNoPositiveFilter) {
+		t.Errorf("export: %v", err)
+	}
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		addSamples(t, red, "a", "1", 1, 3)
+		buf := &bytes.Buffer{}
+		if err := ts.Export(ctx, red, buf, &ts.ExportOption{Filters: []string{"l=1"}}); err != nil {
+			t.Fatal(err)
+		}
+		red1 := redisstacktest.NewTestClient(t, 3)
+		if stats, err := ts.Import(ctx, red1, buf, nil); err != nil {
+			t.Fatal(err)
+		} else if *stats != (ts.ImportStats{Series: 1, Samples: 3}) {
+			t.Errorf("stats %+v", stats)
+		}
+		if got := readSeries(t, red1, "a"); !reflect.DeepEqual(got.samples, []int64{1, 1, 2, 2, 3, 3}) {
+			t.Errorf("samples %v", got.samples)
+		}
+	})
+}
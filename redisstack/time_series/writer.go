@@ -0,0 +1,156 @@
+package redisstack
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+)
+
+var ErrWriterClosed = errors.New("writer closed")
+
+type WriterOption struct {
+	BatchSize     int
+	FlushInterval time.Duration
+	QueueSize     int
+	MaxPipeline   int
+	Resolver      OptionResolver
+	OnError       func(sample *Sample, err error)
+}
+
+type Writer struct {
+	red     redis.UniversalClient
+	opt     WriterOption
+	ctx     context.Context
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	closed  bool
+	writers sync.WaitGroup
+	queue   chan *Sample
+	done    chan struct{}
+}
+
+func NewWriter(red redis.UniversalClient, opt *WriterOption) *Writer {
+	w := &Writer{red: red, done: make(chan struct{})}
+	if opt != nil {
+		w.opt = *opt
+	}
+	if w.opt.BatchSize <= 0 {
+		w.opt.BatchSize = 1000
+	}
+	if w.opt.FlushInterval <= 0 {
+		w.opt.FlushInterval = 100 * time.Millisecond
+	}
+	if w.opt.QueueSize <= 0 {
+		w.opt.QueueSize = w.opt.BatchSize * 10
+	}
+	if w.opt.MaxPipeline <= 0 {
+		w.opt.MaxPipeline = 8
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	w.queue = make(chan *Sample, w.opt.QueueSize)
+	go w.run()
+	return w
+}
+
+func (w *Writer) Write(ctx context.Context, samples ...*Sample) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrWriterClosed
+	}
+	w.writers.Add(1)
+	w.mu.Unlock()
+	defer w.writers.Done()
+	for _, sample := range samples {
+		select {
+		case w.queue <- sample:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.ctx.Done():
+			return ErrWriterClosed
+		}
+	}
+	return nil
+}
+
+func (w *Writer) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		go func() {
+			w.writers.Wait()
+			close(w.queue)
+		}()
+	}
+	w.mu.Unlock()
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		return ctx.Err()
+	}
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+	defer w.cancel()
+	var batches [][]*Sample
+	var cur []*Sample
+	var timer *time.Timer
+	var timerC <-chan time.Time
+	for {
+		select {
+		case sample, ok := <-w.queue:
+			if !ok {
+				w.flush(append(batches, cur))
+				return
+			}
+			if cur = append(cur, sample); len(cur) == 1 && timerC == nil {
+				timer = time.NewTimer(w.opt.FlushInterval)
+				timerC = timer.C
+			}
+			if len(cur) >= w.opt.BatchSize {
+				batches, cur = append(batches, cur), nil
+			}
+			if len(batches) >= w.opt.MaxPipeline || (len(batches) > 0 && len(w.queue) == 0) {
+				w.flush(batches)
+				batches = nil
+			}
+		case <-timerC:
+			timerC = nil
+			w.flush(append(batches, cur))
+			batches, cur = nil, nil
+		}
+		if len(cur) == 0 && timerC != nil {
+			timer.Stop()
+			timerC = nil
+		}
+	}
+}
+
+func (w *Writer) flush(batches [][]*Sample) {
+	n := 0
+	for _, batch := range batches {
+		n += len(batch)
+	}
+	if n == 0 {
+		return
+	}
+	res, err := mAdd(w.ctx, w.red, batches, w.opt.Resolver)
+	if w.opt.OnError == nil {
+		return
+	}
+	for i, batch := range batches {
+		for j, sample := range batch {
+			if err != nil {
+				w.opt.OnError(sample, err)
+			} else if res[i][j].Err != nil {
+				w.opt.OnError(sample, res[i][j].Err)
+			}
+		}
+	}
+}
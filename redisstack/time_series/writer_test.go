@@ -0,0 +1,161 @@
+package redisstack_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v9"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+	ts "github.com/ldeng7/go-redis-stack/redisstack/time_series"
+)
+
+func waitSamples(t *testing.T, red *redis.Client, key string, n int, timeout time.Duration) time.Duration {
+	t.Helper()
+	start := time.Now()
+	for {
+		samples, err := ts.RangeResult(red.Do(context.Background(), ts.RangeArgs(key, &ts.MultiQuery{})...).Val())
+		if err == nil && len(samples) >= n {
+			return time.Since(start)
+		} else if time.Since(start) > timeout {
+			t.Fatalf("%d samples of %s not written in %v: %v %v", n, key, timeout, len(samples), err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func writeSamples(t *testing.T, w *ts.Writer, key string, from, to int64) {
+	t.Helper()
+	for mt := from; mt <= to; mt++ {
+		if err := w.Write(context.Background(), &ts.Sample{Key: key, Time: ts.AtMilli(mt), Value: float64(mt)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func autoCreate(string) *ts.Option {
+	return &ts.Option{}
+}
+
+func TestWriterSizeFlush(t *testing.T) {
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		w := ts.NewWriter(red, &ts.WriterOption{BatchSize: 5, FlushInterval: time.Hour, Resolver: autoCreate})
+		defer w.Close(context.Background())
+		writeSamples(t, w, "k", 1, 5)
+		waitSamples(t, red, "k", 5, time.Second)
+	})
+}
+
+func TestWriterIntervalFlush(t *testing.T) {
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		w := ts.NewWriter(red, &ts.WriterOption{BatchSize: 1000, FlushInterval: 20 * time.Millisecond, Resolver: autoCreate})
+		defer w.Close(context.Background())
+		writeSamples(t, w, "k", 1, 3)
+		waitSamples(t, red, "k", 3, time.Second)
+	})
+}
+
+func TestWriterClose(t *testing.T) {
+	ctx := context.Background()
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		var mu sync.Mutex
+		var errs []error
+		w := ts.NewWriter(red, &ts.WriterOption{FlushInterval: time.Hour, OnError: func(sample *ts.Sample, err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}})
+		if err := red.Do(ctx, ts.CreateArgs("k", nil)...).Err(); err != nil {
+			t.Fatal(err)
+		}
+		writeSamples(t, w, "k", 1, 10)
+		if err := w.Write(ctx, &ts.Sample{Key: "missing", Time: ts.AtMilli(1), Value: 1}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(ctx); err != nil {
+			t.Fatal(err)
+		}
+		waitSamples(t, red, "k", 10, 0)
+		if len(errs) != 1 {
+			t.Errorf("errors: %v", errs)
+		}
+		if err := w.Write(ctx, &ts.Sample{Key: "k", Value: 1}); !errors.Is(err, ts.ErrWriterClosed) {
+			t.Errorf("write after close: %v", err)
+		}
+		if err := w.Close(ctx); err != nil {
+			t.Errorf("second close: %v", err)
+		}
+	})
+}
+
+func TestWriterNilOption(t *testing.T) {
+	redisstacktest.ForEachProtocol(t, func(t *testing.T, red *redis.Client) {
+		if err := red.Do(context.Background(), ts.CreateArgs("k", nil)...).Err(); err != nil {
+			t.Fatal(err)
+		}
+		w := ts.NewWriter(red, nil)
+		writeSamples(t, w, "k", 1, 2)
+		if err := w.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		waitSamples(t, red, "k", 2, 0)
+	})
+}
+
+func newStalledClient(t *testing.T) *redis.Client {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+	red := redis.NewClient(&redis.Options{Addr: l.Addr().String(), ReadTimeout: 200 * time.Millisecond, MaxRetries: -1})
+	t.Cleanup(func() { red.Close() })
+	return red
+}
+
+func TestWriterBackpressure(t *testing.T) {
+	w := ts.NewWriter(newStalledClient(t), &ts.WriterOption{BatchSize: 1, QueueSize: 1, MaxPipeline: 1})
+	sample := &ts.Sample{Key: "k", Value: 1}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		err = w.Write(ctx, sample)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("write on a full queue: %v", err)
+	}
+
+	blocked := make(chan error, 1)
+	go func() { blocked <- w.Write(context.Background(), sample) }()
+	time.Sleep(20 * time.Millisecond)
+	ctx1, cancel1 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel1()
+	start := time.Now()
+	if err := w.Close(ctx1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("close: %v", err)
+	} else if d := time.Since(start); d > 150*time.Millisecond {
+		t.Errorf("close took %v past its deadline", d)
+	}
+	select {
+	case err := <-blocked:
+		if err != nil && !errors.Is(err, ts.ErrWriterClosed) {
+			t.Errorf("blocked write: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("blocked write did not return after close")
+	}
+}
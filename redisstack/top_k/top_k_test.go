@@ -0,0 +1,73 @@
+package top_k
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ldeng7/go-redis-stack/redisstack"
+	"github.com/ldeng7/go-redis-stack/redisstack/redisstacktest"
+)
+
+func TestArgs(t *testing.T) {
+	for _, c := range []struct {
+		args []any
+		exp  []any
+	}{
+		{AddArgs("k", []string{"a", "b"}), []any{"TOPK.ADD", "k", "a", "b"}},
+		{CountArgs("k", []string{"a"}), []any{"TOPK.COUNT", "k", "a"}},
+		{IncrByArgs("k", []redisstack.ItemAmount{{Item: "a", Amount: 3}}), []any{"TOPK.INCRBY", "k", "a", int64(3)}},
+		{InfoArgs("k"), []any{"TOPK.INFO", "k"}},
+		{ListArgs("k"), []any{"TOPK.LIST", "k"}},
+		{ListWithCountArgs("k"), []any{"TOPK.LIST", "k", "WITHCOUNT"}},
+		{QueryArgs("k", []string{"a", "b"}), []any{"TOPK.QUERY", "k", "a", "b"}},
+		{ReserveArgs("k", 10, nil), []any{"TOPK.RESERVE", "k", int64(10)}},
+		{ReserveArgs("k", 10, &Info{Width: 50, Depth: 4, Decay: 0.9}), []any{"TOPK.RESERVE", "k", int64(10), int64(50), int64(4), 0.9}},
+	} {
+		if !reflect.DeepEqual(c.args, c.exp) {
+			t.Errorf("got %#v, expected %#v", c.args, c.exp)
+		}
+	}
+}
+
+func TestReplies(t *testing.T) {
+	exchanges, err := redisstacktest.LoadExchanges("testdata/replies.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expelled := "b"
+	for _, c := range []struct {
+		name  string
+		args  []any
+		parse func(any) (any, error)
+		exp   any
+	}{
+		{"Add", AddArgs("k", []string{"a", "c"}), func(val any) (any, error) { return AddResult(val) }, []*string{nil, &expelled}},
+		{"Count", CountArgs("k", []string{"a", "c"}), func(val any) (any, error) { return CountResult(val) }, []int64{3, 0}},
+		{"IncrBy", IncrByArgs("k", []redisstack.ItemAmount{{Item: "a", Amount: 2}}), func(val any) (any, error) { return IncrByResult(val) }, []*string{nil}},
+		{"Info", InfoArgs("k"), func(val any) (any, error) { return InfoResult(val) }, &Info{K: 10, Width: 50, Depth: 4, Decay: 0.9, Extra: map[string]any{}}},
+		{"List", ListArgs("k"), func(val any) (any, error) { return ListResult(val) }, []string{"a", "b"}},
+		{"ListWithCount", ListWithCountArgs("k"), func(val any) (any, error) { return ListWithCountResult(val) }, []redisstack.ItemAmount{{Item: "a", Amount: 3}, {Item: "b", Amount: 2}}},
+		{"Query", QueryArgs("k", []string{"a", "c"}), func(val any) (any, error) { return QueryResult(val) }, []bool{true, false}},
+	} {
+		redisstacktest.CheckExchange(t, exchanges, c.name, c.args, c.parse, c.exp)
+	}
+}
+
+func TestResultErrors(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		parse func(any) (any, error)
+		val   any
+	}{
+		{"Add", func(val any) (any, error) { return AddResult(val) }, []any{int64(1)}},
+		{"Info", func(val any) (any, error) { return InfoResult(val) }, []any{"decay", "x"}},
+		{"ListWithCountItem", func(val any) (any, error) { return ListWithCountResult(val) }, []any{int64(1), int64(3)}},
+		{"ListWithCountAmount", func(val any) (any, error) { return ListWithCountResult(val) }, []any{"a", "3"}},
+	} {
+		var perr *redisstack.ParseError
+		if _, err := c.parse(c.val); !errors.As(err, &perr) {
+			t.Errorf("%s: expected a parse error, got %v", c.name, err)
+		}
+	}
+}